@@ -0,0 +1,624 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Accounts give players a home on the leaderboard server. 'account
+// register' creates one and keeps its token in the profile; from then on
+// every finished game is recorded on the account, leaderboard submissions
+// go out under the account's name, and 'account' shows the profile the
+// server keeps: totals and best scores by mode, a versus rating of its own
+// and the match history.
+//
+// The server stores only a hash of each token. Accounts are kept like the
+// boards, as a JSONL file replayed on startup: a line creating an account,
+// then a line for every game played on it. The rating is the lobby's to
+// move, see ladder.go; it shares the file, so each side catches up on the
+// other's lines before reading.
+
+const (
+	// accountHistory is how many recent games the profile lists
+	accountHistory = 20
+	accountFile    = "account.json"
+)
+
+// accountGame is one game on an account.
+type accountGame struct {
+	Time     time.Time     `json:"time"`
+	Mode     string        `json:"mode"`
+	Duration time.Duration `json:"duration"`
+	Score    int           `json:"score"`
+	Level    int           `json:"level"`
+	Words    int           `json:"words"`
+	WPM      int           `json:"wpm"`
+	Accuracy float64       `json:"accuracy"`
+	Opponent string        `json:"opponent,omitempty"`
+	Won      bool          `json:"won,omitempty"`
+	// Rated versus matches moved the account's rating by the opponent's
+	// rating; Rating is the account's rating after the match. Only older
+	// files have them: ratings now come from the lobby's ladderMatch lines
+	Rated          bool `json:"rated,omitempty"`
+	OpponentRating int  `json:"opponent_rating,omitempty"`
+	Rating         int  `json:"rating,omitempty"`
+	// Replay is the archive URL of the game's uploaded replay
+	Replay string `json:"replay,omitempty"`
+}
+
+// accountMode sums up an account's games in one mode.
+type accountMode struct {
+	Mode  string `json:"mode"`
+	Games int    `json:"games"`
+	Wins  int    `json:"wins,omitempty"`
+	Best  int    `json:"best"`
+	// BestWPM is the fastest game's WPM
+	BestWPM int `json:"best_wpm"`
+}
+
+// accountProfile is the reply to GET /account.
+type accountProfile struct {
+	ID      string        `json:"id"`
+	Name    string        `json:"name"`
+	Created time.Time     `json:"created"`
+	Rating  rating        `json:"rating"`
+	Games   int           `json:"games"`
+	Played  time.Duration `json:"played"`
+	Modes   []accountMode `json:"modes"`
+	// History is the latest games, newest first
+	History []accountGame `json:"history"`
+}
+
+// accountLink is the player's side of an account, kept in the profile.
+type accountLink struct {
+	Server string `json:"server"`
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Token  string `json:"token"`
+}
+
+// Server
+
+// accountRecord is one line of the accounts file: an account being
+// created, a game played on one, or a match the lobby rated.
+type accountRecord struct {
+	ID        string       `json:"id"`
+	Name      string       `json:"name,omitempty"`
+	TokenHash string       `json:"token_hash,omitempty"`
+	Created   time.Time    `json:"created,omitzero"`
+	Game      *accountGame `json:"game,omitempty"`
+	Match     *ladderMatch `json:"match,omitempty"`
+}
+
+type account struct {
+	id      string
+	name    string
+	created time.Time
+	rating  rating
+	games   []accountGame
+}
+
+type accountStore struct {
+	mu       sync.Mutex
+	path     string
+	accounts map[string]*account
+	// byToken maps token hashes to account ids, and byName folded names,
+	// see nameKey
+	byToken map[string]string
+	byName  map[string]string
+	// read is how far into the file has been applied, see catchUp
+	read int64
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func openAccountStore(path string) (*accountStore, error) {
+	s := &accountStore{path: path, accounts: map[string]*account{}, byToken: map[string]string{}, byName: map[string]string{}}
+	if err := s.catchUp(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// catchUp applies the lines appended to the file since it last read it,
+// by this process or another sharing the file. A line still being written
+// waits for the next time. The caller holds s.mu, or has the store to
+// itself.
+func (s *accountStore) catchUp() error {
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.Seek(s.read, io.SeekStart); err != nil {
+		return err
+	}
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		s.read += int64(len(line))
+		var r accountRecord
+		if err := json.Unmarshal(line, &r); err != nil {
+			// Skip a line torn by a crash rather than refusing to start
+			continue
+		}
+		s.apply(r)
+	}
+}
+
+// apply takes in one line of the accounts file. The caller holds s.mu.
+func (s *accountStore) apply(r accountRecord) {
+	if r.Match != nil {
+		if a := s.accounts[r.ID]; a != nil {
+			a.rating = a.rating.update(r.Match.OpponentRating, r.Match.Won)
+		}
+		return
+	}
+	if r.Game == nil {
+		s.accounts[r.ID] = &account{id: r.ID, name: r.Name, created: r.Created, rating: rating{Rating: startRating}}
+		s.byToken[r.TokenHash] = r.ID
+		// Names registered twice before they were checked stay the first
+		// account's
+		if _, taken := s.byName[nameKey(r.Name)]; !taken {
+			s.byName[nameKey(r.Name)] = r.ID
+		}
+		return
+	}
+	if a := s.accounts[r.ID]; a != nil {
+		if r.Game.Rated {
+			a.rating = a.rating.update(r.Game.OpponentRating, r.Game.Won)
+			r.Game.Rating = a.rating.Rating
+		}
+		a.games = append(a.games, *r.Game)
+	}
+}
+
+// write appends r to the accounts file and applies it, along with
+// anything another process appended first.
+func (s *accountStore) write(r accountRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.writeLocked(r)
+}
+
+// writeLocked is write for a caller already holding s.mu.
+func (s *accountStore) writeLocked(r accountRecord) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if err := s.catchUp(); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return s.catchUp()
+}
+
+// lookup is the account token signs in to, or nil. The caller holds s.mu.
+func (s *accountStore) lookup(token string) *account {
+	if token == "" {
+		return nil
+	}
+	if err := s.catchUp(); err != nil {
+		logger.Warn("reading accounts", "err", err)
+	}
+	return s.accounts[s.byToken[hashToken(token)]]
+}
+
+// nameKey folds a name, so names differing only in case are one name.
+func nameKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// named reports whether name belongs to an account.
+func (s *accountStore) named(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.catchUp(); err != nil {
+		logger.Warn("reading accounts", "err", err)
+	}
+	_, taken := s.byName[nameKey(name)]
+	return taken
+}
+
+// byRequest is the account whose token r carries, or nil.
+func (s *accountStore) byRequest(r *http.Request) *account {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lookup(token)
+}
+
+// profile sums up a. The caller holds s.mu.
+func (a *account) profile() accountProfile {
+	p := accountProfile{ID: a.id, Name: a.name, Created: a.created, Rating: a.rating, Games: len(a.games), Modes: []accountMode{}}
+	modes := map[string]*accountMode{}
+	for _, g := range a.games {
+		p.Played += g.Duration
+		am := modes[g.Mode]
+		if am == nil {
+			am = &accountMode{Mode: g.Mode}
+			modes[g.Mode] = am
+		}
+		am.Games++
+		if g.Won {
+			am.Wins++
+		}
+		am.Best = max(am.Best, g.Score)
+		am.BestWPM = max(am.BestWPM, g.WPM)
+	}
+	for _, am := range modes {
+		p.Modes = append(p.Modes, *am)
+	}
+	sort.Slice(p.Modes, func(i, j int) bool { return p.Modes[i].Games > p.Modes[j].Games })
+	for i := len(a.games) - 1; i >= 0 && len(p.History) < accountHistory; i-- {
+		p.History = append(p.History, a.games[i])
+	}
+	return p
+}
+
+// handleRegister creates an account from POST /accounts {"name": ...} and
+// answers with its link, the only time the token is sent.
+func (s *accountStore) handleRegister(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10)).Decode(&req); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len([]rune(name)) > maxBoardName {
+		http.Error(w, fmt.Sprintf("names are 1 to %d characters", maxBoardName), http.StatusBadRequest)
+		return
+	}
+	link := accountLink{ID: randomHex(8), Name: name, Token: randomHex(24)}
+	rec := accountRecord{ID: link.ID, Name: name, TokenHash: hashToken(link.Token), Created: time.Now().UTC()}
+	s.mu.Lock()
+	err := s.catchUp()
+	if _, taken := s.byName[nameKey(name)]; err == nil && taken {
+		s.mu.Unlock()
+		http.Error(w, fmt.Sprintf("the name %q is taken", name), http.StatusConflict)
+		return
+	}
+	if err == nil {
+		err = s.writeLocked(rec)
+	}
+	s.mu.Unlock()
+	if err != nil {
+		logger.Error("account register", "err", err)
+		http.Error(w, "storing account failed", http.StatusInternalServerError)
+		return
+	}
+	logger.Info("account register", "id", link.ID, "name", name)
+	writeJSON(w, link)
+}
+
+// handleAccount serves the profile on GET /account and records a game on
+// POST /account/games.
+func (s *accountStore) handleAccount(w http.ResponseWriter, r *http.Request) {
+	a := s.byRequest(r)
+	if a == nil {
+		http.Error(w, "unknown account", http.StatusUnauthorized)
+		return
+	}
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/account":
+		s.mu.Lock()
+		p := a.profile()
+		s.mu.Unlock()
+		writeJSON(w, p)
+	case r.Method == http.MethodPost && r.URL.Path == "/account/games":
+		var g accountGame
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<12)).Decode(&g); err != nil || g.Score < 0 {
+			http.Error(w, "bad game", http.StatusBadRequest)
+			return
+		}
+		g.Time = time.Now().UTC()
+		// Only the lobby moves ratings, from matches it relayed
+		g.Rating, g.Rated, g.OpponentRating = 0, false, 0
+		if err := s.write(accountRecord{ID: a.id, Game: &g}); err != nil {
+			logger.Error("account game", "err", err)
+			http.Error(w, "storing game failed", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "not found", http.StatusNotFound)
+	}
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Client
+
+func accountPath(profile string) (string, error) {
+	dir, err := profileDir(profile)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, accountFile), nil
+}
+
+// loadAccount returns the profile's account, or nil if it has none.
+func loadAccount(profile string) (*accountLink, error) {
+	path, err := accountPath(profile)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var link accountLink
+	return &link, json.Unmarshal(data, &link)
+}
+
+func saveAccount(profile string, link accountLink) error {
+	path, err := accountPath(profile)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(link, "", "  ")
+	if err != nil {
+		return err
+	}
+	// The token is as good as a password
+	return os.WriteFile(path, data, 0o600)
+}
+
+// call sends an account request to the server, decoding the reply into
+// out unless it is nil.
+func (l accountLink) call(method, path string, body, out any) error {
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, strings.TrimSuffix(l.Server, "/")+path, &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if l.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+l.Token)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return serverError("account server", resp)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// accountGame is the finished game as recorded on the account.
+func (m model) accountGame(s session) accountGame {
+	return accountGame{
+		Mode:     modeName(s),
+		Duration: s.Duration,
+		Score:    s.Score,
+		Level:    s.Level,
+		Words:    s.WordsTyped,
+		WPM:      s.WPM,
+		Accuracy: s.Accuracy,
+		Opponent: s.Opponent,
+		Won:      s.Won,
+		Replay:   s.Replay,
+	}
+}
+
+// recordAccountGame records s on the profile's account, if it has one.
+// Drills are only kept locally.
+func (m model) recordAccountGame(s session) error {
+	if m.drilling {
+		return nil
+	}
+	link, err := loadAccount(m.profile)
+	if err != nil || link == nil {
+		return err
+	}
+	return link.call(http.MethodPost, "/account/games", m.accountGame(s), nil)
+}
+
+// runAccount is the account command: register, logout, or show the
+// profile.
+func runAccount(args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "register":
+			return registerAccount(args[1:])
+		case "logout":
+			path, err := accountPath("")
+			if err != nil {
+				return err
+			}
+			if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			fmt.Println("Logged out; the account stays on the server.")
+			return nil
+		}
+		return fmt.Errorf("usage: %s account [register -server URL -name NAME | logout]", progName())
+	}
+	link, err := loadAccount("")
+	if err != nil {
+		return err
+	}
+	if link == nil {
+		return fmt.Errorf("no account yet; create one with '%s account register -server URL -name NAME'", progName())
+	}
+	s := profileScreen{styles: newStyles(lipgloss.DefaultRenderer()), link: *link, loading: true}
+	_, err = tea.NewProgram(s, tea.WithAltScreen()).Run()
+	return err
+}
+
+func registerAccount(args []string) error {
+	fs := flag.NewFlagSet("account register", flag.ExitOnError)
+	server := fs.String("server", "", "Leaderboard server to keep the account on (e.g. http://host:8080)")
+	name := fs.String("name", os.Getenv("USER"), "Account name, shown on the leaderboard")
+	fs.Parse(args)
+	if *server == "" {
+		return fmt.Errorf("usage: %s account register -server URL -name NAME", progName())
+	}
+	if old, err := loadAccount(""); err == nil && old != nil {
+		return fmt.Errorf("already logged in as %s on %s; 'account logout' first", old.Name, old.Server)
+	}
+	var link accountLink
+	anon := accountLink{Server: *server}
+	if err := anon.call(http.MethodPost, "/accounts", map[string]string{"name": *name}, &link); err != nil {
+		return fmt.Errorf("registering: %w", err)
+	}
+	link.Server = *server
+	if err := saveAccount("", link); err != nil {
+		return err
+	}
+	fmt.Printf("Registered %s on %s. Finished games are now recorded on the account.\n", link.Name, link.Server)
+	return nil
+}
+
+// profileScreen shows the account's profile from the server.
+type profileScreen struct {
+	styles  *styles
+	link    accountLink
+	profile accountProfile
+	loading bool
+	err     error
+}
+
+type profileMsg struct {
+	profile accountProfile
+	err     error
+}
+
+func fetchProfileCmd(link accountLink) tea.Cmd {
+	return func() tea.Msg {
+		var p accountProfile
+		err := link.call(http.MethodGet, "/account", nil, &p)
+		return profileMsg{p, err}
+	}
+}
+
+func (p profileScreen) Init() tea.Cmd {
+	return fetchProfileCmd(p.link)
+}
+
+func (p profileScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case profileMsg:
+		p.loading = false
+		p.profile, p.err = msg.profile, msg.err
+	case tea.KeyMsg:
+		if msg.String() == "r" && !p.loading {
+			p.loading = true
+			return p, fetchProfileCmd(p.link)
+		}
+		return p, tea.Quit
+	}
+	return p, nil
+}
+
+func (p profileScreen) View() string {
+	st := p.styles
+	var b strings.Builder
+	b.WriteString("\n" + st.title.Render("PROFILE - "+p.link.Name) + "\n\n")
+	switch {
+	case p.loading:
+		b.WriteString(st.stats.Render("Loading from "+p.link.Server+"...") + "\n")
+	case p.err != nil:
+		b.WriteString(st.stats.Render(fmt.Sprintf("Profile unavailable: %v", p.err)) + "\n")
+	default:
+		pr := p.profile
+		b.WriteString(st.stats.Render(fmt.Sprintf("Member since %s   Games %d   Time %v   Rating %d (%d rated)",
+			pr.Created.Format("2006-01-02"), pr.Games, pr.Played.Round(time.Minute), pr.Rating.Rating, pr.Rating.Games)) + "\n\n")
+		b.WriteString(st.pause.Render("By mode") + "\n")
+		if len(pr.Modes) == 0 {
+			b.WriteString(st.stats.Render("  No games recorded yet.") + "\n")
+		}
+		for _, am := range pr.Modes {
+			line := fmt.Sprintf("  %-9s %4d games  best %7d  top %3d WPM", am.Mode, am.Games, am.Best, am.BestWPM)
+			if am.Wins > 0 {
+				line += fmt.Sprintf("  %d wins", am.Wins)
+			}
+			b.WriteString(st.stats.Render(line) + "\n")
+		}
+		b.WriteString("\n" + st.pause.Render("Recent games") + "\n")
+		for _, g := range pr.History {
+			line := fmt.Sprintf("  %s  %-9s %7d  level %2d  %3d WPM", g.Time.Local().Format("2006-01-02 15:04"), g.Mode, g.Score, g.Level, g.WPM)
+			if g.Opponent != "" {
+				result := "lost to"
+				if g.Won {
+					result = "beat"
+				}
+				line += fmt.Sprintf("  %s %s", result, g.Opponent)
+			}
+			if g.Rating != 0 {
+				line += fmt.Sprintf("  (%d)", g.Rating)
+			}
+			b.WriteString(st.stats.Render(line) + "\n")
+		}
+	}
+	b.WriteString("\n" + st.help.Render("[r: refresh | any other key: leave]") + "\n")
+	return b.String()
+}
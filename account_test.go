@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAccountNames(t *testing.T) {
+	accounts, err := openAccountStore(t.TempDir() + "/accounts.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	register := func(name string) (int, accountLink) {
+		w := httptest.NewRecorder()
+		accounts.handleRegister(w, httptest.NewRequest(http.MethodPost, "/accounts", strings.NewReader(`{"name":"`+name+`"}`)))
+		var link accountLink
+		json.NewDecoder(w.Body).Decode(&link)
+		return w.Code, link
+	}
+	code, ann := register("Ann")
+	if code != http.StatusOK {
+		t.Fatalf("registering Ann: %d", code)
+	}
+	for _, name := range []string{"Ann", "ann", " ANN "} {
+		if code, _ := register(name); code != http.StatusConflict {
+			t.Errorf("registering %q again: %d, want %d", name, code, http.StatusConflict)
+		}
+	}
+
+	// Versus entries are refused before any replay, so they show whether
+	// the name was let through
+	board := &boardStore{accounts: accounts}
+	submit := func(name, token string) int {
+		body, _ := json.Marshal(boardEntry{Name: name, Mode: "versus"})
+		r := httptest.NewRequest(http.MethodPost, "/scores", strings.NewReader(string(body)))
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		board.handleScores(w, r)
+		return w.Code
+	}
+	tests := []struct {
+		name, token string
+		want        int
+	}{
+		{"ann", "", http.StatusForbidden},
+		{"Ann", "", http.StatusForbidden},
+		{"anything", ann.Token, http.StatusUnprocessableEntity},
+		{"bob", "", http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		if got := submit(tt.name, tt.token); got != tt.want {
+			t.Errorf("submitting as %q (token %v): %d, want %d", tt.name, tt.token != "", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Adaptive difficulty rubber-bands the pace to keep the player in the flow
+// zone: accurate typing with an empty screen speeds words up, and typos
+// with words close to the bottom slow them down. Every step the game looks
+// at the accuracy of the last several seconds of keystrokes and how far
+// down the lowest word is, and eases a multiplier on the fall and spawn
+// rates toward where those point. The adaptive setting, 0 to 1, is how far
+// the multiplier may swing; 0 turns it off.
+
+const (
+	// flowAccuracy is the accuracy the player is held at; above it the
+	// game speeds up and below it slows down
+	flowAccuracy = 0.92
+	// flowSwing is the furthest the multiplier strays from 1 at full
+	// strength, and flowEase how much of the way to its target it moves
+	// each step
+	flowSwing = 0.5
+	flowEase  = 0.2
+	// flowDecay fades keystrokes out of the rolling accuracy, about
+	// ten seconds' worth counting
+	flowDecay = 0.9
+	// flowMinKeys is how many recent keystrokes the accuracy needs to
+	// count for anything
+	flowMinKeys = 5
+)
+
+// flow tracks the rolling accuracy and the pace multiplier it sets.
+type flow struct {
+	// keys and typos are the recent keystrokes, decayed each step, and
+	// seenKeys and seenTypos the tally's totals they have caught up to
+	keys, typos         float64
+	seenKeys, seenTypos int
+	// rate multiplies the fall and spawn rates; zero until the first step
+	rate float64
+}
+
+// flowRate is the multiplier on the pace, 1 unless adaptive difficulty
+// is on.
+func (m model) flowRate() float64 {
+	if m.adaptive <= 0 || m.flow.rate == 0 {
+		return 1
+	}
+	return m.flow.rate
+}
+
+// adapt eases the pace multiplier toward the player's accuracy and the
+// screen's pressure.
+func (m model) adapt() model {
+	if m.adaptive <= 0 {
+		return m
+	}
+	f := &m.flow
+	f.keys = f.keys*flowDecay + float64(m.tally.Keystrokes-f.seenKeys)
+	f.typos = f.typos*flowDecay + float64(m.tally.Typos-f.seenTypos)
+	f.seenKeys, f.seenTypos = m.tally.Keystrokes, m.tally.Typos
+
+	accuracy := 0.0
+	if f.keys >= flowMinKeys {
+		acc := 1 - f.typos/f.keys
+		if acc >= flowAccuracy {
+			accuracy = (acc - flowAccuracy) / (1 - flowAccuracy)
+		} else {
+			accuracy = max(-1, (acc-flowAccuracy)/(1-flowAccuracy)/2)
+		}
+	}
+	lowest := -1
+	for _, w := range m.words {
+		lowest = max(lowest, w.y)
+	}
+	pressure := min(1, max(-1, 2*float64(lowest+1)/gameHeight-1))
+
+	target := 1 + m.adaptive*flowSwing*(accuracy-pressure)/2
+	if f.rate == 0 {
+		f.rate = 1
+	}
+	f.rate += (target - f.rate) * flowEase
+	return m
+}
+
+// setAdaptive is the 'config set adaptive' setter.
+func setAdaptive(s *settings, value string) error {
+	if value == "off" {
+		s.Adaptive = 0
+		return nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f < 0 || f > 1 {
+		return fmt.Errorf("adaptive is how strongly the pace adapts, from 0 to 1, or off, not %q", value)
+	}
+	s.Adaptive = f
+	return nil
+}
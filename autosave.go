@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// autosaveEvery is how many ticks pass between snapshots.
+const autosaveEvery = 5
+
+// savedWord is the on-disk form of a falling word.
+type savedWord struct {
+	Text string `json:"text"`
+	X    int    `json:"x"`
+	Y    int    `json:"y"`
+}
+
+// snapshot is the periodically saved state of a run in progress. It is
+// removed when the game ends or the program exits cleanly, so finding one
+// at startup means the previous run was interrupted.
+type snapshot struct {
+	SavedAt    time.Time     `json:"saved_at"`
+	StartTime  time.Time     `json:"start_time"`
+	Elapsed    time.Duration `json:"elapsed"`
+	Score      int           `json:"score"`
+	Level      int           `json:"level"`
+	Lives      int           `json:"lives"`
+	WordsTyped int           `json:"words_typed"`
+	Assisted   bool          `json:"assisted,omitempty"`
+	Words      []savedWord   `json:"words"`
+	Tally      tally         `json:"tally"`
+}
+
+func autosavePath() (string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "autosave.json"), nil
+}
+
+func (m model) snapshot() snapshot {
+	s := snapshot{
+		SavedAt:    time.Now(),
+		StartTime:  m.startTime,
+		Elapsed:    m.elapsed(),
+		Score:      m.score,
+		Level:      m.level,
+		Lives:      m.lives,
+		WordsTyped: m.wordsTyped,
+		Assisted:   m.assisted,
+		Tally:      m.tally,
+	}
+	for _, w := range m.words {
+		s.Words = append(s.Words, savedWord{Text: w.text, X: w.x, Y: w.y})
+	}
+	return s
+}
+
+// autosaves orders writes against removals. Writes run off the update loop,
+// so one can still be in flight when its game ends; over is the start time
+// of the last game discarded, whose snapshots are no longer written.
+var autosaves struct {
+	sync.Mutex
+	over time.Time
+}
+
+// autosaveCmd writes the snapshot off the update loop. Errors are logged
+// rather than surfaced; a missed autosave shouldn't interrupt play.
+func autosaveCmd(s snapshot) tea.Cmd {
+	return func() tea.Msg {
+		if err := writeAutosave(s); err != nil {
+			logger.Error("autosave", "err", err)
+		}
+		return nil
+	}
+}
+
+func writeAutosave(s snapshot) error {
+	autosaves.Lock()
+	defer autosaves.Unlock()
+	if s.StartTime.Equal(autosaves.over) {
+		return nil
+	}
+	path, err := autosavePath()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	// Write then rename so a crash mid-write never leaves a torn file
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// loadAutosave returns the snapshot left by an interrupted run, if any.
+func loadAutosave() (*snapshot, error) {
+	path, err := autosavePath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var s snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// discardAutosave removes the snapshot of the game that started at start,
+// once it is over or has been quit, and drops any write of it still to
+// come.
+func discardAutosave(start time.Time) error {
+	autosaves.Lock()
+	defer autosaves.Unlock()
+	autosaves.over = start
+	return removeAutosaveLocked()
+}
+
+// removeAutosave removes the snapshot an interrupted run left.
+func removeAutosave() error {
+	autosaves.Lock()
+	defer autosaves.Unlock()
+	return removeAutosaveLocked()
+}
+
+func removeAutosaveLocked() error {
+	path, err := autosavePath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// promptResume asks on the terminal whether to resume an interrupted run.
+func promptResume(s *snapshot) bool {
+	fmt.Printf("An interrupted game was found (score %d, level %d, saved %s).\n",
+		s.Score, s.Level, s.SavedAt.Format(time.Stamp))
+	fmt.Print("Resume it? [Y/n] ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "" || answer == "y" || answer == "yes"
+}
+
+// resumeModel rebuilds a model from an interrupted run's snapshot.
+func resumeModel(dict *dictionary, s snapshot) model {
+	m := initialModel(dict)
+	m.title = false
+	m.resumed = true
+	gameStarted()
+	m.startTime = time.Now().Add(-s.Elapsed)
+	m.score = s.Score
+	m.level = s.Level
+	m.lives = s.Lives
+	m.wordsTyped = s.WordsTyped
+	m.assisted = s.Assisted
+	m.tally = s.Tally
+	for _, w := range s.Words {
+		// Near enough, a row a second since it appeared
+		m.words = append(m.words, word{text: w.Text, x: w.X, y: w.Y, spawned: -time.Duration(w.Y) * tickRate})
+	}
+	// Come back paused so the player isn't dropped into a live screen
+	m.paused = true
+	return m
+}
+
+// session converts an abandoned snapshot into a stats entry.
+func (s snapshot) session() session {
+	return session{
+		Time:       s.StartTime,
+		Duration:   s.Elapsed,
+		Score:      s.Score,
+		Level:      s.Level,
+		WordsTyped: s.WordsTyped,
+		WPM:        wpm(s.WordsTyped, s.Elapsed),
+		Recovered:  true,
+		Assisted:   s.Assisted,
+	}.withTally(s.Tally)
+}
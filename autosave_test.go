@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDiscardAutosave(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	saved := func() bool {
+		s, err := loadAutosave()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s != nil
+	}
+
+	game := snapshot{StartTime: time.Now(), Score: 10}
+	if err := writeAutosave(game); err != nil || !saved() {
+		t.Fatalf("writeAutosave: %v, saved %v", err, saved())
+	}
+	if err := discardAutosave(game.StartTime); err != nil || saved() {
+		t.Fatalf("discardAutosave: %v, saved %v", err, saved())
+	}
+	// A write that was in flight when the game ended
+	if err := writeAutosave(game); err != nil || saved() {
+		t.Fatalf("late write: %v, saved %v", err, saved())
+	}
+	next := snapshot{StartTime: game.StartTime.Add(time.Minute)}
+	if err := writeAutosave(next); err != nil || !saved() {
+		t.Fatalf("next game: %v, saved %v", err, saved())
+	}
+}
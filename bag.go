@@ -0,0 +1,70 @@
+package main
+
+import (
+	"math/rand"
+	"slices"
+)
+
+// Words are dealt from shuffle bags rather than drawn at random: every
+// word of a length comes up once, in a shuffled order, before any comes up
+// again. On a small custom list that spreads the words out instead of
+// repeating a few while others never show. Each length has a bag of its
+// own, so dealing only looks at the lengths that fit the playfield.
+
+// bag is the rest of the current round of each length, as indexes into the
+// dictionary's words of that length.
+type bag [maxWordLen + 1][]int
+
+// deal takes the next word of lo to hi letters that keep accepts. The
+// length is picked in proportion to how many words it has, as from one bag
+// of the whole band, and the word from that length's bag. Lengths with
+// nothing acceptable are passed over; if no length has anything, deal
+// reports false.
+func (d *dictionary) deal(rng *rand.Rand, b *bag, lo, hi int, keep func(string) bool) (string, bool) {
+	var lengths []int
+	total := 0
+	for n := max(lo, minWordLen); n <= min(hi, maxWordLen); n++ {
+		if k := len(d.ofLength(n)); k > 0 {
+			lengths = append(lengths, n)
+			total += k
+		}
+	}
+	for len(lengths) > 0 {
+		i, pick := 0, rng.Intn(total)
+		for pick >= len(d.ofLength(lengths[i])) {
+			pick -= len(d.ofLength(lengths[i]))
+			i++
+		}
+		n := lengths[i]
+		if w, ok := d.dealLength(rng, &b[n], n, keep); ok {
+			return w, true
+		}
+		total -= len(d.ofLength(n))
+		lengths = slices.Delete(lengths, i, i+1)
+	}
+	return "", false
+}
+
+// dealLength takes the next word in the round of length n that keep
+// accepts, refilling the round from rng when it runs out. Words keep turns
+// down stay in the round for later. If nothing left in the round is
+// acceptable, a new round starts, and if nothing in that is either,
+// dealLength reports false.
+func (d *dictionary) dealLength(rng *rand.Rand, round *[]int, n int, keep func(string) bool) (string, bool) {
+	words := d.ofLength(n)
+	for range 2 {
+		if len(*round) == 0 {
+			*round = rng.Perm(len(words))
+		}
+		r := *round
+		for i, idx := range r {
+			if keep(words[idx]) {
+				r[0], r[i] = r[i], r[0]
+				*round = r[1:]
+				return words[idx], true
+			}
+		}
+		*round = nil
+	}
+	return "", false
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var dealWords = []string{"ox", "cat", "dog", "emu", "bear", "deer", "lion", "wolf", "horse", "tiger"}
+
+func TestDeal(t *testing.T) {
+	d := newDictionary(slices.Clone(dealWords))
+	all := func(string) bool { return true }
+	tests := []struct {
+		name   string
+		lo, hi int
+		keep   func(string) bool
+		// want is every word deal may come up with, or nil if none fits
+		want []string
+	}{
+		{"one length", 4, 4, all, []string{"bear", "deer", "lion", "wolf"}},
+		{"band", 2, 3, all, []string{"ox", "cat", "dog", "emu"}},
+		{"band past the dictionary", 5, 12, all, []string{"horse", "tiger"}},
+		{"keep filters", 3, 5, func(w string) bool { return strings.Contains(w, "e") }, []string{"emu", "bear", "deer", "horse", "tiger"}},
+		{"nothing kept", 3, 5, func(string) bool { return false }, nil},
+		{"nothing that long", 6, 12, all, nil},
+		{"empty band", 4, 3, all, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rng := rand.New(rand.NewSource(1))
+			var b bag
+			dealt := map[string]bool{}
+			for range 200 {
+				w, ok := d.deal(rng, &b, tt.lo, tt.hi, tt.keep)
+				if ok != (tt.want != nil) {
+					t.Fatalf("deal = %q, %v", w, ok)
+				}
+				if ok && !slices.Contains(tt.want, w) {
+					t.Fatalf("dealt %q, want one of %v", w, tt.want)
+				}
+				dealt[w] = ok
+			}
+			for _, w := range tt.want {
+				if !dealt[w] {
+					t.Errorf("%q never dealt", w)
+				}
+			}
+		})
+	}
+}
+
+func TestDealRounds(t *testing.T) {
+	d := newDictionary(slices.Clone(dealWords))
+	rng := rand.New(rand.NewSource(1))
+	var b bag
+	four := d.ofLength(4)
+	for round := range 5 {
+		seen := map[string]bool{}
+		for range four {
+			w, _ := d.deal(rng, &b, 4, 4, func(string) bool { return true })
+			if seen[w] {
+				t.Fatalf("round %d dealt %q twice", round, w)
+			}
+			seen[w] = true
+		}
+	}
+}
+
+func TestDealSpreadsLengths(t *testing.T) {
+	d := newDictionary(slices.Clone(dealWords))
+	rng := rand.New(rand.NewSource(1))
+	var b bag
+	counts := map[int]int{}
+	for range 8000 {
+		w, _ := d.deal(rng, &b, 2, 4, func(string) bool { return true })
+		counts[len(w)]++
+	}
+	// One word of 2 letters, three of 3 and four of 4
+	for n, want := range map[int]int{2: 1000, 3: 3000, 4: 4000} {
+		if got := counts[n]; got < want*9/10 || got > want*11/10 {
+			t.Errorf("%d words of %d letters, want about %d", got, n, want)
+		}
+	}
+}
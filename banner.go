@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Each level has a name, shown in a banner across the playfield for a few
+// ticks when the game levels up. The banner's rules open out from the
+// title and then close again as it fades.
+
+var levelTitles = []string{
+	"Clear Skies",
+	"First Contact",
+	"Turbulence",
+	"Crosswind",
+	"Meteor Shower",
+	"Swarm",
+	"Storm Front",
+	"Blitz",
+	"Onslaught",
+	"Maelstrom",
+	"Armageddon",
+	"Singularity",
+}
+
+// bannerLife is how many ticks the level banner stays up.
+const bannerLife = 3
+
+type banner struct {
+	text string
+	life int
+}
+
+// levelTitle names a level; levels past the last title keep its name.
+func levelTitle(level int) string {
+	return levelTitles[min(max(level, 1), len(levelTitles))-1]
+}
+
+// announceLevel puts up the banner for the level just reached.
+func (m model) announceLevel() model {
+	if m.minimal {
+		return m
+	}
+	m.banner = banner{text: fmt.Sprintf("LEVEL %d - %s", m.level, levelTitle(m.level)), life: bannerLife}
+	return m
+}
+
+// drawBanner writes the banner into the middle of the screen buffer.
+func (m model) drawBanner() {
+	if m.banner.life <= 0 {
+		return
+	}
+	// The rules are widest on the middle tick
+	stage := min(m.banner.life, bannerLife+1-m.banner.life)
+	text := []rune(m.banner.text)
+	rule := len(text) * stage / ((bannerLife + 1) / 2)
+	y := gameHeight / 2
+	m.drawCentered(y-1, strings.Repeat("=", rule))
+	m.drawCentered(y, "  "+string(text)+"  ")
+	m.drawCentered(y+1, strings.Repeat("=", rule))
+}
+
+func (m model) drawCentered(y int, text string) {
+	runes := []rune(text)
+	x := max(0, (screenWidth-len(runes))/2)
+	for i, ch := range runes {
+		if x+i < screenWidth && y >= 0 && y < gameHeight {
+			m.frame.cells[y][x+i] = ch
+			m.frame.kinds[y][x+i] = cellPopup
+		}
+	}
+}
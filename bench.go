@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// -bench-demo plays a scripted heavy game as fast as it can and reports how
+// long Update and View take, to measure rendering work against. The screen
+// is kept full of words, a word is typed out every few frames so several
+// explosions are always in flight, and the terminal is large enough for
+// the boxed layout. Nothing is drawn; frames use simulated time.
+
+const (
+	benchFrames = 3000
+	// benchKillEvery is how many frames pass between typed words
+	benchKillEvery = 4
+)
+
+// benchModel is the scripted game before its first frame, seeded so every
+// run plays out the same.
+func benchModel(dict *dictionary, frameRate time.Duration) model {
+	r := lipgloss.NewRenderer(io.Discard)
+	r.SetColorProfile(termenv.TrueColor)
+
+	m := initialModel(dict)
+	m.styles = newStyles(r)
+	m.seed = 1
+	m.rng.Seed(m.seed)
+	m.title = false
+	m.frameRate = frameRate
+	m.level, m.startLevel = 20, 20
+	m.lives = 1 << 20
+	m.pace = pace{Fall: 1, Spawn: maxSpawns}
+	m.width, m.height = 200, 60
+	return m
+}
+
+// benchFrame advances the scripted game one frame to now, typing out the
+// first word on screen every benchKillEvery'th frame. update is called with
+// each message in turn.
+func benchFrame(m model, i int, now time.Time, update func(m model, msg tea.Msg) model) model {
+	m = update(m, tickMsg(now))
+	if i%benchKillEvery == 0 && len(m.words) > 0 {
+		for _, r := range m.words[0].text {
+			m = update(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+	}
+	return m
+}
+
+// benchDemo runs the scripted game and prints its frame-time percentiles.
+func benchDemo(dict *dictionary, frameRate time.Duration) error {
+	m := benchModel(dict, frameRate)
+
+	var updates, views []time.Duration
+	var words, particles int
+	update := func(m model, msg tea.Msg) model {
+		start := time.Now()
+		next, _ := m.Update(msg)
+		updates = append(updates, time.Since(start))
+		return next.(model)
+	}
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	now := time.Now()
+	for i := 0; i < benchFrames; i++ {
+		now = now.Add(frameRate)
+		m = benchFrame(m, i, now, update)
+		start := time.Now()
+		m.View()
+		views = append(views, time.Since(start))
+
+		words += len(m.words)
+		particles += m.particleCount()
+	}
+	runtime.ReadMemStats(&after)
+
+	fmt.Printf("Bench demo: %d frames of %v on a %dx%d terminal\n", benchFrames, frameRate, m.width, m.height)
+	fmt.Printf("On screen:  %.1f words, %.0f particles on average\n\n", float64(words)/benchFrames, float64(particles)/benchFrames)
+	fmt.Printf("%-8s %10s %10s %10s %10s\n", "", "p50", "p90", "p99", "max")
+	for _, row := range []struct {
+		name  string
+		times []time.Duration
+	}{{"Update", updates}, {"View", views}} {
+		p := percentiles(row.times, 0.5, 0.9, 0.99, 1)
+		fmt.Printf("%-8s %10v %10v %10v %10v\n", row.name, p[0], p[1], p[2], p[3])
+	}
+	fmt.Printf("\nPer frame:  %d allocations, %d KiB\n",
+		(after.Mallocs-before.Mallocs)/benchFrames, (after.TotalAlloc-before.TotalAlloc)/benchFrames/1024)
+	return nil
+}
+
+// percentiles picks the given fractions, 0 to 1, of the times.
+func percentiles(times []time.Duration, at ...float64) []time.Duration {
+	sorted := append([]time.Duration(nil), times...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	out := make([]time.Duration, len(at))
+	for i, f := range at {
+		if len(sorted) > 0 {
+			out[i] = sorted[int(f*float64(len(sorted)-1))].Round(time.Microsecond / 10)
+		}
+	}
+	return out
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// benchWarmUp is how many frames the scripted game runs before timing
+// starts, enough to fill the screen and set explosions going.
+const benchWarmUp = 200
+
+// heavyModel is the -bench-demo game once it's in full swing: the screen
+// full of words and explosions in flight on a 200x60 terminal.
+func heavyModel(tb testing.TB) (model, time.Time) {
+	tb.Helper()
+	dict, err := loadDictionary("short_words.txt")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	m := benchModel(dict, tickRate)
+	now := time.Now()
+	for i := range benchWarmUp {
+		now = now.Add(tickRate)
+		m = benchFrame(m, i, now, benchUpdate)
+	}
+	if len(m.words) < 8 || len(m.effects) == 0 {
+		tb.Fatalf("warm-up left %d words and %d explosions, want a heavy screen", len(m.words), len(m.effects))
+	}
+	return m, now
+}
+
+func benchUpdate(m model, msg tea.Msg) model {
+	next, _ := m.Update(msg)
+	return next.(model)
+}
+
+func BenchmarkUpdate(b *testing.B) {
+	m, now := heavyModel(b)
+	i := 0
+	for b.Loop() {
+		now = now.Add(tickRate)
+		m = benchFrame(m, i, now, benchUpdate)
+		i++
+	}
+}
+
+func BenchmarkView(b *testing.B) {
+	m, _ := heavyModel(b)
+	for b.Loop() {
+		m.View()
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Words are lost when they reach the bottom of the playfield, or a few
+// rows higher with 'config set boundary N', which marks the raised line
+// with a faint rule. Either way a word about to cross flashes for the
+// step before it does, a last chance to finish it. Raising the boundary
+// makes for a harder game, so it counts as a custom difficulty profile.
+
+// maxBoundary is the most rows the boundary can be raised.
+const maxBoundary = 5
+
+// bottom is the row a word is lost on reaching.
+func (m model) bottom() int {
+	return gameHeight - m.boundary
+}
+
+// landing reports whether w crosses the boundary on the next step.
+func (m model) landing(w word) bool {
+	if w.y+1 < m.bottom() {
+		return false
+	}
+	rows, _ := m.pace.perStep(m.level)
+	return m.fallClock+rows*m.paceRate() >= 1
+}
+
+// drawBoundary rules the raised boundary across the cleared playfield.
+func (m model) drawBoundary() {
+	if m.boundary == 0 {
+		return
+	}
+	rule := '-'
+	if m.symbols.name != "" && m.symbols.name != "ascii" {
+		rule = '┄'
+	}
+	for x := range m.fieldWidth() {
+		m.put(x, m.bottom(), rule, cellDecor)
+	}
+}
+
+func setBoundary(s *settings, value string) error {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 || n > maxBoundary {
+		return fmt.Errorf("boundary is how many rows above the bottom words are lost, 0 to %d, not %q", maxBoundary, value)
+	}
+	s.Boundary = n
+	return nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import "time"
+
+// Long sessions are hard on hands and wrists. With 'config set break-every
+// 30m' the game pauses after every thirty minutes of play, pauses not
+// counted, and shows a stretch to do before carrying on. Live matches and
+// classrooms aren't interrupted.
+
+// stretches are shown in turn, one a break.
+var stretches = []string{
+	"Shake out your hands and roll your wrists, ten times each way.",
+	"Spread your fingers wide, hold for five seconds, then make a loose fist.",
+	"Hold one arm out, palm up, and gently pull the fingers back with the other hand. Swap.",
+	"Roll your shoulders back ten times and let your arms hang loose.",
+	"Look at something across the room for twenty seconds to rest your eyes.",
+	"Stand up, stretch your arms overhead and take a few deep breaths.",
+}
+
+// breakDue reports whether the game has been played long enough since the
+// last break to take another.
+func (m model) breakDue() bool {
+	return m.breakEvery > 0 && m.peer == nil && m.classroom == nil &&
+		m.gameTime() >= time.Duration(m.breaks+1)*m.breakEvery
+}
+
+// takeBreak pauses the game for a stretch.
+func (m model) takeBreak() model {
+	m.breaks++
+	m.paused = true
+	m.pauseReason = "break"
+	logger.Info("break", "played", m.gameTime().Round(time.Second), "breaks", m.breaks)
+	return m
+}
+
+// stretch is the prompt for the break being taken.
+func (m model) stretch() string {
+	return stretches[(m.breaks-1)%len(stretches)]
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// A challenge code packs everything that shapes a run into ten characters
+// a friend can type: the seed, co-op or solo, the starting level and a
+// check byte of the dictionary. Playing with -challenge CODE then deals the
+// exact same words in the same places.
+//
+// The six packed bytes are: a version and mode bit and the starting level
+// in the first, the seed in the next four and the first byte of the
+// dictionary digest in the last. They are written with the join code
+// alphabet, five bits a character, and split in two halves.
+
+const (
+	maxStartLevel = 31
+	challengeLen  = 10
+)
+
+type challenge struct {
+	seed  uint32
+	coop  bool
+	level int
+	// dict is the first byte of the dictionary's digest
+	dict byte
+}
+
+func (c challenge) String() string {
+	var raw [6]byte
+	raw[0] = byte(c.level & maxStartLevel)
+	if c.coop {
+		raw[0] |= 1 << 5
+	}
+	binary.BigEndian.PutUint32(raw[1:5], c.seed)
+	raw[5] = c.dict
+
+	bits := uint64(0)
+	for _, b := range raw {
+		bits = bits<<8 | uint64(b)
+	}
+	var code [challengeLen]byte
+	for i := challengeLen - 1; i >= 0; i-- {
+		code[i] = joinCodeChars[bits&31]
+		bits >>= 5
+	}
+	return string(code[:5]) + "-" + string(code[5:])
+}
+
+// parseChallenge reads a code, forgiving case and separators.
+func parseChallenge(code string) (challenge, error) {
+	var c challenge
+	code = strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+	if len(code) != challengeLen {
+		return c, fmt.Errorf("challenge codes are %d characters", challengeLen)
+	}
+	bits := uint64(0)
+	for _, r := range code {
+		i := strings.IndexRune(joinCodeChars, r)
+		if i < 0 {
+			return c, fmt.Errorf("%q isn't used in challenge codes", r)
+		}
+		bits = bits<<5 | uint64(i)
+	}
+	var raw [6]byte
+	for i := 5; i >= 0; i-- {
+		raw[i] = byte(bits)
+		bits >>= 8
+	}
+	if raw[0]>>6 != 0 {
+		return c, errors.New("this challenge needs a newer version of the game")
+	}
+	c.level = int(raw[0] & maxStartLevel)
+	c.coop = raw[0]&(1<<5) != 0
+	c.seed = binary.BigEndian.Uint32(raw[1:5])
+	c.dict = raw[5]
+	if c.level < 1 {
+		return c, errors.New("not a valid challenge code")
+	}
+	return c, nil
+}
+
+// dictCheck is the dictionary byte of a challenge code.
+func dictCheck(d *dictionary) byte {
+	b, _ := hex.DecodeString(d.digest()[:2])
+	return b[0]
+}
+
+// challenge is the code that replays this game, or "" if it can't be
+// replayed: versus and classroom games depend on other players, and
+// resumed, chat-fed, seasonal event, narrowed, reloaded,
+// remote-controlled, time-leveled and custom-rules games on more than the
+// seed, and drills and shift training on their own words.
+func (m model) challenge() string {
+	if m.peer != nil || m.classroom != nil || m.drilling || m.keyDrill != "" || m.shift || m.resumed || m.fed || m.evented || m.narrowed || m.reloaded || m.controlled || m.levelTicks > 0 || m.customRules() {
+		return ""
+	}
+	return challenge{seed: uint32(m.seed), coop: m.coop, level: m.startLevel, dict: dictCheck(m.dict)}.String()
+}
+
+// withChallenge sets up the game a challenge code describes.
+func (m model) withChallenge(c challenge) (model, error) {
+	if dictCheck(m.dict) != c.dict {
+		return m, errors.New("this challenge was made with a different dictionary")
+	}
+	m.seed = int64(c.seed)
+	m.rng.Seed(m.seed)
+	m.startLevel, m.level = c.level, c.level
+	if c.coop {
+		return m.withCoop()
+	}
+	return m, nil
+}
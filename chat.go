@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Chat is available in the lobby and on the versus game over screen. TAB
+// switches the keyboard between the screen and the chat line, so chat
+// never collides with gameplay keys.
+
+const (
+	maxChatLen  = 200
+	keptChat    = 50
+	shownChat   = 6
+	chatBurst   = 5
+	chatRefill  = 2 * time.Second
+	floodNotice = "You're sending messages too fast"
+)
+
+type chatLine struct {
+	from string
+	text string
+}
+
+// floodGate is a token bucket allowing chatBurst messages at once and one
+// more every chatRefill.
+type floodGate struct {
+	tokens float64
+	last   time.Time
+}
+
+func (g *floodGate) allow(now time.Time) bool {
+	if g.last.IsZero() {
+		g.tokens = chatBurst
+	} else {
+		g.tokens = min(chatBurst, g.tokens+float64(now.Sub(g.last))/float64(chatRefill))
+	}
+	g.last = now
+	if g.tokens < 1 {
+		return false
+	}
+	g.tokens--
+	return true
+}
+
+// cleanChat strips anything that could drive the terminal and bounds the
+// length.
+func cleanChat(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsPrint(r) {
+			return r
+		}
+		return -1
+	}, s)
+	s = strings.TrimSpace(s)
+	if r := []rune(s); len(r) > maxChatLen {
+		s = string(r[:maxChatLen])
+	}
+	return s
+}
+
+// cleanName is cleanChat for a player's name from the network, bounded
+// like names on the boards.
+func cleanName(s string) string {
+	s = cleanChat(s)
+	if r := []rune(s); len(r) > maxBoardName {
+		s = strings.TrimSpace(string(r[:maxBoardName]))
+	}
+	return s
+}
+
+type chatBox struct {
+	lines  []chatLine
+	input  string
+	typing bool
+	// send limits what we send; recv drops floods from a direct peer
+	send, recv floodGate
+}
+
+func (c chatBox) add(from, text string) chatBox {
+	if text = cleanChat(text); text == "" {
+		return c
+	}
+	c.lines = append(c.lines, chatLine{from: cleanName(from), text: text})
+	if len(c.lines) > keptChat {
+		c.lines = c.lines[len(c.lines)-keptChat:]
+	}
+	return c
+}
+
+// receive adds a line from someone else unless they are flooding.
+func (c chatBox) receive(from, text string) chatBox {
+	if !c.recv.allow(time.Now()) {
+		return c
+	}
+	return c.add(from, text)
+}
+
+// key edits the chat line while typing. It returns the text to send when
+// ENTER is pressed, or an empty string.
+func (c chatBox) key(msg tea.KeyMsg) (chatBox, string) {
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyTab:
+		c.typing = false
+	case tea.KeyBackspace:
+		if r := []rune(c.input); len(r) > 0 {
+			c.input = string(r[:len(r)-1])
+		}
+	case tea.KeyEnter:
+		text := cleanChat(c.input)
+		if text == "" {
+			return c, ""
+		}
+		if !c.send.allow(time.Now()) {
+			c = c.add("", floodNotice)
+			return c, ""
+		}
+		c.input = ""
+		return c, text
+	case tea.KeySpace:
+		c.input += " "
+	case tea.KeyRunes:
+		if len([]rune(c.input))+len(msg.Runes) <= maxChatLen {
+			c.input += string(msg.Runes)
+		}
+	}
+	return c, ""
+}
+
+// render draws the last few lines and the input line.
+func (c chatBox) render(st *styles) []string {
+	var out []string
+	for _, l := range c.lines[max(0, len(c.lines)-shownChat):] {
+		if l.from == "" {
+			out = append(out, st.help.Render(l.text))
+		} else {
+			out = append(out, st.stats.Render(fmt.Sprintf("<%s> %s", l.from, l.text)))
+		}
+	}
+	if c.typing {
+		out = append(out, st.highlight.Render("say: "+c.input+"_"))
+	} else {
+		out = append(out, st.help.Render("[TAB: chat]"))
+	}
+	return out
+}
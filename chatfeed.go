@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Streamers can let their audience throw words at them: words from a
+// Twitch or YouTube live chat jump the dictionary queue. Only plain a-z
+// words of a typable length are taken, and each viewer is rate limited.
+
+const (
+	// minThrownLen keeps chat filler like "a" and "ok" off the screen
+	minThrownLen = 3
+	// maxThrown bounds the queue; the oldest words are dropped first
+	maxThrown = 20
+)
+
+// thrownMsg carries words picked out of one chat message.
+type thrownMsg struct {
+	from  string
+	words []string
+}
+
+// chatWords picks the usable words out of a chat message.
+func chatWords(text string) []string {
+	if strings.HasPrefix(text, "!") {
+		// Bot commands
+		return nil
+	}
+	var words []string
+	for _, f := range strings.Fields(strings.ToLower(text)) {
+		f = strings.Trim(f, ".,!?;:'\"()")
+		if len(f) < minThrownLen || len(f) > maxWordLen || strings.IndexFunc(f, func(r rune) bool { return r < 'a' || r > 'z' }) >= 0 {
+			continue
+		}
+		words = append(words, f)
+	}
+	return words
+}
+
+// chatFeed forwards usable chat words to the program, at most one message
+// per viewer every chatRefill after a short burst.
+type chatFeed struct {
+	prog  *tea.Program
+	mu    sync.Mutex
+	gates map[string]*floodGate
+}
+
+func (f *chatFeed) message(from, text string) {
+	words := chatWords(text)
+	if len(words) == 0 {
+		return
+	}
+	f.mu.Lock()
+	g := f.gates[from]
+	if g == nil {
+		g = &floodGate{}
+		f.gates[from] = g
+	}
+	ok := g.allow(time.Now())
+	f.mu.Unlock()
+	if ok {
+		f.prog.Send(thrownMsg{from: from, words: words})
+	}
+}
+
+// throw queues words from chat to be spawned ahead of the dictionary.
+func (m model) throw(msg thrownMsg) model {
+	if m.coop {
+		// Chat can't know whose half of the keyboard a word needs
+		return m
+	}
+	m.thrown = append(m.thrown, msg.words...)
+	if len(m.thrown) > maxThrown {
+		m.thrown = m.thrown[len(m.thrown)-maxThrown:]
+	}
+	logger.Debug("chat words", "from", msg.from, "words", msg.words)
+	return m
+}
+
+// nextThrown takes the first queued chat word that fits the playfield and
+// doesn't clash with the words on screen, reporting false if none does.
+// Words too wide for the playfield are dropped; clashing ones stay queued
+// until the screen clears.
+func (m model) nextThrown() (model, string, bool) {
+	var text string
+	var kept []string
+	for _, w := range m.thrown {
+		switch {
+		case len(w) >= m.fieldWidth():
+		case text == "" && !m.clashes(w, 0):
+			text = w
+		default:
+			kept = append(kept, w)
+		}
+	}
+	m.thrown = kept
+	if text == "" {
+		return m, "", false
+	}
+	m.recent = append(m.recent, text)
+	if len(m.recent) > recentSpawns {
+		m.recent = m.recent[1:]
+	}
+	return m, text, true
+}
+
+// twitchIRC is Twitch's chat server; anonymous "justinfan" logins can
+// read any channel.
+const twitchIRC = "irc.chat.twitch.tv:6667"
+
+// followTwitch reads a channel's chat, reconnecting whenever it drops.
+func (f *chatFeed) followTwitch(channel string) {
+	channel = "#" + strings.ToLower(strings.TrimPrefix(channel, "#"))
+	for backoff := time.Second; ; backoff = min(backoff*2, time.Minute) {
+		err := f.readTwitch(channel)
+		logger.Warn("twitch chat", "channel", channel, "err", err, "retry", backoff)
+		time.Sleep(backoff)
+	}
+}
+
+func (f *chatFeed) readTwitch(channel string) error {
+	conn, err := net.DialTimeout("tcp", twitchIRC, 10*time.Second)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	fmt.Fprintf(conn, "NICK justinfan%d\r\nJOIN %s\r\n", time.Now().UnixNano()%100000, channel)
+	logger.Info("twitch chat", "channel", channel)
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if rest, ok := strings.CutPrefix(line, "PING "); ok {
+			fmt.Fprintf(conn, "PONG %s\r\n", rest)
+			continue
+		}
+		// :nick!nick@nick.tmi.twitch.tv PRIVMSG #channel :text
+		prefix, rest, ok := strings.Cut(line, " PRIVMSG ")
+		if !ok {
+			continue
+		}
+		_, text, ok := strings.Cut(rest, " :")
+		if !ok {
+			continue
+		}
+		from, _, _ := strings.Cut(strings.TrimPrefix(prefix, ":"), "!")
+		f.message(from, text)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("connection closed")
+}
+
+// youtubeChatURL is the YouTube Data API's live chat endpoint.
+const youtubeChatURL = "https://www.googleapis.com/youtube/v3/liveChat/messages"
+
+type youtubeChatPage struct {
+	NextPageToken         string `json:"nextPageToken"`
+	PollingIntervalMillis int    `json:"pollingIntervalMillis"`
+	Items                 []struct {
+		Snippet struct {
+			DisplayMessage string `json:"displayMessage"`
+		} `json:"snippet"`
+		AuthorDetails struct {
+			ChannelID string `json:"channelId"`
+		} `json:"authorDetails"`
+	} `json:"items"`
+}
+
+// followYouTube polls a live chat at the rate the API asks for.
+func (f *chatFeed) followYouTube(chatID, key string) {
+	var token string
+	first := true
+	for {
+		page, err := fetchYouTubeChat(chatID, key, token)
+		wait := 5 * time.Second
+		if err != nil {
+			logger.Warn("youtube chat", "err", err)
+		} else {
+			token = page.NextPageToken
+			if !first {
+				// The first page is backlog from before the game started
+				for _, item := range page.Items {
+					f.message(item.AuthorDetails.ChannelID, item.Snippet.DisplayMessage)
+				}
+			}
+			first = false
+			wait = max(wait, time.Duration(page.PollingIntervalMillis)*time.Millisecond)
+		}
+		time.Sleep(wait)
+	}
+}
+
+func fetchYouTubeChat(chatID, key, token string) (*youtubeChatPage, error) {
+	q := url.Values{"liveChatId": {chatID}, "part": {"snippet,authorDetails"}, "key": {key}}
+	if token != "" {
+		q.Set("pageToken", token)
+	}
+	resp, err := httpClient.Get(youtubeChatURL + "?" + q.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("youtube chat: %s", resp.Status)
+	}
+	var page youtubeChatPage
+	return &page, json.NewDecoder(resp.Body).Decode(&page)
+}
@@ -0,0 +1,393 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// A teacher hosts a class session and reads out its join code; students
+// join with it and wait. When the teacher starts the session every student
+// gets the same seed and word list, so they all play the same game, and
+// reports progress each tick to the teacher's dashboard.
+//
+// Class messages: "join" (Version, Name, Text = code) from the student, answered by
+// "welcome" or "error" (Text); "start" (Seed, WordList) from the teacher;
+// "progress" every tick and "done" at the end from the student (Score,
+// Lives, Level, Words, WPM, Accuracy).
+
+const joinCodeChars = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+func newJoinCode() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	for i := range b {
+		b[i] = joinCodeChars[int(b[i])%len(joinCodeChars)]
+	}
+	return string(b)
+}
+
+// student is the teacher's view of one student.
+type student struct {
+	name     string
+	p        *peer
+	status   string
+	score    int
+	level    int
+	lives    int
+	words    int
+	wpm      int
+	accuracy float64
+	joined   time.Time
+	finished time.Time
+}
+
+// classroom is a teacher's session, shared by the connection handlers and
+// the dashboard.
+type classroom struct {
+	mu       sync.Mutex
+	code     string
+	seed     int64
+	words    []string
+	started  bool
+	students []*student
+	prog     *tea.Program
+}
+
+// refresh redraws the dashboard after the class changes.
+func (c *classroom) refresh() {
+	if c.prog != nil {
+		c.prog.Send(classUpdateMsg{})
+	}
+}
+
+func (c *classroom) startMsg() netMsg {
+	return netMsg{Type: "start", Seed: c.seed, WordList: c.words}
+}
+
+// start sends the game to everyone who has joined; later arrivals get it
+// as they join.
+func (c *classroom) start() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.started {
+		return
+	}
+	c.started = true
+	for _, s := range c.students {
+		if s.status == "waiting" {
+			s.status = "playing"
+			s.p.send(c.startMsg())
+		}
+	}
+	logger.Info("class start", "code", c.code, "students", len(c.students))
+}
+
+func (c *classroom) handle(conn net.Conn) {
+	defer conn.Close()
+	p := newPeer(conn)
+	join, err := p.recv()
+	if err != nil || join.Type != "join" || !p.checkVersion(join) {
+		return
+	}
+	if !strings.EqualFold(strings.TrimSpace(join.Text), c.code) {
+		p.send(netMsg{Type: "error", Text: "wrong class code"})
+		return
+	}
+	name := cleanName(join.Name)
+	if name == "" {
+		name = conn.RemoteAddr().String()
+	}
+
+	s := &student{name: name, p: p, status: "waiting", joined: time.Now()}
+	c.mu.Lock()
+	c.students = append(c.students, s)
+	p.send(netMsg{Type: "welcome"})
+	if c.started {
+		s.status = "playing"
+		p.send(c.startMsg())
+	}
+	c.mu.Unlock()
+	c.refresh()
+	logger.Info("student joined", "name", name)
+	go p.heartbeat()
+
+	for {
+		msg, err := p.recv()
+		if err != nil {
+			break
+		}
+		c.mu.Lock()
+		s.score, s.level, s.lives, s.words = msg.Score, msg.Level, msg.Lives, msg.Words
+		s.wpm, s.accuracy = msg.WPM, msg.Accuracy
+		if msg.Type == "done" {
+			s.status = "done"
+			s.finished = time.Now()
+		}
+		c.mu.Unlock()
+		c.refresh()
+	}
+	c.mu.Lock()
+	if s.status != "done" {
+		s.status = "left"
+	}
+	c.mu.Unlock()
+	c.refresh()
+}
+
+// snapshot copies the students, best score first.
+func (c *classroom) snapshot() []student {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]student, len(c.students))
+	for i, s := range c.students {
+		out[i] = *s
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].score > out[j].score })
+	return out
+}
+
+// exportCSV writes one row per student.
+func (c *classroom) exportCSV(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	cw := csv.NewWriter(file)
+	cw.Write([]string{"name", "status", "score", "level", "lives", "words_typed", "wpm", "accuracy", "joined", "finished"})
+	for _, s := range c.snapshot() {
+		finished := ""
+		if !s.finished.IsZero() {
+			finished = s.finished.Format(time.RFC3339)
+		}
+		cw.Write([]string{
+			s.name,
+			s.status,
+			strconv.Itoa(s.score),
+			strconv.Itoa(s.level),
+			strconv.Itoa(s.lives),
+			strconv.Itoa(s.words),
+			strconv.Itoa(s.wpm),
+			strconv.FormatFloat(s.accuracy, 'f', 1, 64),
+			s.joined.Format(time.RFC3339),
+			finished,
+		})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// Dashboard
+
+type classUpdateMsg struct{}
+
+type classExportedMsg struct {
+	path string
+	err  error
+}
+
+type dashboardModel struct {
+	class  *classroom
+	addr   string
+	out    string
+	styles *styles
+	notice string
+}
+
+func (m dashboardModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m dashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "ctrl+c":
+			return m, tea.Quit
+		case "s":
+			m.class.start()
+			m.notice = "Game started"
+		case "e":
+			path := m.out
+			return m, func() tea.Msg {
+				return classExportedMsg{path, m.class.exportCSV(path)}
+			}
+		}
+	case classExportedMsg:
+		if msg.err != nil {
+			m.notice = fmt.Sprintf("Export failed: %v", msg.err)
+		} else {
+			m.notice = "Exported to " + msg.path
+		}
+	}
+	return m, nil
+}
+
+func (m dashboardModel) View() string {
+	var b strings.Builder
+	b.WriteString("\n" + m.styles.title.Render("CLASSROOM") + "  ")
+	b.WriteString(m.styles.pause.Render("Join code: "+m.class.code) + "\n")
+	b.WriteString(m.styles.help.Render(fmt.Sprintf("Students run: %s class join -code %s %s", progName(), m.class.code, m.addr)) + "\n\n")
+
+	students := m.class.snapshot()
+	best := 1
+	for _, s := range students {
+		best = max(best, s.words)
+	}
+	b.WriteString(m.styles.help.Render(fmt.Sprintf("%-16s %-8s %6s %3s %3s %4s %6s  %s", "NAME", "STATUS", "SCORE", "LVL", "HP", "WPM", "ACC", "PROGRESS")) + "\n")
+	if len(students) == 0 {
+		b.WriteString(m.styles.stats.Render("Waiting for students to join...") + "\n")
+	}
+	for _, s := range students {
+		const barWidth = 20
+		filled := s.words * barWidth / best
+		bar := strings.Repeat("#", filled) + strings.Repeat(".", barWidth-filled)
+		line := fmt.Sprintf("%-16.16s %-8s %6d %3d %3d %4d %5.1f%%  %s %d",
+			s.name, s.status, s.score, s.level, s.lives, s.wpm, s.accuracy, bar, s.words)
+		if s.status == "left" {
+			b.WriteString(m.styles.help.Render(line) + "\n")
+		} else {
+			b.WriteString(m.styles.stats.Render(line) + "\n")
+		}
+	}
+	if m.notice != "" {
+		b.WriteString("\n" + m.styles.stats.Render(m.notice) + "\n")
+	}
+	b.WriteString("\n" + m.styles.help.Render("[s: start the game | e: export CSV | q: end the session]"))
+	return b.String()
+}
+
+// hostClass runs a class session with the teacher's dashboard.
+func hostClass(args []string) error {
+	fs := flag.NewFlagSet("class host", flag.ExitOnError)
+	addr := fs.String("addr", ":4002", "Address students connect to")
+	dictPath := fs.String("d", "/usr/share/dict/words", "Dictionary the class plays with")
+	out := fs.String("o", "", "CSV file for the e key (default: class-CODE.csv)")
+	fs.Parse(args)
+
+	dict, err := loadDictionary(*dictPath)
+	if err != nil {
+		return fmt.Errorf("loading dictionary: %w", err)
+	}
+	if dict.len() == 0 {
+		return errors.New("dictionary is empty")
+	}
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+
+	c := &classroom{code: newJoinCode(), seed: time.Now().UnixNano(), words: dict.words}
+	if *out == "" {
+		*out = "class-" + c.code + ".csv"
+	}
+	m := dashboardModel{class: c, addr: ln.Addr().String(), out: *out, styles: newStyles(lipgloss.DefaultRenderer())}
+	c.prog = tea.NewProgram(m, tea.WithAltScreen())
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go c.handle(conn)
+		}
+	}()
+	_, err = c.prog.Run()
+	return err
+}
+
+// Student
+
+func (m model) progressMsg() netMsg {
+	msg := netMsg{
+		Type:     "progress",
+		Score:    m.score,
+		Lives:    m.lives,
+		Level:    m.level,
+		Words:    m.wordsTyped,
+		WPM:      wpm(m.wordsTyped, m.elapsed()),
+		Accuracy: m.tally.accuracy(),
+	}
+	if m.gameOver {
+		msg.Type = "done"
+	}
+	return msg
+}
+
+// joinClass joins a teacher's session and plays the class game.
+func joinClass(args []string) error {
+	fs := flag.NewFlagSet("class join", flag.ExitOnError)
+	code := fs.String("code", "", "The join code the teacher gave out")
+	name := fs.String("name", os.Getenv("USER"), "Name shown to the teacher")
+	fs.Parse(args)
+	if fs.NArg() != 1 || *code == "" {
+		return fmt.Errorf("usage: %s class join -code CODE host:port", progName())
+	}
+
+	conn, err := net.DialTimeout("tcp", fs.Arg(0), 10*time.Second)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	p := newPeer(conn)
+	if err := p.send(netMsg{Type: "join", Version: protocolVersion, Name: *name, Text: *code}); err != nil {
+		return err
+	}
+	reply, err := p.recv()
+	if err != nil {
+		return fmt.Errorf("joining class: %w", err)
+	}
+	if reply.Type == "error" {
+		return errors.New(reply.Text)
+	}
+	fmt.Printf("Joined class %s as %s. Waiting for the teacher to start...\n", strings.ToUpper(*code), *name)
+	go p.heartbeat()
+	start, err := p.recv()
+	if err != nil || start.Type != "start" {
+		return errors.New("the class ended before it started")
+	}
+
+	dict := newDictionary(start.WordList)
+	if dict.len() == 0 {
+		return errors.New("the class dictionary is empty")
+	}
+	m := initialModel(dict)
+	m.seed = start.Seed
+	m.rng.Seed(start.Seed)
+	m.title = false
+	m.classroom = p
+	m.presence = startPresence()
+	defer m.presence.close()
+	gameStarted()
+	logger.Info("class start", "code", *code)
+
+	final, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+	if err != nil {
+		return err
+	}
+	fm := final.(model)
+	done := fm.progressMsg()
+	done.Type = "done"
+	p.send(done)
+	if _, err := fm.finish(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error recording game: %v\n", err)
+	}
+	return nil
+}
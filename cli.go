@@ -0,0 +1,322 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// command is a subcommand with its own flag set.
+type command struct {
+	name    string
+	summary string
+	flags   *flag.FlagSet
+	run     func(args []string) error
+	// completeArgs are positional arguments offered by shell completion
+	completeArgs []string
+}
+
+func newCommand(name, summary string) *command {
+	return &command{
+		name:    name,
+		summary: summary,
+		flags:   flag.NewFlagSet(name, flag.ExitOnError),
+	}
+}
+
+func commands() []*command {
+	var opts playOptions
+	playCmd := newCommand("play", "Play the game (the default when no command is given)")
+	addPlayFlags(playCmd.flags, &opts)
+	playCmd.run = func(args []string) error {
+		return play(opts)
+	}
+
+	statsCmd := newCommand("stats", "Show lifetime statistics; 'stats dashboard' charts them, 'stats history' browses recent games and matches, 'stats export' dumps the history as JSON or CSV")
+	statsCmd.run = func(args []string) error {
+		if len(args) > 0 {
+			switch args[0] {
+			case "export":
+				return exportStats(args[1:])
+			case "dashboard":
+				return showDashboard()
+			case "history":
+				return showHistory(args[1:])
+			}
+		}
+		return printStats()
+	}
+	statsCmd.completeArgs = []string{"dashboard", "history", "export"}
+
+	goalsCmd := newCommand("goals", "Show practice goals and your streak; 'goals add wpm=60/5' or 'goals add daily=10m' sets one, 'goals remove N' drops one")
+	goalsCmd.run = func(args []string) error {
+		return runGoals(args)
+	}
+	goalsCmd.completeArgs = []string{"add", "remove"}
+
+	dictCmd := newCommand("dict", "Inspect a dictionary file")
+	dictPath := dictCmd.flags.String("d", "/usr/share/dict/words", "Path to dictionary file")
+	prefix := dictCmd.flags.String("prefix", "", "List words starting with this prefix")
+	length := dictCmd.flags.Int("len", 0, "List words of exactly this length")
+	dictCmd.run = func(args []string) error {
+		return inspectDictionary(*dictPath, *prefix, *length)
+	}
+
+	configCmd := newCommand("config", "Show where saves and stats are stored; 'config set KEY VALUE' changes a setting")
+	configCmd.run = func(args []string) error {
+		if len(args) > 0 && args[0] == "set" {
+			return setSetting(args[1:])
+		}
+		return printConfig()
+	}
+	configCmd.completeArgs = []string{"set"}
+
+	accountCmd := newCommand("account", "Show your account's profile from the leaderboard server; 'account register' creates one, 'account logout' forgets it")
+	accountCmd.run = func(args []string) error {
+		return runAccount(args)
+	}
+	accountCmd.completeArgs = []string{"register", "logout"}
+
+	replaysCmd := newCommand("replays", "Browse the replay archive on a leaderboard server and watch replays; 'replays play FILE' watches a downloaded one")
+	replaysCmd.run = func(args []string) error {
+		return runReplays(args)
+	}
+	replaysCmd.completeArgs = []string{"play"}
+
+	replayCmd := newCommand("replay", "Watch a downloaded replay file: replay [-d dict] FILE")
+	replayDict := replayCmd.flags.String("d", "/usr/share/dict/words", "Dictionary the replay was played with")
+	replayCmd.run = func(args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("usage: %s replay [-d dict] FILE", progName())
+		}
+		return playReplayFile(*replayDict, args[0])
+	}
+
+	versionCmd := newCommand("version", "Print version and build information")
+	versionCmd.run = func(args []string) error {
+		fmt.Printf("%s %s\n", progName(), versionString())
+		return nil
+	}
+
+	updateCmd := newCommand("self-update", "Download the latest release and replace this binary")
+	force := updateCmd.flags.Bool("force", false, "Reinstall even if already on the latest version")
+	updateCmd.run = func(args []string) error {
+		return selfUpdate(*force)
+	}
+
+	var serverOpts serverOptions
+	serverCmd := newCommand("server", "Host the game over SSH; each player's key is their profile")
+	addServerFlags(serverCmd.flags, &serverOpts)
+	serverCmd.run = func(args []string) error {
+		return serve(serverOpts)
+	}
+
+	var boardOpts leaderboardOptions
+	boardCmd := newCommand("leaderboard", "Run the online leaderboard server players submit scores to")
+	addLeaderboardFlags(boardCmd.flags, &boardOpts)
+	boardCmd.run = func(args []string) error {
+		return serveLeaderboard(boardOpts)
+	}
+
+	var versusOpts versusOptions
+	versusCmd := newCommand("versus", "Play a head-to-head match against another player over the network")
+	addVersusFlags(versusCmd.flags, &versusOpts)
+	versusCmd.run = func(args []string) error {
+		return versus(versusOpts)
+	}
+
+	lobbyCmd := newCommand("lobby", "Run a versus lobby where players find opponents")
+	var lobbyAddr string
+	var bracket int
+	var lobbyAccounts string
+	addLobbyFlags(lobbyCmd.flags, &lobbyAddr, &bracket, &lobbyAccounts)
+	lobbyCmd.run = func(args []string) error {
+		return serveLobby(lobbyAddr, bracket, lobbyAccounts)
+	}
+
+	classCmd := newCommand("class", "Run a classroom session: 'class host' for the teacher, 'class join' for students")
+	classCmd.run = func(args []string) error {
+		if len(args) > 0 {
+			switch args[0] {
+			case "host":
+				return hostClass(args[1:])
+			case "join":
+				return joinClass(args[1:])
+			}
+		}
+		return fmt.Errorf("usage: %s class host|join [flags]", progName())
+	}
+	classCmd.completeArgs = []string{"host", "join"}
+
+	watchCmd := newCommand("watch", "Watch a game being played with -spectate: watch host:8090 or ws://host:8090/watch/<id>")
+	watchCmd.run = func(args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("usage: %s watch <url>", progName())
+		}
+		return watch(args[0])
+	}
+
+	completionCmd := newCommand("completion", "Print a shell completion script (bash, zsh or fish)")
+	completionCmd.run = func(args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("usage: %s completion bash|zsh|fish", progName())
+		}
+		return printCompletion(args[0])
+	}
+	completionCmd.completeArgs = []string{"bash", "zsh", "fish"}
+
+	return []*command{playCmd, statsCmd, goalsCmd, accountCmd, replaysCmd, replayCmd, dictCmd, configCmd, versusCmd, serverCmd, lobbyCmd, classCmd, watchCmd, boardCmd, versionCmd, updateCmd, completionCmd}
+}
+
+func progName() string {
+	return filepath.Base(os.Args[0])
+}
+
+// runCLI dispatches args to a subcommand. Arguments that start with a flag
+// go to play, so the old single-command invocations keep working.
+func runCLI(args []string) error {
+	cmds := commands()
+	name := "play"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	} else if len(args) > 0 && (args[0] == "--version" || args[0] == "-version") {
+		name, args = "version", args[1:]
+	}
+
+	if name == "help" {
+		if len(args) > 0 {
+			if cmd := findCommand(cmds, args[0]); cmd != nil {
+				cmd.flags.Usage()
+				return nil
+			}
+		}
+		printUsage(cmds)
+		return nil
+	}
+
+	cmd := findCommand(cmds, name)
+	if cmd == nil {
+		printUsage(cmds)
+		return fmt.Errorf("unknown command %q", name)
+	}
+	cmd.flags.Parse(args)
+	return cmd.run(cmd.flags.Args())
+}
+
+func findCommand(cmds []*command, name string) *command {
+	for _, cmd := range cmds {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+func printUsage(cmds []*command) {
+	fmt.Fprintf(os.Stderr, "Usage: %s [command] [flags]\n\nCommands:\n", progName())
+	for _, cmd := range cmds {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun '%s help <command>' for a command's flags.\n", progName())
+}
+
+func printConfig() error {
+	dir, err := dataDir()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Data directory: %s\n", dir)
+	fmt.Printf("History:        %s\n", filepath.Join(dir, "history.jsonl"))
+	fmt.Printf("Autosave:       %s\n", filepath.Join(dir, "autosave.json"))
+	fmt.Printf("Settings:       %s\n", filepath.Join(dir, "config.json"))
+	s, err := loadSettings()
+	if err != nil {
+		return fmt.Errorf("reading settings: %w", err)
+	}
+	discord := "off"
+	if s.Discord {
+		discord = "on"
+	}
+	if s.DiscordApp != "" {
+		discord += " (application " + s.DiscordApp + ")"
+	}
+	fmt.Printf("\nDiscord presence: %s\n", discord)
+	if ticks := s.levelTicks(); ticks > 0 {
+		fmt.Printf("Leveling:         every %v\n", time.Duration(ticks)*tickRate)
+	} else {
+		fmt.Printf("Leveling:         every %d words\n", wordsPerLevel)
+	}
+	hud := s.HUD
+	if hud == nil {
+		hud = defaultHUD
+	}
+	fmt.Printf("Status line:      %s\n", strings.Join(hud, ","))
+	effects := s.Effects
+	if effects == "" {
+		effects = defaultEffects
+	}
+	fmt.Printf("Effects:          %s\n", effects)
+	fmt.Printf("Graveyard:        %s\n", graveyardStatus(s.Graveyard))
+	if s.Submit == "enter" {
+		fmt.Println("Words finish:     on enter")
+	} else {
+		fmt.Println("Words finish:     on their last letter")
+	}
+	lo, hi := s.Lengths.band(1)
+	fmt.Printf("Word lengths:     %d-%d letters at level 1, growing %g a level\n", lo, hi, s.Lengths.Growth)
+	fmt.Printf("Pace:             words fall %g rows/s (+%g a level), spawn checks %g/s (+%g a level)\n", s.Pace.Fall, s.Pace.FallGrowth, s.Pace.Spawn, s.Pace.SpawnGrowth)
+	if s.Adaptive > 0 {
+		fmt.Printf("Adaptive pace:    strength %g\n", s.Adaptive)
+	} else {
+		fmt.Printf("Adaptive pace:    off\n")
+	}
+	if s.WarmUp > 0 {
+		fmt.Printf("Warm-up:          shorter words for the first %v\n", s.WarmUp)
+	} else {
+		fmt.Printf("Warm-up:          off\n")
+	}
+	if s.Boundary > 0 {
+		fmt.Printf("Boundary:         %d %s above the bottom\n", s.Boundary, plural(s.Boundary, "row"))
+	} else {
+		fmt.Printf("Boundary:         the bottom row\n")
+	}
+	sc := s.Scoring
+	fmt.Printf("Scoring:          letter %g, level %g, speed %g, accuracy %g, combo %g\n", sc.Letter, sc.Level, sc.Speed, sc.Accuracy, sc.Combo)
+	if s.ExtraLife.Every > 0 {
+		fmt.Printf("Extra life:       every %d points, up to %d lives\n", s.ExtraLife.Every, s.ExtraLife.Max)
+	} else {
+		fmt.Printf("Extra life:       off\n")
+	}
+	return nil
+}
+
+func inspectDictionary(path, prefix string, length int) error {
+	dict, err := loadDictionary(path)
+	if err != nil {
+		return fmt.Errorf("loading dictionary: %w", err)
+	}
+
+	switch {
+	case prefix != "":
+		for _, w := range dict.withPrefix(strings.ToLower(prefix)) {
+			if length == 0 || len(w) == length {
+				fmt.Println(w)
+			}
+		}
+	case length != 0:
+		for _, w := range dict.ofLength(length) {
+			fmt.Println(w)
+		}
+	default:
+		fmt.Printf("%s: %d words\n", path, dict.len())
+		for n := minWordLen; n <= maxWordLen; n++ {
+			if count := len(dict.ofLength(n)); count > 0 {
+				fmt.Printf("  %2d letters: %d\n", n, count)
+			}
+		}
+	}
+	return nil
+}
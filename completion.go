@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// printCompletion writes a completion script for shell, generated from the
+// command table so new commands and flags are picked up automatically.
+func printCompletion(shell string) error {
+	cmds := commands()
+	switch shell {
+	case "bash":
+		fmt.Print(bashCompletion(cmds))
+	case "zsh":
+		// zsh can load bash completions through bashcompinit
+		fmt.Print("autoload -U +X bashcompinit && bashcompinit\n" + bashCompletion(cmds))
+	case "fish":
+		fmt.Print(fishCompletion(cmds))
+	default:
+		return fmt.Errorf("unsupported shell %q (want bash, zsh or fish)", shell)
+	}
+	return nil
+}
+
+func flagNames(fs *flag.FlagSet) []string {
+	var names []string
+	fs.VisitAll(func(f *flag.Flag) {
+		names = append(names, "-"+f.Name)
+	})
+	return names
+}
+
+func bashCompletion(cmds []*command) string {
+	prog := progName()
+	fn := "_" + strings.NewReplacer("-", "_", ".", "_").Replace(prog)
+
+	var names []string
+	for _, cmd := range cmds {
+		names = append(names, cmd.name)
+	}
+	// A bare flag as the first word means play
+	top := append([]string{"help"}, names...)
+	top = append(top, flagNames(cmds[0].flags)...)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s() {\n", fn)
+	b.WriteString("\tlocal cur=${COMP_WORDS[COMP_CWORD]}\n")
+	b.WriteString("\tif [ \"$COMP_CWORD\" -eq 1 ]; then\n")
+	fmt.Fprintf(&b, "\t\tCOMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(top, " "))
+	b.WriteString("\t\treturn\n\tfi\n")
+	b.WriteString("\tcase ${COMP_WORDS[1]} in\n")
+	for _, cmd := range cmds {
+		words := append(flagNames(cmd.flags), cmd.completeArgs...)
+		fmt.Fprintf(&b, "\t%s) COMPREPLY=($(compgen -W %q -- \"$cur\")) ;;\n", cmd.name, strings.Join(words, " "))
+	}
+	fmt.Fprintf(&b, "\thelp) COMPREPLY=($(compgen -W %q -- \"$cur\")) ;;\n", strings.Join(names, " "))
+	b.WriteString("\tesac\n}\n")
+	fmt.Fprintf(&b, "complete -o default -F %s %s\n", fn, prog)
+	return b.String()
+}
+
+func fishCompletion(cmds []*command) string {
+	prog := progName()
+	var b strings.Builder
+	for _, cmd := range cmds {
+		fmt.Fprintf(&b, "complete -c %s -n __fish_use_subcommand -a %s -d %q\n", prog, cmd.name, cmd.summary)
+		for _, arg := range cmd.completeArgs {
+			fmt.Fprintf(&b, "complete -c %s -n '__fish_seen_subcommand_from %s' -a %s\n", prog, cmd.name, arg)
+		}
+		cmd.flags.VisitAll(func(f *flag.Flag) {
+			fmt.Fprintf(&b, "complete -c %s -n '__fish_seen_subcommand_from %s' -o %s -d %q\n", prog, cmd.name, f.Name, f.Usage)
+		})
+	}
+	return b.String()
+}
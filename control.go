@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The control socket lets local tools drive and observe a game with
+// JSON-RPC 2.0, one request or notification per line. Methods:
+//
+//	state                  the score, level, lives and words on screen
+//	inject {"words":[..]}  spawn these words ahead of the dictionary
+//	pause, resume          pause or resume the game
+//	set_level {"level":N}  jump to level N
+//	subscribe              stream game events as "event" notifications
+//
+// Injected words and level changes keep the game off the leaderboard.
+
+// controlTimeout bounds how long a request waits for the game to answer.
+const controlTimeout = 2 * time.Second
+
+type rpcRequest struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcResponse struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  any             `json:"params,omitempty"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// JSON-RPC error codes.
+const (
+	rpcParseError     = -32700
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcInternalError  = -32603
+)
+
+// controlMsg carries a request into the program, which answers on reply.
+type controlMsg struct {
+	method string
+	params json.RawMessage
+	reply  chan controlReply
+}
+
+type controlReply struct {
+	result any
+	err    *rpcError
+}
+
+// controlState is the answer to "state".
+type controlState struct {
+	Score  int      `json:"score"`
+	Level  int      `json:"level"`
+	Lives  int      `json:"lives"`
+	Words  []string `json:"words"`
+	Input  string   `json:"input"`
+	Paused bool     `json:"paused"`
+	Over   bool     `json:"game_over"`
+}
+
+// control answers a request from the control socket.
+func (m model) control(msg controlMsg) (model, controlReply) {
+	switch msg.method {
+	case "state":
+		s := controlState{Score: m.score, Level: m.level, Lives: m.lives, Input: m.input, Paused: m.paused, Over: m.gameOver, Words: []string{}}
+		for _, w := range m.words {
+			s.Words = append(s.Words, w.text)
+		}
+		return m, controlReply{result: s}
+	case "inject":
+		var p struct {
+			Words []string `json:"words"`
+		}
+		if err := json.Unmarshal(msg.params, &p); err != nil {
+			return m, controlReply{err: &rpcError{rpcInvalidParams, err.Error()}}
+		}
+		if m.coop {
+			return m, controlReply{err: &rpcError{rpcInvalidParams, "co-op games can't take injected words"}}
+		}
+		words := chatWords(strings.Join(p.Words, " "))
+		m = m.throw(thrownMsg{from: "control", words: words})
+		return m, controlReply{result: len(words)}
+	case "pause", "resume":
+		if m.running() || m.paused {
+			m.paused = msg.method == "pause"
+			m.pauseReason = "remote"
+		}
+		return m, controlReply{result: m.paused}
+	case "set_level":
+		var p struct {
+			Level int `json:"level"`
+		}
+		if err := json.Unmarshal(msg.params, &p); err != nil || p.Level < 1 {
+			return m, controlReply{err: &rpcError{rpcInvalidParams, "level must be 1 or more"}}
+		}
+		m.level = p.Level
+		m.controlled = true
+		logger.Info("level set", "level", m.level)
+		return m, controlReply{result: m.level}
+	}
+	return m, controlReply{err: &rpcError{rpcMethodNotFound, "no method " + msg.method}}
+}
+
+// serveControl listens on the unix socket at path.
+func serveControl(path string, p *tea.Program, events *eventBus) (func(), error) {
+	// A socket left behind by a crash would block the listen
+	if fi, err := os.Stat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		os.Remove(path)
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				if !errors.Is(err, net.ErrClosed) {
+					logger.Warn("control socket", "err", err)
+				}
+				return
+			}
+			go handleControl(c, p, events)
+		}
+	}()
+	return func() { ln.Close() }, nil
+}
+
+func handleControl(c net.Conn, p *tea.Program, events *eventBus) {
+	defer c.Close()
+	var mu sync.Mutex
+	enc := json.NewEncoder(c)
+	send := func(r rpcResponse) {
+		r.Version = "2.0"
+		mu.Lock()
+		defer mu.Unlock()
+		enc.Encode(r)
+	}
+	var unsubscribe func()
+	defer func() {
+		if unsubscribe != nil {
+			unsubscribe()
+		}
+	}()
+
+	scanner := bufio.NewScanner(c)
+	for scanner.Scan() {
+		var req rpcRequest
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			send(rpcResponse{Error: &rpcError{rpcParseError, err.Error()}})
+			continue
+		}
+		var reply controlReply
+		if req.Method == "subscribe" {
+			if unsubscribe == nil {
+				var ch <-chan gameEvent
+				ch, unsubscribe = events.subscribe()
+				go func() {
+					for e := range ch {
+						send(rpcResponse{Method: "event", Params: e})
+					}
+				}()
+			}
+			reply.result = true
+		} else {
+			reply = askGame(p, req)
+		}
+		if req.ID == nil {
+			// A notification gets no response
+			continue
+		}
+		send(rpcResponse{ID: req.ID, Result: reply.result, Error: reply.err})
+	}
+}
+
+// askGame passes req to the program and waits for the answer.
+func askGame(p *tea.Program, req rpcRequest) controlReply {
+	msg := controlMsg{method: req.Method, params: req.Params, reply: make(chan controlReply, 1)}
+	// Send blocks while the program is busy, so don't hold up the timeout
+	go p.Send(msg)
+	select {
+	case r := <-msg.reply:
+		return r
+	case <-time.After(controlTimeout):
+		return controlReply{err: &rpcError{rpcInternalError, fmt.Sprintf("game didn't answer within %v", controlTimeout)}}
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Local co-op splits the keyboard down the middle. Player one owns words
+// typable with the left hand and player two words typable with the right,
+// so every keystroke belongs to exactly one player.
+const (
+	leftHandKeys  = "qwertasdfgzxcvb"
+	rightHandKeys = "yuiophjklnm"
+)
+
+// seat holds player two's side of a co-op game. Player one uses the
+// model's own input, current, score, lives and wordsTyped fields.
+type seat struct {
+	input   string
+	current *word
+	score   int
+	lives   int
+	words   int
+}
+
+// typableWith reports whether every letter of w is in keys.
+func typableWith(w, keys string) bool {
+	for _, r := range w {
+		if !strings.ContainsRune(keys, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// coopPools splits dict into the word pools for player one and two.
+func coopPools(dict *dictionary) ([2]*dictionary, error) {
+	pools := [2]*dictionary{
+		dict.filter(func(w string) bool { return typableWith(w, leftHandKeys) }),
+		dict.filter(func(w string) bool { return typableWith(w, rightHandKeys) }),
+	}
+	for i, p := range pools {
+		if p.len() == 0 {
+			return pools, fmt.Errorf("dictionary has no words for player %d's half of the keyboard", i+1)
+		}
+	}
+	return pools, nil
+}
+
+// withCoop sets m up for two players.
+func (m model) withCoop() (model, error) {
+	pools, err := coopPools(m.dict)
+	if err != nil {
+		return m, err
+	}
+	m.coop = true
+	m.pools = pools
+	m.partner.lives = m.lives
+	return m, nil
+}
+
+// swapSeats exchanges player one's fields with player two's.
+func (m model) swapSeats() model {
+	m.input, m.partner.input = m.partner.input, m.input
+	m.current, m.partner.current = m.partner.current, m.current
+	m.score, m.partner.score = m.partner.score, m.score
+	m.lives, m.partner.lives = m.partner.lives, m.lives
+	m.wordsTyped, m.partner.words = m.partner.words, m.wordsTyped
+	return m
+}
+
+// asPartner runs f with player two in player one's seat, so the
+// single-player logic serves both.
+func (m model) asPartner(f func(model) model) model {
+	m = m.swapSeats()
+	m.seat = 1
+	m = f(m)
+	m.seat = 0
+	return m.swapSeats()
+}
+
+// seatFor returns which player types r in co-op.
+func seatFor(r rune) int {
+	if strings.ContainsRune(rightHandKeys, r) {
+		return 1
+	}
+	return 0
+}
+
+// alive reports whether the given player still has lives.
+func (m model) alive(owner int) bool {
+	if owner == 1 {
+		return m.partner.lives > 0
+	}
+	return m.lives > 0
+}
+
+// coopMiss takes a life from the player who owned w. The game ends once
+// both players are out.
+func (m model) coopMiss(w word) model {
+	if w.owner == 1 {
+		m.partner.lives = max(m.partner.lives-1, 0)
+	} else {
+		m.lives = max(m.lives-1, 0)
+	}
+	logger.Warn("miss", "word", w.text, "player", w.owner+1)
+	m = m.note("life_lost", func(e *moment) { e.Word, e.Lives = w.text, m.lives+m.partner.lives })
+	if m.lives <= 0 && m.partner.lives <= 0 {
+		m = m.endGame()
+	}
+	return m
+}
+
+// renderCoopStatus is the split HUD shown instead of the solo status line.
+func (m model) renderCoopStatus() string {
+	p1 := fmt.Sprintf("P1 Score: %d  %-3s  Input: %-8s", m.score, strings.Repeat(lifeIcon, max(0, m.lives)), m.input)
+	p2 := fmt.Sprintf("P2 Score: %d  %s  Input: %s", m.partner.score, strings.Repeat(lifeIcon, max(0, m.partner.lives)), m.partner.input)
+	return m.styles.word.Render(p1) + m.styles.status.Render(fmt.Sprintf(" Level: %d  ", m.level)) + m.styles.partner.Render(p2)
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The crt renderer, -renderer crt, makes the screen look like an old
+// phosphor monitor. It draws like the ansi renderer, then turns every color
+// into a shade of green as bright as the color was, so what stood out
+// still does. Every other row of the playfield has faint scanlines through
+// its empty cells, and now and then the whole screen dims for a frame, a
+// slight flicker. It's purely cosmetic.
+
+const (
+	// scanline fills the empty cells of every other row
+	scanline = '─'
+	// flickerEvery is how many tenths of a second pass between flickers,
+	// and flickerDim how bright the screen is during one
+	flickerEvery = 37
+	flickerDim   = 0.8
+)
+
+// phosphor is the green a full-brightness color becomes, and dimmest how
+// dark the faintest shade gets, so dim text stays readable.
+var (
+	phosphor = [3]float64{0.2, 1, 0.2}
+	dimmest  = 50.0
+)
+
+// crtRenderer is the ansi renderer through a green screen.
+type crtRenderer struct {
+	// scan styles the scanlines
+	scan lipgloss.Style
+}
+
+func newCRTRenderer(r *lipgloss.Renderer) crtRenderer {
+	return crtRenderer{scan: r.NewStyle().Foreground(lipgloss.Color("#1C1C1C"))}
+}
+
+func (c crtRenderer) row(b *strings.Builder, m model, y int) {
+	if y%2 == 0 {
+		m.renderRuns(b, y)
+		return
+	}
+	row := m.frame.cells[y][:m.fieldWidth()]
+	kinds := m.frame.kinds[y][:m.fieldWidth()]
+	blank := func(x int) bool { return row[x] == ' ' }
+	start := 0
+	for x := 1; x <= len(row); x++ {
+		if x < len(row) && kinds[x] == kinds[start] && blank(x) == blank(start) {
+			continue
+		}
+		if blank(start) {
+			b.WriteString(c.scan.Render(strings.Repeat(string(scanline), x-start)))
+		} else {
+			b.WriteString(m.styles.cell(kinds[start]).Render(cellText(row[start:x])))
+		}
+		start = x
+	}
+}
+
+func (crtRenderer) finish(view string) string {
+	bright := 1.0
+	if time.Now().UnixMilli()/100%flickerEvery == 0 {
+		bright = flickerDim
+	}
+	// Text with no color of its own starts out phosphor green too
+	base := greenSGR(255*bright, false)
+	view = sgrPattern.ReplaceAllStringFunc(view, func(seq string) string {
+		return monochrome(seq, bright, base)
+	})
+	lines := strings.Split(view, "\n")
+	for i, l := range lines {
+		lines[i] = base + l + "\x1b[0m"
+	}
+	return strings.Join(lines, "\n")
+}
+
+// sgrPattern matches the escape sequences that set colors and attributes.
+var sgrPattern = regexp.MustCompile(`\x1b\[([0-9;]*)m`)
+
+// monochrome rewrites one SGR sequence with its colors turned green,
+// putting base back after a reset.
+func monochrome(seq string, bright float64, base string) string {
+	params := strings.Split(seq[2:len(seq)-1], ";")
+	var out []string
+	for i := 0; i < len(params); i++ {
+		p, _ := strconv.Atoi(params[i])
+		switch {
+		case p == 0:
+			out = append(out, "0", base[2:len(base)-1])
+		case (p == 38 || p == 48) && i+4 < len(params) && params[i+1] == "2":
+			r, _ := strconv.Atoi(params[i+2])
+			g, _ := strconv.Atoi(params[i+3])
+			bl, _ := strconv.Atoi(params[i+4])
+			lum := 0.2126*float64(r) + 0.7152*float64(g) + 0.0722*float64(bl)
+			out = append(out, greenParams(lum*bright, p == 48))
+			i += 4
+		case (p == 38 || p == 48) && i+2 < len(params) && params[i+1] == "5":
+			out = append(out, greenParams(200*bright, p == 48))
+			i += 2
+		case p >= 30 && p <= 37, p >= 90 && p <= 97:
+			out = append(out, greenParams(200*bright, false))
+		case p >= 40 && p <= 47, p >= 100 && p <= 107:
+			out = append(out, greenParams(200*bright, true))
+		default:
+			out = append(out, params[i])
+		}
+	}
+	return "\x1b[" + strings.Join(out, ";") + "m"
+}
+
+// greenSGR is the sequence setting a green as bright as lum out of 255.
+func greenSGR(lum float64, background bool) string {
+	return "\x1b[" + greenParams(lum, background) + "m"
+}
+
+func greenParams(lum float64, background bool) string {
+	lum = max(lum, dimmest)
+	if background {
+		// Backgrounds stay dark enough for the text on them
+		lum /= 2
+	}
+	which := 38
+	if background {
+		which = 48
+	}
+	return fmt.Sprintf("%d;2;%d;%d;%d", which, int(lum*phosphor[0]), int(lum*phosphor[1]), int(lum*phosphor[2]))
+}
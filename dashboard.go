@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The dashboard sums up the history on one screen: lifetime totals, the
+// best score in each mode, and how WPM and accuracy have gone over the
+// last trendGames games. It opens from the title screen with tab, and on
+// its own with 'stats dashboard'.
+
+const (
+	trendGames = 30
+	// chartRows is the height of the WPM bar chart
+	chartRows = 8
+)
+
+// blocks are the eighths a bar is drawn in, empty first.
+var blocks = []rune(" ▁▂▃▄▅▆▇█")
+
+// modeName is how the dashboard labels a session's mode.
+func modeName(s session) string {
+	switch {
+	case s.Mode != "":
+		return s.Mode
+	case s.Target > 0:
+		return "speedrun"
+	case s.Goal != "":
+		return "goal"
+	}
+	return "solo"
+}
+
+// barChart draws values as columns rows high, scaled so top fills them.
+func barChart(values []int, rows, top int) []string {
+	lines := make([]string, rows)
+	for r := range lines {
+		var b strings.Builder
+		floor := (rows - 1 - r) * 8
+		for _, v := range values {
+			eighths := 0
+			if top > 0 {
+				eighths = v * rows * 8 / top
+			}
+			b.WriteRune(blocks[min(max(eighths-floor, 0), 8)])
+			b.WriteRune(' ')
+		}
+		lines[r] = b.String()
+	}
+	return lines
+}
+
+// sparkline draws values in a single row, scaled from lo to hi.
+func sparkline(values []float64, lo, hi float64) string {
+	var b strings.Builder
+	for _, v := range values {
+		i := 8
+		if hi > lo {
+			i = 1 + int((v-lo)/(hi-lo)*7)
+		}
+		b.WriteRune(blocks[min(max(i, 1), 8)])
+		b.WriteRune(' ')
+	}
+	return b.String()
+}
+
+// renderDashboard draws the dashboard for sessions.
+func renderDashboard(st *styles, sessions []session) string {
+	var b strings.Builder
+	b.WriteString("\n" + st.title.Render("LIFETIME STATS") + "\n\n")
+	if len(sessions) == 0 {
+		b.WriteString(st.stats.Render("No games recorded yet.") + "\n")
+		return b.String()
+	}
+
+	var words, keys, typos int
+	var played time.Duration
+	best := map[string]session{}
+	var modes []string
+	for _, s := range sessions {
+		words += s.WordsTyped
+		keys += s.Keystrokes
+		typos += s.Typos
+		played += s.Duration
+		mode := modeName(s)
+		if prev, ok := best[mode]; !ok {
+			modes = append(modes, mode)
+			best[mode] = s
+		} else if s.Score > prev.Score {
+			best[mode] = s
+		}
+	}
+	accuracy := tally{Keystrokes: keys, Typos: typos}.accuracy()
+	b.WriteString(st.stats.Render(fmt.Sprintf("Games %d   Time %v   Words %d   WPM %d   CPM %d   Accuracy %.1f%%",
+		len(sessions), played.Round(time.Minute), words, wpm(words, played), cpm(keys, played), accuracy)) + "\n\n")
+
+	b.WriteString(st.pause.Render("Best scores") + "\n")
+	for _, mode := range modes {
+		s := best[mode]
+		b.WriteString(st.stats.Render(fmt.Sprintf("  %-9s %7d  level %2d  %s", mode, s.Score, s.Level, s.Time.Format("2006-01-02"))) + "\n")
+	}
+
+	recent := sessions[max(0, len(sessions)-trendGames):]
+	rates := make([]int, len(recent))
+	accs := make([]float64, len(recent))
+	top, lo := 1, 100.0
+	for i, s := range recent {
+		rates[i], accs[i] = s.WPM, s.Accuracy
+		top = max(top, s.WPM)
+		lo = min(lo, s.Accuracy)
+	}
+	b.WriteString("\n" + st.pause.Render(fmt.Sprintf("WPM, last %d games", len(recent))) + st.help.Render(fmt.Sprintf("  (top %d)", top)) + "\n")
+	for _, line := range barChart(rates, chartRows, top) {
+		b.WriteString("  " + st.word.Render(line) + "\n")
+	}
+	b.WriteString("\n" + st.pause.Render(fmt.Sprintf("Accuracy, last %d games", len(recent))) + st.help.Render(fmt.Sprintf("  (%.0f%% to 100%%)", lo)) + "\n")
+	b.WriteString("  " + st.popup.Render(sparkline(accs, lo, 100)) + "\n")
+	return b.String()
+}
+
+// dashboardScreen is the dashboard as a program of its own.
+type dashboardScreen struct {
+	styles   *styles
+	sessions []session
+}
+
+func (d dashboardScreen) Init() tea.Cmd {
+	return nil
+}
+
+func (d dashboardScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if _, ok := msg.(tea.KeyMsg); ok {
+		return d, tea.Quit
+	}
+	return d, nil
+}
+
+func (d dashboardScreen) View() string {
+	return renderDashboard(d.styles, d.sessions) + "\n" + d.styles.help.Render("Press any key to leave") + "\n"
+}
+
+// openDashboard shows the player's dashboard over the title screen.
+func (m model) openDashboard() model {
+	sessions, err := loadHistory(m.profile)
+	if err != nil {
+		logger.Error("reading history", "profile", m.profile, "err", err)
+	}
+	// An empty history still opens, to say there's nothing yet
+	m.dashboard = append([]session{}, sessions...)
+	return m
+}
+
+// showDashboard runs the dashboard for the local history.
+func showDashboard() error {
+	sessions, err := loadHistory("")
+	if err != nil {
+		return err
+	}
+	d := dashboardScreen{styles: newStyles(lipgloss.DefaultRenderer()), sessions: sessions}
+	_, err = tea.NewProgram(d, tea.WithAltScreen()).Run()
+	return err
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	_ "net/http/pprof"
+	"os"
+	"runtime"
+	"time"
+)
+
+// servePprof exposes the net/http/pprof handlers on addr in the background.
+func servePprof(addr string) {
+	go func() {
+		if err := http.ListenAndServe(addr, nil); err != nil {
+			fmt.Fprintf(os.Stderr, "pprof server: %v\n", err)
+		}
+	}()
+}
+
+// renderDebug draws the F3 overlay with frame timing, entity counts,
+// allocation stats and the current spawn odds.
+func (m model) renderDebug() string {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	return m.styles.debug.Render(fmt.Sprintf(
+		"frame: %v  words: %d  effects: %d  particles: %d\n"+
+			"heap: %d KiB  mallocs: %d  gc: %d\n"+
+			"min words: %d  spawn chance: %.0f%%  pace: x%.2f",
+		m.frame.renderTime.Round(time.Microsecond), len(m.words), len(m.effects), m.particleCount(),
+		mem.HeapAlloc/1024, mem.Mallocs, mem.NumGC,
+		m.minWords(), m.spawnChance()*100, m.flowRate(),
+	))
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// A dictionary line can carry a definition after the category, in a third
+// tab-separated column. While the game is paused, a panel beside the
+// playfield lists the words on screen with their definitions, lowest
+// first, and the arrow keys scroll it. The game underneath is left as it
+// was.
+
+// definitionsWidth is how wide the definitions panel wraps its text.
+const definitionsWidth = 36
+
+// define records the definitions of the dictionary's words.
+func (d *dictionary) define(definitions map[string]string) {
+	d.definitions = map[string]string{}
+	for _, w := range d.words {
+		if def, ok := definitions[w]; ok {
+			d.definitions[w] = def
+		}
+	}
+}
+
+// definitionsLines is the panel's text before scrolling: each defined word
+// on screen, lowest first, with its definition wrapped under it.
+func (m model) definitionsLines() []string {
+	words := make([]word, 0, len(m.words))
+	for _, w := range m.words {
+		if m.dict.definitions[w.text] != "" {
+			words = append(words, w)
+		}
+	}
+	sort.SliceStable(words, func(i, j int) bool { return words[i].y > words[j].y })
+	var lines []string
+	seen := map[string]bool{}
+	for _, w := range words {
+		if seen[w.text] {
+			continue
+		}
+		seen[w.text] = true
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, m.styles.highlight.Render(w.text))
+		for _, l := range wrapText(m.dict.definitions[w.text], definitionsWidth) {
+			lines = append(lines, m.styles.stats.Render(l))
+		}
+	}
+	return lines
+}
+
+// renderDefinitions is the pause screen's definitions panel.
+func (m model) renderDefinitions() []string {
+	lines := m.definitionsLines()
+	if len(lines) == 0 {
+		return nil
+	}
+	panel := []string{m.styles.title.Render("DEFINITIONS"), ""}
+	room := gameHeight - len(panel) - 1
+	scroll := min(m.defScroll, max(0, len(lines)-room))
+	panel = append(panel, lines[scroll:min(len(lines), scroll+room)]...)
+	if len(lines) > room {
+		panel = append(panel, m.styles.help.Render("[up/down: scroll]"))
+	}
+	return panel
+}
+
+// scrollDefinitions moves the definitions panel by delta lines.
+func (m model) scrollDefinitions(delta int) model {
+	room := gameHeight - 3
+	m.defScroll = min(max(0, m.defScroll+delta), max(0, len(m.definitionsLines())-room))
+	return m
+}
+
+// wrapText breaks s into lines of at most width columns at spaces.
+func wrapText(s string, width int) []string {
+	var lines []string
+	line := ""
+	for _, f := range strings.Fields(s) {
+		switch {
+		case line == "":
+			line = f
+		case len(line)+1+len(f) <= width:
+			line += " " + f
+		default:
+			lines = append(lines, line)
+			line = f
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"bufio"
+	"container/heap"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	// Filter for reasonable word lengths (1-12 chars) for better gameplay
+	minWordLen = 1
+	maxWordLen = 12
+
+	// maxDictWords bounds how many words are kept in memory. Larger lists
+	// are sampled while streaming so huge dictionaries never have to be
+	// held in full: the words with the lowest hashes are kept, so every
+	// load keeps the same ones, whatever order the file is in, and replays
+	// and challenge codes still match. The sample is cached in the data
+	// directory, and the full list is only read again when it changes.
+	maxDictWords = 100000
+)
+
+// dictionary holds the playable words sorted by length and then
+// alphabetically, so each length is a contiguous range and prefix lookups
+// are a binary search within each range.
+type dictionary struct {
+	words []string
+	// categories tags words from the optional second column of the
+	// dictionary file, and names lists the tags, sorted
+	categories map[string]string
+	names      []string
+	// definitions holds the optional third column, see definitions.go
+	definitions map[string]string
+	// display maps typed words to how they are drawn, for files with a
+	// typed column, see translit.go
+	display map[string]string
+	// start[n] is the index of the first word of length n; words of length
+	// n live in words[start[n]:start[n+1]].
+	start [maxWordLen + 2]int
+}
+
+func loadDictionary(path string) (*dictionary, error) {
+	return readDictionary(path, false)
+}
+
+// readDictionary loads the words at path, lowercased unless keepCase is
+// set. A list too long to keep in full is read from its cached sample when
+// there is one.
+func readDictionary(path string, keepCase bool) (*dictionary, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	cache := dictCachePath(file, path, keepCase)
+	if cached, err := os.Open(cache); err == nil {
+		defer cached.Close()
+		return scanDictionary(cached, path, keepCase)
+	}
+	d, sampled, err := sampleDictionary(file, path, keepCase)
+	if err == nil && sampled && cache != "" {
+		if err := d.save(cache); err != nil {
+			logger.Warn("caching dictionary sample", "dict", path, "err", err)
+		}
+	}
+	return d, err
+}
+
+// scanDictionary reads a dictionary file from r; name is what errors call
+// it.
+func scanDictionary(r io.Reader, name string, keepCase bool) (*dictionary, error) {
+	d, _, err := sampleDictionary(r, name, keepCase)
+	return d, err
+}
+
+// dictEntry is a line of a dictionary file, as sampled.
+type dictEntry struct {
+	hash                                uint64
+	word, category, definition, display string
+}
+
+// dictSample is a max-heap on hash of the entries kept so far.
+type dictSample []dictEntry
+
+func (s dictSample) Len() int { return len(s) }
+func (s dictSample) Less(i, j int) bool {
+	if s[i].hash != s[j].hash {
+		return s[i].hash > s[j].hash
+	}
+	return s[i].word > s[j].word
+}
+func (s dictSample) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s *dictSample) Push(x any)   { *s = append(*s, x.(dictEntry)) }
+func (s *dictSample) Pop() any {
+	old := *s
+	e := old[len(old)-1]
+	*s = old[:len(old)-1]
+	return e
+}
+
+func wordHash(w string) uint64 {
+	h := fnv.New64a()
+	io.WriteString(h, w)
+	return h.Sum64()
+}
+
+// sampleDictionary is scanDictionary, also reporting whether the list was
+// too long to keep in full.
+func sampleDictionary(r io.Reader, name string, keepCase bool) (*dictionary, bool, error) {
+	var err error
+	var sample dictSample
+	columns := defaultColumns
+	candidates := 0
+	scanner := bufio.NewScanner(r)
+	for first := true; scanner.Scan(); first = false {
+		line := scanner.Text()
+		if first && strings.HasPrefix(line, dictHeader) {
+			if columns, err = parseColumns(line); err != nil {
+				return nil, false, fmt.Errorf("%s: %w", name, err)
+			}
+			continue
+		}
+		fields := strings.SplitN(line, "\t", len(columns))
+		shown := column(fields, columns, "word")
+		word := shown
+		_, transliterated := columns["typed"]
+		if transliterated {
+			word = column(fields, columns, "typed")
+		}
+		if !keepCase {
+			word = strings.ToLower(word)
+		}
+		if len(word) < minWordLen || len(word) > maxWordLen {
+			continue
+		}
+		candidates++
+		e := dictEntry{
+			hash:       wordHash(word),
+			word:       word,
+			category:   column(fields, columns, "category"),
+			definition: column(fields, columns, "definition"),
+		}
+		if transliterated && shown != "" && len([]rune(shown)) <= maxWordLen {
+			e.display = shown
+		}
+
+		switch {
+		case len(sample) < maxDictWords:
+			sample = append(sample, e)
+			if len(sample) == maxDictWords {
+				heap.Init(&sample)
+			}
+		default:
+			// Keep e if it hashes below the highest kept
+			if top := sample[0]; e.hash < top.hash || e.hash == top.hash && e.word < top.word {
+				sample[0] = e
+				heap.Fix(&sample, 0)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, false, err
+	}
+	words := make([]string, 0, len(sample))
+	categories, definitions, display := map[string]string{}, map[string]string{}, map[string]string{}
+	for _, e := range sample {
+		words = append(words, e.word)
+		if e.category != "" {
+			categories[e.word] = e.category
+		}
+		if e.definition != "" {
+			definitions[e.word] = e.definition
+		}
+		if e.display != "" {
+			display[e.word] = e.display
+		}
+	}
+	d := newDictionary(words)
+	d.categorize(categories)
+	d.define(definitions)
+	d.transliterate(display)
+	return d, candidates > maxDictWords, nil
+}
+
+// dictCachePath is where the sample of the list open in file is cached,
+// named for the list's path, size and modification time so an edited list
+// is sampled again. It is empty if there is nowhere to cache.
+func dictCachePath(file *os.File, path string, keepCase bool) string {
+	info, err := file.Stat()
+	if err != nil {
+		return ""
+	}
+	dir, err := dataDir()
+	if err != nil {
+		return ""
+	}
+	abs, _ := filepath.Abs(path)
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%d\n%d\n%t", abs, info.Size(), info.ModTime().UnixNano(), keepCase)
+	return filepath.Join(dir, "dict-cache", hex.EncodeToString(h.Sum(nil)[:8])+".txt")
+}
+
+// save writes the dictionary to path as a dictionary file, every column
+// included.
+func (d *dictionary) save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s word typed category definition\n", dictHeader)
+	for _, w := range d.words {
+		fmt.Fprintf(&b, "%s\t%s\t%s\t%s\n", d.display[w], w, d.categories[w], d.definitions[w])
+	}
+	// Write then rename so a half-written sample is never read
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(b.String()), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func newDictionary(words []string) *dictionary {
+	sort.Slice(words, func(i, j int) bool {
+		if len(words[i]) != len(words[j]) {
+			return len(words[i]) < len(words[j])
+		}
+		return words[i] < words[j]
+	})
+	// Lowercasing folds entries like "A" and "a" together
+	words = slices.Compact(words)
+
+	d := &dictionary{words: words}
+	for n := range d.start {
+		d.start[n] = sort.Search(len(words), func(i int) bool {
+			return len(words[i]) >= n
+		})
+	}
+	return d
+}
+
+// filter returns a new dictionary of the words keep accepts.
+func (d *dictionary) filter(keep func(string) bool) *dictionary {
+	var words []string
+	for _, w := range d.words {
+		if keep(w) {
+			words = append(words, w)
+		}
+	}
+	f := newDictionary(words)
+	f.categorize(d.categories)
+	f.define(d.definitions)
+	f.transliterate(d.display)
+	return f
+}
+
+// categorize tags the dictionary's words from categories.
+func (d *dictionary) categorize(categories map[string]string) {
+	d.categories = map[string]string{}
+	seen := map[string]bool{}
+	for _, w := range d.words {
+		if c, ok := categories[w]; ok {
+			d.categories[w] = c
+			if !seen[c] {
+				seen[c] = true
+				d.names = append(d.names, c)
+			}
+		}
+	}
+	sort.Strings(d.names)
+}
+
+// category is the index of w's tag in names plus one, or zero for an
+// untagged word.
+func (d *dictionary) category(w string) int {
+	c, ok := d.categories[w]
+	if !ok {
+		return 0
+	}
+	i, _ := slices.BinarySearch(d.names, c)
+	return i + 1
+}
+
+func (d *dictionary) len() int {
+	return len(d.words)
+}
+
+// digest identifies the word list, so a replay can tell whether it has the
+// same dictionary the game was played with.
+func (d *dictionary) digest() string {
+	h := sha256.New()
+	for _, w := range d.words {
+		io.WriteString(h, w+"\n")
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// ofLength returns all words with exactly n letters.
+func (d *dictionary) ofLength(n int) []string {
+	if n < minWordLen || n > maxWordLen {
+		return nil
+	}
+	return d.words[d.start[n]:d.start[n+1]]
+}
+
+// withPrefix returns all words starting with prefix, shortest first.
+func (d *dictionary) withPrefix(prefix string) []string {
+	var matches []string
+	for n := max(len(prefix), minWordLen); n <= maxWordLen; n++ {
+		bucket := d.ofLength(n)
+		i := sort.SearchStrings(bucket, prefix)
+		for ; i < len(bucket) && strings.HasPrefix(bucket[i], prefix); i++ {
+			matches = append(matches, bucket[i])
+		}
+	}
+	return matches
+}
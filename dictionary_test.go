@@ -0,0 +1,60 @@
+package main
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSampleDeterministic(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	rng := rand.New(rand.NewSource(1))
+	seen := map[string]bool{}
+	var lines []string
+	for len(lines) < maxDictWords*3/2 {
+		b := make([]byte, 4+rng.Intn(8))
+		for i := range b {
+			b[i] = byte('a' + rng.Intn(26))
+		}
+		if w := string(b); !seen[w] {
+			seen[w] = true
+			lines = append(lines, w+"\ttag")
+		}
+	}
+	dir := t.TempDir()
+	write := func(name string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	path := write("words")
+	d, err := loadDictionary(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.len() != maxDictWords {
+		t.Fatalf("kept %d words, want %d", d.len(), maxDictWords)
+	}
+	if d.categories[d.words[0]] != "tag" {
+		t.Errorf("lost the category of %q", d.words[0])
+	}
+	cached, err := loadDictionary(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cached.digest() != d.digest() || len(cached.categories) != len(d.categories) {
+		t.Error("the cached sample differs from the first load")
+	}
+	rng.Shuffle(len(lines), func(i, j int) { lines[i], lines[j] = lines[j], lines[i] })
+	shuffled, err := scanDictionary(strings.NewReader(strings.Join(lines, "\n")), "shuffled", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shuffled.digest() != d.digest() {
+		t.Error("the same words in another order sampled differently")
+	}
+}
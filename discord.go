@@ -0,0 +1,268 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Discord Rich Presence shows what the player is doing next to their name
+// in Discord. The running Discord client listens on a local socket
+// (discord-ipc-N) for frames of a little-endian opcode and length followed
+// by a JSON payload.
+
+const (
+	discordHandshake = 0
+	discordFrame     = 1
+	discordClose     = 2
+	// discordEvery keeps well inside Discord's limit of 5 updates per 20s
+	discordEvery = 15 * time.Second
+)
+
+// activity is what the presence shows.
+type activity struct {
+	Details string
+	State   string
+	// Start is when the game began, shown by Discord as elapsed time
+	Start int64
+}
+
+// activity describes the model for Discord.
+func (m model) activity() activity {
+	mode := "Solo"
+	switch {
+	case m.peer != nil:
+		mode = "Versus vs " + m.opponent.name
+	case m.coop:
+		mode = "Co-op"
+	case m.classroom != nil:
+		mode = "Classroom"
+	}
+	a := activity{Details: mode}
+	switch {
+	case m.title:
+		a.Details = "On the title screen"
+	case m.gameOver:
+		a.State = fmt.Sprintf("Game over: %d points, level %d", m.score, m.level)
+	case m.paused:
+		a.State = fmt.Sprintf("Paused at level %d", m.level)
+	default:
+		// Round the score so every word doesn't need an update
+		a.State = fmt.Sprintf("Level %d, %d+ points", m.level, m.score/100*100)
+		a.Start = m.startTime.Unix()
+	}
+	return a
+}
+
+// presence keeps Discord up to date with the latest activity, connecting
+// whenever Discord is running. Updates between sends are coalesced.
+type presence struct {
+	app     string
+	mu      sync.Mutex
+	last    activity
+	pending chan activity
+	done    chan struct{}
+}
+
+// startPresence connects to Discord if the settings ask for it. It
+// returns nil when presence is off.
+func startPresence() *presence {
+	s, err := loadSettings()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Ignoring unreadable settings: %v\n", err)
+		return nil
+	}
+	if !s.Discord {
+		return nil
+	}
+	if s.DiscordApp == "" {
+		fmt.Fprintf(os.Stderr, "Discord presence needs an application ID: %s config set discord-app ID\n", progName())
+		return nil
+	}
+	return newPresence(s.DiscordApp)
+}
+
+func newPresence(app string) *presence {
+	d := &presence{app: app, pending: make(chan activity, 1), done: make(chan struct{})}
+	go d.run()
+	return d
+}
+
+// set offers the current activity; it never blocks the game.
+func (d *presence) set(a activity) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if a == d.last {
+		return
+	}
+	d.last = a
+	select {
+	case <-d.pending:
+	default:
+	}
+	d.pending <- a
+}
+
+// close clears the presence and disconnects.
+func (d *presence) close() {
+	if d != nil {
+		close(d.done)
+	}
+}
+
+func (d *presence) run() {
+	var conn io.ReadWriteCloser
+	defer func() {
+		if conn != nil {
+			conn.Close()
+		}
+	}()
+	var next time.Time
+	for {
+		var a activity
+		select {
+		case <-d.done:
+			return
+		case a = <-d.pending:
+		}
+		select {
+		case <-d.done:
+			return
+		case <-time.After(time.Until(next)):
+		}
+		// Anything newer that arrived while waiting wins
+		select {
+		case a = <-d.pending:
+		default:
+		}
+		next = time.Now().Add(discordEvery)
+
+		if conn == nil {
+			var err error
+			if conn, err = dialDiscord(d.app); err != nil {
+				logger.Debug("discord", "err", err)
+				continue
+			}
+		}
+		if err := setActivity(conn, a); err != nil {
+			logger.Debug("discord", "err", err)
+			conn.Close()
+			conn = nil
+		}
+	}
+}
+
+// discordPaths lists where the Discord client may be listening.
+func discordPaths() []string {
+	var paths []string
+	for i := range 10 {
+		name := "discord-ipc-" + strconv.Itoa(i)
+		if runtime.GOOS == "windows" {
+			paths = append(paths, `\\.\pipe\`+name)
+			continue
+		}
+		for _, env := range []string{"XDG_RUNTIME_DIR", "TMPDIR", "TMP", "TEMP"} {
+			if dir := os.Getenv(env); dir != "" {
+				paths = append(paths, filepath.Join(dir, name))
+			}
+		}
+		paths = append(paths, filepath.Join("/tmp", name))
+	}
+	return paths
+}
+
+// dialDiscord connects to the Discord client and completes the handshake.
+func dialDiscord(app string) (io.ReadWriteCloser, error) {
+	for _, path := range discordPaths() {
+		var conn io.ReadWriteCloser
+		var err error
+		if runtime.GOOS == "windows" {
+			conn, err = os.OpenFile(path, os.O_RDWR, 0)
+		} else {
+			conn, err = net.DialTimeout("unix", path, time.Second)
+		}
+		if err != nil {
+			continue
+		}
+		if err := writeDiscord(conn, discordHandshake, map[string]any{"v": 1, "client_id": app}); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		op, reply, err := readDiscord(conn)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		if op == discordClose {
+			conn.Close()
+			return nil, fmt.Errorf("discord refused the connection: %s", reply)
+		}
+		logger.Info("discord connected", "path", path)
+		return conn, nil
+	}
+	return nil, errors.New("discord isn't running")
+}
+
+func setActivity(conn io.ReadWriter, a activity) error {
+	act := map[string]any{"details": a.Details}
+	if a.State != "" {
+		act["state"] = a.State
+	}
+	if a.Start != 0 {
+		act["timestamps"] = map[string]int64{"start": a.Start}
+	}
+	err := writeDiscord(conn, discordFrame, map[string]any{
+		"cmd":   "SET_ACTIVITY",
+		"args":  map[string]any{"pid": os.Getpid(), "activity": act},
+		"nonce": strconv.FormatInt(time.Now().UnixNano(), 36),
+	})
+	if err != nil {
+		return err
+	}
+	op, reply, err := readDiscord(conn)
+	if err != nil {
+		return err
+	}
+	if op == discordClose {
+		return fmt.Errorf("discord closed the connection: %s", reply)
+	}
+	return nil
+}
+
+func writeDiscord(w io.Writer, op uint32, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	frame := make([]byte, 8, 8+len(data))
+	binary.LittleEndian.PutUint32(frame, op)
+	binary.LittleEndian.PutUint32(frame[4:], uint32(len(data)))
+	_, err = w.Write(append(frame, data...))
+	return err
+}
+
+func readDiscord(r io.Reader) (uint32, []byte, error) {
+	var header [8]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, nil, err
+	}
+	n := binary.LittleEndian.Uint32(header[4:])
+	if n > 1<<20 {
+		return 0, nil, fmt.Errorf("discord frame too large: %d bytes", n)
+	}
+	data := make([]byte, n)
+	_, err := io.ReadFull(r, data)
+	return binary.LittleEndian.Uint32(header[:]), data, err
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"math/rand"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// After a solo game the game over screen offers a practice drill, 'd',
+// built from the game's weak spots: the words that got past, the slowest
+// kills for their length, and dictionary words heavy in the letters most
+// often mistyped. The drill is a short game over those words alone, won
+// by typing drillSize of them, and is recorded in the history as a
+// "drill" that doesn't rank or go on the boards.
+
+const (
+	// drillSize is how many words a drill deals and must be typed to win
+	drillSize = 20
+	// drillSlow is how many of the slowest kills go in
+	drillSlow = 5
+	// drillLetters is how many of the most mistyped letters words are
+	// picked for
+	drillLetters = 3
+)
+
+// drillWords is the drill the finished game's analysis makes, or nil when
+// there is nothing to practice.
+func (m model) drillWords() []string {
+	var words []string
+	seen := map[string]bool{}
+	add := func(w string) {
+		if !seen[w] && len(w) >= minWordLen && len(w) <= maxWordLen {
+			seen[w] = true
+			words = append(words, w)
+		}
+	}
+	for _, w := range m.tally.Missed {
+		add(w)
+	}
+	// Slowest for their length, so long words don't crowd out the rest
+	killed := make([]string, 0, len(m.tally.KillTimes))
+	for w := range m.tally.KillTimes {
+		killed = append(killed, w)
+	}
+	perLetter := func(w string) int64 { return m.tally.KillTimes[w] / int64(len(w)) }
+	sort.Slice(killed, func(i, j int) bool {
+		if perLetter(killed[i]) != perLetter(killed[j]) {
+			return perLetter(killed[i]) > perLetter(killed[j])
+		}
+		return killed[i] < killed[j]
+	})
+	for _, w := range killed[:min(len(killed), drillSlow)] {
+		add(w)
+	}
+	if letters := m.weakLetters(); letters != "" && len(words) < drillSize {
+		// Dictionary words using the most of them, in a random order on a tie
+		candidates := slices.Clone(m.dict.words)
+		count := func(w string) int {
+			n := 0
+			for _, r := range w {
+				if strings.ContainsRune(letters, r) {
+					n++
+				}
+			}
+			return n
+		}
+		rng := rand.New(rand.NewSource(m.seed))
+		rng.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
+		sort.SliceStable(candidates, func(i, j int) bool { return count(candidates[i]) > count(candidates[j]) })
+		for _, w := range candidates {
+			if len(words) >= drillSize || count(w) == 0 {
+				break
+			}
+			add(w)
+		}
+	}
+	return words[:min(len(words), drillSize)]
+}
+
+// weakLetters is up to drillLetters letters the game mistyped most.
+func (m model) weakLetters() string {
+	var letters []byte
+	for i, ls := range m.tally.Letters {
+		if ls.Misses > 0 {
+			letters = append(letters, byte('a'+i))
+		}
+	}
+	sort.SliceStable(letters, func(i, j int) bool {
+		return m.tally.Letters[letters[i]-'a'].Misses > m.tally.Letters[letters[j]-'a'].Misses
+	})
+	return string(letters[:min(len(letters), drillLetters)])
+}
+
+// drillable reports whether the game over screen offers a drill. Hosted
+// players have no local session to start it in.
+func (m model) drillable() bool {
+	t := m.tally
+	return m.profile == "" && m.peer == nil && m.classroom == nil && !m.coop && (len(t.Missed) > 0 || len(t.KillTimes) > 0 || m.weakLetters() != "")
+}
+
+// drillModel starts a drill over words, keeping prev's display settings.
+func drillModel(prev model, words []string) model {
+	m := initialModel(newDictionary(slices.Clone(words)))
+	m.dict.define(prev.dict.definitions)
+	m.dict.transliterate(prev.dict.display)
+	m.drilling = true
+	m.shift = prev.shift
+	m.slowMotion = prev.slowMotion
+	if prev.oneHand {
+		m.pace = prev.pace
+	}
+	m.profile = prev.profile
+	m.season, m.seasonal = prev.season, prev.seasonal
+	m.minimal, m.screenshotANSI, m.graveyard = prev.minimal, prev.screenshotANSI, prev.graveyard
+	m.styles, m.renderer, m.frameRate = prev.styles, prev.renderer, prev.frameRate
+	m.effectsLevel, m.symbols, m.hud = prev.effectsLevel, prev.symbols, prev.hud
+	m.width, m.height = prev.width, prev.height
+	// Deal every length from the start and finish once they're practised
+	m.lengths = wordLengths{Start: maxWordLen, Spread: maxWordLen}
+	m.goal = goal{kind: "words", target: drillSize}
+	m.title = false
+	m.startTime = time.Now()
+	gameStarted()
+	return m
+}
+
+// playDrill runs the drill prev asked for, and any drill it asks for in
+// turn.
+func playDrill(prev model) error {
+	for words := prev.drill; words != nil; {
+		logger.Info("drill start", "words", len(words))
+		m := drillModel(prev, words)
+		final, err := tea.NewProgram(m, tea.WithAltScreen(), tea.WithReportFocus(), withFPS(m.frameRate)).Run()
+		if err != nil {
+			return err
+		}
+		prev = final.(model)
+		if _, err := prev.finish(); err != nil {
+			return err
+		}
+		words = prev.drill
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// Game events are published on a bus for external tools to follow: the
+// control socket streams them to subscribers and the overlay feed serves
+// them to stream overlays. Slow subscribers miss events rather than
+// holding the game up.
+
+// gameEvent is one thing that happened in a game. Type is one of
+//
+//	spawn        a word appeared (Word)
+//	key          a letter was typed (Key, Hit)
+//	kill         a word was destroyed (Word, Points, Combo)
+//	combo        a kill made a combo of two or more in a row (Combo)
+//	level_up     the level went up (Level)
+//	life_lost    a word reached the bottom (Word, Lives)
+//	life_gained  an extra life was earned (Lives)
+//	game_over    the game ended
+//
+// and Score, Level and Lives are always the state after it.
+type gameEvent struct {
+	Type   string    `json:"type"`
+	Time   time.Time `json:"time"`
+	Word   string    `json:"word,omitempty"`
+	Key    string    `json:"key,omitempty"`
+	Hit    bool      `json:"hit,omitempty"`
+	Points int       `json:"points,omitempty"`
+	Combo  int       `json:"combo,omitempty"`
+	Score  int       `json:"score"`
+	Level  int       `json:"level"`
+	Lives  int       `json:"lives"`
+}
+
+// eventBus fans events out to subscribers. A nil bus drops them, so games
+// nobody follows pay nothing.
+type eventBus struct {
+	mu   sync.Mutex
+	subs map[chan gameEvent]struct{}
+}
+
+func newEventBus() *eventBus {
+	return &eventBus{subs: make(map[chan gameEvent]struct{})}
+}
+
+// subscriberBuffer is how many events a subscriber can fall behind by
+// before it starts missing them.
+const subscriberBuffer = 64
+
+// subscribe returns a channel of events and a function that ends the
+// subscription.
+func (b *eventBus) subscribe() (<-chan gameEvent, func()) {
+	ch := make(chan gameEvent, subscriberBuffer)
+	b.mu.Lock()
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subs[ch]; ok {
+			delete(b.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+func (b *eventBus) publish(e gameEvent) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// emit publishes an event of type typ with the game's current state; fill
+// sets the fields particular to the event.
+func (m model) emit(typ string, fill func(e *gameEvent)) {
+	if m.events == nil {
+		return
+	}
+	e := gameEvent{Type: typ, Time: time.Now(), Score: m.score, Level: m.level, Lives: m.lives}
+	if fill != nil {
+		fill(&e)
+	}
+	m.events.publish(e)
+}
@@ -0,0 +1,53 @@
+package main
+
+import "fmt"
+
+// Arcade style, every extraLife.Every points earn a bonus life, up to
+// extraLife.Max lives at once. Both are part of the difficulty profile;
+// Every set to 0 turns bonus lives off.
+type extraLife struct {
+	Every int `json:"every"`
+	Max   int `json:"max"`
+}
+
+var defaultExtraLife = extraLife{Every: 10000, Max: 5}
+
+// awardLives grants the lives earned by scoring points on top of before.
+func (m model) awardLives(before int) model {
+	if m.extraLife.Every <= 0 {
+		return m
+	}
+	earned := m.score/m.extraLife.Every - before/m.extraLife.Every
+	for range earned {
+		if m.lives >= m.extraLife.Max {
+			break
+		}
+		m.lives++
+		m = m.lifeChanged(true)
+		logger.Info("extra life", "lives", m.lives, "score", m.score)
+		m.emit("life_gained", nil)
+		m = m.note("life_gained", nil)
+		m = m.celebrate()
+	}
+	return m
+}
+
+// celebrate puts on a show for a bonus life in the middle of the
+// playfield. The minimal screen has no show to put on.
+func (m model) celebrate() model {
+	if m.minimal {
+		return m
+	}
+	x, y := m.fieldWidth()/2, gameHeight/2
+	burst := createExplosion(x, y, 8, m.sparks())
+	for i := range burst.particles {
+		burst.particles[i].char = '♥'
+		burst.particles[i].color = sparkHeart
+		burst.particles[i].lifetime += 2 * tickRate
+	}
+	m = m.addEffect(burst)
+	text := fmt.Sprintf("EXTRA LIFE! %d left", m.lives)
+	left := max(0, min(x-len(text)/2, m.fieldWidth()-len(text)))
+	m.popups = append(m.popups, popup{text: text, x: left, y: y, life: popupLife + 1})
+	return m
+}
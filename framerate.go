@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The game moves in steps of tickRate: words fall a row, spawn and level
+// up, and replays count the same steps. Frames are separate and come every
+// frameRate, -tick-ms on the command line. A frame moves the particles by
+// the time since the last one and redraws, and every tickRate of that time
+// the game steps. Fast terminals can animate at 30fps and slow SSH links can
+// drop to a few frames a second, with the words falling just as fast.
+
+const (
+	defaultTickMs = 100
+	minTickMs     = 10
+)
+
+// frameRate checks a -tick-ms value.
+func frameRate(ms int) (time.Duration, error) {
+	if ms < minTickMs || ms > int(tickRate/time.Millisecond) {
+		return 0, fmt.Errorf("-tick-ms must be between %d and %d", minTickMs, tickRate/time.Millisecond)
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// withFPS caps Bubble Tea's redraws at the frame rate, so slow links aren't
+// sent keystroke echoes faster than the frames they sit in.
+func withFPS(rate time.Duration) tea.ProgramOption {
+	return tea.WithFPS(max(1, int(time.Second/rate)))
+}
+
+// maxCatchUp is how many steps a late frame may make up at once, and
+// maxFrameGap the longest time between frames that counts as play. Frames
+// later than that come after the process was suspended or the machine
+// slept, and only move the game on by a frame.
+const (
+	maxCatchUp  = 3
+	maxFrameGap = 5 * time.Second
+)
+
+// advance runs the frame at now, moving everything by the time since the
+// previous frame rather than by a frame's worth, so late or dropped ticks
+// don't slow the words down. It reports whether the game stepped.
+func (m model) advance(now time.Time) (model, bool) {
+	dt := m.frameRate
+	if !m.lastFrame.IsZero() {
+		dt = max(0, now.Sub(m.lastFrame))
+		if dt > maxFrameGap {
+			dt = m.frameRate
+		}
+	}
+	m.lastFrame = now
+	dt = m.scaled(dt)
+	m = m.updateEffects(dt)
+	m.sinceStep += dt
+	stepped := false
+	for n := 0; m.sinceStep >= tickRate && !m.gameOver; n++ {
+		if n == maxCatchUp {
+			// Too far behind to catch up fairly; let the rest go
+			m.sinceStep = 0
+			break
+		}
+		m.sinceStep -= tickRate
+		m, stepped = m.step(), true
+	}
+	return m, stepped
+}
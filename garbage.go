@@ -0,0 +1,66 @@
+package main
+
+// In versus, strong play sends "garbage" words onto the opponent's screen.
+// Both sides choose garbage from their own dictionary; only the count
+// and when it was sent travel over the wire, see latency.go.
+const (
+	// garbageMinLen is the word length that earns one garbage word
+	garbageMinLen = 7
+	// garbageComboStep earns one garbage word every this many kills in a row
+	garbageComboStep = 5
+	// maxGarbageOnScreen caps live garbage so a burst arrives over several
+	// ticks instead of burying the player at once
+	maxGarbageOnScreen = 3
+)
+
+// garbageEarned is how many garbage words killing text sends, given the
+// combo already includes this kill.
+func (m model) garbageEarned(text string) int {
+	n := 0
+	if len(text) >= garbageMinLen {
+		n++
+	}
+	if m.combo%garbageComboStep == 0 {
+		n++
+	}
+	return n
+}
+
+// flushGarbage sends earned garbage to the opponent. Garbage still queued
+// against us is cancelled first, Tetris-style, so trading attacks favors
+// whoever is ahead rather than whoever sent last.
+func (m model) flushGarbage() (model, int) {
+	n := m.outgoing
+	m.outgoing = 0
+	cancel := min(n, len(m.pendingGarbage))
+	m.pendingGarbage = m.pendingGarbage[cancel:]
+	return m, n - cancel
+}
+
+// spawnGarbage drops one queued garbage word if it has landed and there
+// is room for it.
+func (m model) spawnGarbage() model {
+	if len(m.pendingGarbage) == 0 || m.lastFrame.Before(m.pendingGarbage[0]) {
+		return m
+	}
+	live := 0
+	for _, w := range m.words {
+		if w.garbage {
+			live++
+		}
+	}
+	if live >= maxGarbageOnScreen {
+		return m
+	}
+
+	m, text, ok := m.deal(0)
+	if !ok {
+		// Nothing fits yet; it lands once something does
+		return m
+	}
+	maxX := max(m.fieldWidth()-len(text)-1, 0)
+	m.words = append(m.words, word{text: text, x: m.rng.Intn(maxX + 1), garbage: true, spawned: m.gameTime()})
+	m.pendingGarbage = m.pendingGarbage[1:]
+	logger.Debug("garbage spawn", "word", text, "pending", len(m.pendingGarbage))
+	return m
+}
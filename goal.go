@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A goal gives a run a finish line: -goal score=5000 or -goal words=100
+// ends the game with a victory once it is reached, and the history records
+// how long it took.
+
+var goalKinds = []string{"score", "words"}
+
+type goal struct {
+	kind   string
+	target int
+}
+
+func (g goal) String() string {
+	if g.kind == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s=%d", g.kind, g.target)
+}
+
+// Set parses a -goal flag.
+func (g *goal) Set(s string) error {
+	kind, n, ok := strings.Cut(s, "=")
+	if !ok {
+		return errors.New("expected KIND=N, e.g. score=5000 or words=100")
+	}
+	target, err := strconv.Atoi(n)
+	if err != nil || target <= 0 {
+		return fmt.Errorf("goal target must be a positive number, not %q", n)
+	}
+	for _, k := range goalKinds {
+		if kind == k {
+			g.kind, g.target = kind, target
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown goal %q (want %s)", kind, strings.Join(goalKinds, " or "))
+}
+
+// goalProgress is how far the game is toward its goal, counting both players
+// in co-op.
+func (m model) goalProgress() int {
+	switch m.goal.kind {
+	case "score":
+		return m.score + m.partner.score
+	case "words":
+		return m.wordsTyped + m.partner.words
+	}
+	return 0
+}
+
+// checkGoal ends the game with a victory once the goal is reached.
+func (m model) checkGoal() model {
+	if m.goal.kind == "" || m.gameOver || m.goalProgress() < m.goal.target {
+		return m
+	}
+	m.victory = true
+	logger.Info("goal reached", "goal", m.goal.String(), "time", m.elapsed())
+	return m.endGame()
+}
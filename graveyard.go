@@ -0,0 +1,58 @@
+package main
+
+import "fmt"
+
+// 'config set graveyard on' keeps a column beside the playfield of the
+// last few words to leave it, newest first: a tick for each one typed and
+// a cross for each that got past. It's a glance at what you just typed or
+// missed, handy when learning a word list. Matches and speedruns keep
+// their own panels there instead.
+
+// graveyardSize is how many words the graveyard lists.
+const graveyardSize = 10
+
+// fallen is a word that left the playfield.
+type fallen struct {
+	text   string
+	missed bool
+}
+
+// bury adds the word text to the graveyard.
+func (m model) bury(text string, missed bool) model {
+	if !m.graveyard {
+		return m
+	}
+	m.fallen = append([]fallen{{text, missed}}, m.fallen[:min(len(m.fallen), graveyardSize-1)]...)
+	return m
+}
+
+// renderGraveyard is the graveyard panel, or nil when it's off.
+func (m model) renderGraveyard() []string {
+	if !m.graveyard || m.minimal {
+		return nil
+	}
+	lines := []string{m.styles.title.Render("GRAVEYARD"), ""}
+	for _, f := range m.fallen {
+		text := string(m.shown(f.text))
+		if text != f.text {
+			text += " (" + f.text + ")"
+		}
+		if f.missed {
+			lines = append(lines, m.styles.garbage.Render("✗ "+text))
+		} else {
+			lines = append(lines, m.styles.stats.Render("✓ "+text))
+		}
+	}
+	if len(m.fallen) == 0 {
+		lines = append(lines, m.styles.help.Render("No words yet"))
+	}
+	return lines
+}
+
+// graveyardStatus is config's line about the graveyard.
+func graveyardStatus(on bool) string {
+	if on {
+		return fmt.Sprintf("on, the last %d words", graveyardSize)
+	}
+	return "off"
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A handicap evens out a match between players of different skill. It
+// applies to its player's own words: Speed scales how fast they fall and
+// spawn, Lives are added to the start, and Shorter takes letters off the
+// top of the band of lengths. In versus each side sets its own, with
+// -handicap or in the lobby, and the opponent's shows on the side panel.
+// In co-op -handicap and -handicap2 set player one's and two's; Speed
+// can't apply there, as both players share the playfield.
+type handicap struct {
+	Speed   float64 `json:"speed,omitempty"`
+	Lives   int     `json:"lives,omitempty"`
+	Shorter int     `json:"shorter,omitempty"`
+}
+
+// handicapPresets are the handicaps the lobby steps through, lightest
+// first.
+var handicapPresets = []handicap{
+	{},
+	{Speed: 0.85, Lives: 1},
+	{Speed: 0.7, Lives: 2, Shorter: 1},
+	{Speed: 0.55, Lives: 3, Shorter: 2},
+}
+
+// speed is the multiplier on the pace, 1 when unset.
+func (h handicap) speed() float64 {
+	if h.Speed == 0 {
+		return 1
+	}
+	return h.Speed
+}
+
+func (h handicap) String() string {
+	var parts []string
+	if h.Speed != 0 {
+		parts = append(parts, fmt.Sprintf("speed=%g", h.Speed))
+	}
+	if h.Lives != 0 {
+		parts = append(parts, fmt.Sprintf("lives=%d", h.Lives))
+	}
+	if h.Shorter != 0 {
+		parts = append(parts, fmt.Sprintf("shorter=%d", h.Shorter))
+	}
+	return strings.Join(parts, ",")
+}
+
+// describe is the handicap as the lobby and side panel show it.
+func (h handicap) describe() string {
+	if h == (handicap{}) {
+		return "none"
+	}
+	var parts []string
+	if h.Speed != 0 {
+		parts = append(parts, fmt.Sprintf("x%g speed", h.Speed))
+	}
+	if h.Lives != 0 {
+		lives := "lives"
+		if h.Lives == 1 {
+			lives = "life"
+		}
+		parts = append(parts, fmt.Sprintf("+%d %s", h.Lives, lives))
+	}
+	if h.Shorter != 0 {
+		parts = append(parts, fmt.Sprintf("-%d %s", h.Shorter, plural(h.Shorter, "letter")))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Set parses a -handicap flag: speed=0.8,lives=2,shorter=1, in any
+// combination.
+func (h *handicap) Set(s string) error {
+	*h = handicap{}
+	for _, part := range strings.Split(s, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "speed":
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil || f < 0.25 || f > 1 {
+				return fmt.Errorf("handicap speed is from 0.25 to 1, not %q", value)
+			}
+			h.Speed = f
+		case "lives":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 || n > 9 {
+				return fmt.Errorf("handicap lives are from 0 to 9, not %q", value)
+			}
+			h.Lives = n
+		case "shorter":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 || n > maxWordLen-minWordLen {
+				return fmt.Errorf("handicap shorter is from 0 to %d letters, not %q", maxWordLen-minWordLen, value)
+			}
+			h.Shorter = n
+		default:
+			return fmt.Errorf("unknown handicap %q (want speed, lives or shorter)", key)
+		}
+	}
+	return nil
+}
+
+// next is the preset after h, wrapping back to none.
+func (h handicap) next() handicap {
+	for i, p := range handicapPresets {
+		if p == h {
+			return handicapPresets[(i+1)%len(handicapPresets)]
+		}
+	}
+	return handicapPresets[0]
+}
+
+// withHandicaps gives each seat its handicap's extra lives.
+func (m model) withHandicaps(h [2]handicap) model {
+	m.handicaps = h
+	m.lives += h[0].Lives
+	if m.coop {
+		m.partner.lives += h[1].Lives
+	}
+	return m
+}
+
+// rated reports whether the game is a networked match that moves the
+// player's rating; handicapped matches don't, nor lobby matches the lobby
+// doesn't rate.
+func (m model) rated() bool {
+	return m.peer != nil && (!m.ladder || m.ladderRated) && m.handicaps[0] == (handicap{}) && m.opponent.handicap == (handicap{})
+}
+
+// paceRate is the multiplier on the pace from adaptive difficulty and the
+// player's handicap.
+func (m model) paceRate() float64 {
+	rate := m.flowRate()
+	if !m.coop {
+		rate *= m.handicaps[0].speed()
+	}
+	return rate
+}
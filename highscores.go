@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// A game that makes the local top ten asks for three initials at game over,
+// arcade style, before it's written to the history. Hosted players already
+// have a name, so their profile name is used instead.
+
+const (
+	highScoreCount = 10
+	initialsLen    = 3
+)
+
+// highScores returns the best ranked games in sessions, best first.
+// Versus matches, drills and recovered games aren't ranked.
+func highScores(sessions []session) []session {
+	var ranked []session
+	for _, s := range sessions {
+		if s.Mode != "versus" && s.Mode != "drill" && !s.Recovered && s.Score > 0 {
+			ranked = append(ranked, s)
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Score > ranked[j].Score })
+	return ranked[:min(len(ranked), highScoreCount)]
+}
+
+// loadHighScores returns the profile's top ten.
+func loadHighScores(profile string) ([]session, error) {
+	sessions, err := loadHistory(profile)
+	return highScores(sessions), err
+}
+
+// highScoreRank is the place the game takes in the top ten, from 1, or
+// zero if it doesn't make it.
+func (m model) highScoreRank() int {
+	if m.peer != nil || m.resumed || m.drilling {
+		return 0
+	}
+	score := m.score + m.partner.score
+	if score <= 0 {
+		return 0
+	}
+	for i, s := range m.highScores {
+		if score > s.Score {
+			return i + 1
+		}
+	}
+	if len(m.highScores) < highScoreCount {
+		return len(m.highScores) + 1
+	}
+	return 0
+}
+
+// askInitials starts initials entry if the finished game made the top ten.
+func (m model) askInitials() model {
+	if m.highScoreRank() == 0 {
+		return m
+	}
+	if m.player != "" {
+		m.initials = m.player
+		return m
+	}
+	m.enteringInitials = true
+	return m
+}
+
+// typeInitial handles a key on the initials screen.
+func (m model) typeInitial(msg tea.KeyMsg) model {
+	switch msg.Type {
+	case tea.KeyBackspace:
+		if len(m.initials) > 0 {
+			m.initials = m.initials[:len(m.initials)-1]
+		}
+	case tea.KeyEnter:
+		if len(m.initials) > 0 {
+			m.enteringInitials = false
+			logger.Info("high score", "initials", m.initials, "rank", m.highScoreRank())
+		}
+	case tea.KeyRunes:
+		for _, r := range strings.ToUpper(string(msg.Runes)) {
+			if r >= 'A' && r <= 'Z' && len(m.initials) < initialsLen {
+				m.initials += string(r)
+			}
+		}
+	}
+	return m
+}
+
+func (m model) renderInitials() string {
+	var b strings.Builder
+	b.WriteString("\n\n")
+	b.WriteString(m.styles.title.Render("NEW HIGH SCORE"))
+	b.WriteString("  " + m.styles.pause.Render(fmt.Sprintf("#%d", m.highScoreRank())))
+	b.WriteString("\n\n" + m.styles.stats.Render(fmt.Sprintf("Score: %d", m.score+m.partner.score)))
+	b.WriteString("\n\n" + m.styles.stats.Render("Enter your initials: "))
+	for i := range initialsLen {
+		letter := "_"
+		if i < len(m.initials) {
+			letter = m.initials[i : i+1]
+		}
+		b.WriteString(m.styles.highlight.Render(letter) + " ")
+	}
+	b.WriteString("\n\n")
+	for i, s := range m.highScores {
+		b.WriteString(m.styles.help.Render(fmt.Sprintf("%2d. %-3s %7d  level %2d", i+1, s.Initials, s.Score, s.Level)) + "\n")
+	}
+	b.WriteString("\n" + m.styles.help.Render("Type up to 3 letters and press enter"))
+	return b.String()
+}
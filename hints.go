@@ -0,0 +1,55 @@
+package main
+
+import "fmt"
+
+// Beginners can freeze when the screen fills up. '?' asks for a hint: the
+// next letter to type on the lowest word lights up, and keeps lighting the
+// next one until the word is gone. Each hint costs hintCost points, and a
+// hinted game can't go on the boards.
+
+const hintCost = 50
+
+// hintable reports whether hints can be asked for: not in co-op, where
+// '?' has no seat, nor against other players.
+func (m model) hintable() bool {
+	return m.running() && !m.coop && m.peer == nil && m.classroom == nil
+}
+
+// hint marks the lowest word, at a cost, unless it's already marked.
+func (m model) hint() model {
+	lowest := -1
+	for i, w := range m.words {
+		if lowest < 0 || w.y > m.words[lowest].y {
+			lowest = i
+		}
+	}
+	if lowest < 0 || m.words[lowest].hinted {
+		return m
+	}
+	m.words[lowest].hinted = true
+	cost := min(m.score, hintCost)
+	m.hints++
+	m.hintPoints += cost
+	m.score -= cost
+	logger.Info("hint", "word", m.words[lowest].text, "hints", m.hints)
+	m.emit("hint", func(e *gameEvent) { e.Word = m.words[lowest].text })
+	m = m.note("hint", func(e *moment) { e.Word = m.words[lowest].text })
+	return m
+}
+
+// markHint lights the next letter to type on a hinted word drawn at its
+// place in the frame.
+func (m model) markHint(w word) {
+	typed := m.typedOn(w)
+	if !w.hinted || typed >= len(w.text) || w.y < 0 || w.y >= gameHeight {
+		return
+	}
+	if x := w.x + shownAt(typed, m.shownWidth(w.text), len(w.text)); x < screenWidth {
+		m.frame.kinds[w.y][x] = cellHint
+	}
+}
+
+// hintStatus is the game over screen's hint line.
+func (m model) hintStatus() string {
+	return fmt.Sprintf("Hints: %d, costing %d points", m.hints, m.hintPoints)
+}
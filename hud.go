@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// The status line is a row of widgets, each rendering one piece of the
+// HUD or nothing when it has nothing to show. Which widgets appear, and in
+// what order, is a setting: config set hud score,level,lives,wpm
+
+var hudWidgets = map[string]func(m model) string{
+	"score": func(m model) string { return m.styles.status.Render(fmt.Sprintf("Score: %d", m.score)) },
+	"level": func(m model) string { return m.styles.status.Render(fmt.Sprintf("Level: %d", m.level)) },
+	"next":  func(m model) string { return m.renderNextLevel() },
+	"lives": func(m model) string { return m.renderLives() },
+	"words": func(m model) string { return m.styles.status.Render(fmt.Sprintf("Words: %d", m.wordsTyped)) },
+	"wpm": func(m model) string {
+		return m.styles.status.Render(fmt.Sprintf("WPM: %d", wpm(m.wordsTyped, m.elapsed())))
+	},
+	"cpm": func(m model) string {
+		return m.styles.status.Render(fmt.Sprintf("CPM: %d", cpm(m.tally.Keystrokes, m.elapsed())))
+	},
+	"accuracy": func(m model) string {
+		return m.styles.status.Render(fmt.Sprintf("Acc: %.1f%%", m.tally.accuracy()))
+	},
+	"combo": func(m model) string {
+		if m.combo < 2 {
+			return ""
+		}
+		return m.styles.popup.Render(fmt.Sprintf("Combo x%d", m.combo))
+	},
+	"timer": func(m model) string {
+		d := m.elapsed()
+		return m.styles.status.Render(fmt.Sprintf("Time: %d:%02d", int(d.Minutes()), int(d.Seconds())%60))
+	},
+	"team": func(m model) string { return m.renderTeamTag() },
+	"beat": func(m model) string { return m.renderPulse() },
+	"goal": func(m model) string {
+		if m.goal.kind == "" {
+			return ""
+		}
+		return m.styles.status.Render(fmt.Sprintf("Goal: %d/%d", m.goalProgress(), m.goal.target))
+	},
+}
+
+// defaultHUD leaves room in 80 columns for the input box beside it.
+var defaultHUD = []string{"beat", "team", "score", "level", "next", "lives", "wpm", "goal"}
+
+// parseHUD reads a comma separated list of widget names.
+func parseHUD(list string) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if _, ok := hudWidgets[name]; !ok {
+			known := make([]string, 0, len(hudWidgets))
+			for k := range hudWidgets {
+				known = append(known, k)
+			}
+			sort.Strings(known)
+			return nil, fmt.Errorf("unknown HUD widget %q (have %s)", name, strings.Join(known, ", "))
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func (m model) renderStatus() string {
+	var parts []string
+	for _, name := range m.hud {
+		if render := hudWidgets[name]; render != nil {
+			if s := render(m); s != "" {
+				parts = append(parts, s)
+			}
+		}
+	}
+	if m.rtl {
+		// The first widget goes on the right
+		slices.Reverse(parts)
+	}
+	return strings.Join(parts, "  ")
+}
+
+// renderLegend names the colors of categorized and chat words, or is
+// empty when every word is plain.
+func (m model) renderLegend() string {
+	var parts []string
+	for i, name := range m.dict.names {
+		parts = append(parts, m.styles.categories[i%len(categoryColors)].Render("■ "+name))
+	}
+	if m.fed || len(m.thrown) > 0 {
+		parts = append(parts, m.styles.chat.Render("■ chat"))
+	}
+	return strings.Join(parts, "  ")
+}
+
+const (
+	lifeIcon     = "♥"
+	lostLifeIcon = "♡"
+	// maxLifeIcons is the most hearts drawn before switching to a count
+	maxLifeIcons = 8
+	// lifeFlashTicks is how long a lost or gained life blinks
+	lifeFlashTicks = 4
+)
+
+// lifeChanged starts the lives widget blinking after a life is lost or
+// gained.
+func (m model) lifeChanged(gained bool) model {
+	m.lifeFlash = lifeFlashTicks
+	m.lifeGained = gained
+	return m
+}
+
+// renderLives draws a heart per life. A lost life blinks as an empty heart
+// and a gained one as a bright heart for a few ticks.
+func (m model) renderLives() string {
+	if m.lives > maxLifeIcons {
+		return m.styles.garbage.Render(lifeIcon) + m.styles.status.Render(fmt.Sprintf("×%d", m.lives))
+	}
+	blink := m.lifeFlash > 0 && m.lifeFlash%2 == 0
+	hearts := max(0, m.lives)
+	switch {
+	case blink && m.lifeGained && hearts > 0:
+		return m.styles.garbage.Render(strings.Repeat(lifeIcon, hearts-1)) + m.styles.popup.Render(lifeIcon)
+	case blink && !m.lifeGained:
+		return m.styles.garbage.Render(strings.Repeat(lifeIcon, hearts)) + m.styles.help.Render(lostLifeIcon)
+	}
+	return m.styles.garbage.Render(strings.Repeat(lifeIcon, hearts))
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The input box sits beside the status line with the locked target word
+// over what has been typed so far. A typo turns it red until the next tick
+// and leaves the rejected letters showing, so it's clear what went wrong.
+
+const (
+	inputCursor = "█"
+	// inputBoxWidth fits the longest word and the cursor
+	inputBoxWidth = maxWordLen + 3
+)
+
+// typoMissed remembers the input a typo threw away, for the input box.
+func (m model) typoMissed() model {
+	m.typoFlash = 1
+	m.rejected = m.input
+	return m
+}
+
+func (m model) renderInputBox() string {
+	target := m.styles.help.Render("-")
+	if w := m.current; w != nil {
+		target = m.styles.highlight.Render(w.text[:w.matched]) + m.styles.cell(w.kind(w.matched, false)).Render(w.text[w.matched:])
+		if m.requireEnter && w.matched == len(w.text) {
+			target += m.styles.help.Render(" ⏎")
+		}
+	}
+	box, input := m.styles.inputBox, m.styles.status.Render("> "+m.input+inputCursor)
+	if m.typoFlash > 0 {
+		box = box.BorderForeground(m.styles.garbage.GetForeground())
+		if m.input == "" {
+			input = m.styles.garbage.Render("> " + m.rejected + inputCursor)
+		}
+	}
+	return box.Width(inputBoxWidth).Render(strings.Join([]string{"  " + target, input}, "\n"))
+}
+
+// withInputBox sets the input box to the right of the status line, or to
+// its left when the screen is mirrored.
+func (m model) withInputBox(status string) string {
+	if m.rtl {
+		return lipgloss.JoinHorizontal(lipgloss.Center, m.renderInputBox(), "  ", status)
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Center, status, "  ", m.renderInputBox())
+}
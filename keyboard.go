@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Key drills deal only words typable on one part of the keyboard, for
+// focused finger training: -keys left or right for one hand, or top, home
+// or bottom for one row. Which keys those are depends on the keyboard
+// layout, declared with 'config set layout'. The one-handed preset,
+// -one-hand, is a hand's drill with the words falling slower. A drill's
+// dictionary isn't the one the boards and challenge codes know, so drills
+// stay off them.
+
+// keyboardLayout names the letters under each hand and on each row.
+type keyboardLayout struct {
+	left, right       string
+	top, home, bottom string
+}
+
+var keyboardLayouts = map[string]keyboardLayout{
+	"qwerty": {
+		left: "qwertasdfgzxcvb", right: "yuiophjklnm",
+		top: "qwertyuiop", home: "asdfghjkl", bottom: "zxcvbnm",
+	},
+	"dvorak": {
+		left: "pyaoeuiqjkx", right: "fgcrldhtnsbmwvz",
+		top: "pyfgcrl", home: "aoeuidhtns", bottom: "qjkxbmwvz",
+	},
+	"colemak": {
+		left: "qwfpgarstdzxcvb", right: "jluyhneiokm",
+		top: "qwfpgjluy", home: "arstdhneio", bottom: "zxcvbkm",
+	},
+	"azerty": {
+		left: "azertqsdfgwxcvb", right: "yuiophjklmn",
+		top: "azertyuiop", home: "qsdfghjklm", bottom: "wxcvbn",
+	},
+}
+
+var keyDrills = []string{"left", "right", "top", "home", "bottom"}
+
+// layoutNames lists the known layouts, sorted.
+func layoutNames() []string {
+	names := make([]string, 0, len(keyboardLayouts))
+	for name := range keyboardLayouts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// drillKeys is the letters a key drill allows.
+func (l keyboardLayout) drillKeys(drill string) (string, error) {
+	switch drill {
+	case "left":
+		return l.left, nil
+	case "right":
+		return l.right, nil
+	case "top":
+		return l.top, nil
+	case "home":
+		return l.home, nil
+	case "bottom":
+		return l.bottom, nil
+	}
+	return "", fmt.Errorf("unknown key drill %q (want %s)", drill, strings.Join(keyDrills, ", "))
+}
+
+// withKeys restricts the game's words to a key drill on the player's
+// layout.
+func (m model) withKeys(drill string) (model, error) {
+	layout := m.layout
+	if layout == "" {
+		layout = "qwerty"
+	}
+	keys, err := keyboardLayouts[layout].drillKeys(drill)
+	if err != nil {
+		return m, err
+	}
+	d := m.dict.filter(func(w string) bool { return typableWith(w, keys) })
+	if d.len() == 0 {
+		return m, fmt.Errorf("dictionary has no words typable with only %s (%s on %s)", keys, drill, layout)
+	}
+	m.dict = d
+	m.keyDrill = drill
+	return m, nil
+}
+
+// oneHandFall is how fast words fall in the one-handed preset, against
+// the usual pace.
+const oneHandFall = 0.7
+
+// withOneHand is the one-handed preset, -one-hand left or right: only
+// words typable with that hand, falling at oneHandFall of the pace, for
+// players with one usable hand.
+func (m model) withOneHand(hand string) (model, error) {
+	if hand != "left" && hand != "right" {
+		return m, fmt.Errorf("-one-hand is left or right, not %q", hand)
+	}
+	m, err := m.withKeys(hand)
+	if err != nil {
+		return m, err
+	}
+	m.oneHand = true
+	m.pace.Fall *= oneHandFall
+	m.pace.FallGrowth *= oneHandFall
+	return m, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Every kill records how long the word was on screen, in game time so
+// pauses don't count. The stats view averages those times by word length
+// and by word across the history, and lists the slowest words as ones to
+// practice.
+
+// slowWords is how many of the slowest words the stats view lists.
+const slowWords = 10
+
+// gameTime is how long the game has run, pauses excluded, to the frame.
+func (m model) gameTime() time.Duration {
+	return time.Duration(m.ticks)*tickRate + m.sinceStep
+}
+
+// killed records how long word took to kill.
+func (t *tally) killed(word string, took time.Duration) {
+	if t.KillTimes == nil {
+		t.KillTimes = map[string]int64{}
+	}
+	t.KillTimes[word] = took.Milliseconds()
+}
+
+// killAverage is a running average of kill times.
+type killAverage struct {
+	total time.Duration
+	n     int
+}
+
+func (a *killAverage) add(ms int64) {
+	a.total += time.Duration(ms) * time.Millisecond
+	a.n++
+}
+
+func (a killAverage) mean() time.Duration {
+	if a.n == 0 {
+		return 0
+	}
+	return a.total / time.Duration(a.n)
+}
+
+// killTimes averages the history's kill times by word length and by word.
+func killTimes(sessions []session) (byLength map[int]killAverage, byWord map[string]killAverage) {
+	byLength, byWord = map[int]killAverage{}, map[string]killAverage{}
+	for _, s := range sessions {
+		for w, ms := range s.KillTimes {
+			l, a := byLength[len(w)], byWord[w]
+			l.add(ms)
+			a.add(ms)
+			byLength[len(w)], byWord[w] = l, a
+		}
+	}
+	return byLength, byWord
+}
+
+// printKillTimes writes the time-to-kill part of the stats view.
+func printKillTimes(sessions []session) {
+	byLength, byWord := killTimes(sessions)
+	if len(byWord) == 0 {
+		return
+	}
+	lengths := make([]int, 0, len(byLength))
+	for l := range byLength {
+		lengths = append(lengths, l)
+	}
+	sort.Ints(lengths)
+	fmt.Printf("\nTime to kill:\n")
+	for _, l := range lengths {
+		fmt.Printf("  %2d letters  %5.1fs  (%d words)\n", l, byLength[l].mean().Seconds(), byLength[l].n)
+	}
+
+	words := make([]string, 0, len(byWord))
+	for w := range byWord {
+		words = append(words, w)
+	}
+	sort.Slice(words, func(i, j int) bool {
+		if a, b := byWord[words[i]].mean(), byWord[words[j]].mean(); a != b {
+			return a > b
+		}
+		return words[i] < words[j]
+	})
+	words = words[:min(slowWords, len(words))]
+	slowest := make([]string, len(words))
+	for i, w := range words {
+		slowest[i] = fmt.Sprintf("%s %.1fs", w, byWord[w].mean().Seconds())
+	}
+	fmt.Printf("  Slowest:    %s\n", strings.Join(slowest, ", "))
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// The lobby keeps the versus ratings of the players it matches rather than
+// taking the rating a client reports, which could say anything. Players
+// with an account on the leaderboard server sign in to a lobby on the same
+// host with the account's token, and the lobby rates the matches it relays
+// between signed-in players, appending each result to the accounts file it
+// shares with the leaderboard server ('lobby -accounts'). Everyone else
+// plays at the starting rating, unrated. The leaderboard server's versus
+// board is this ladder.
+
+// ladderMatch is a match the lobby rated, as a line of the accounts file.
+type ladderMatch struct {
+	OpponentRating int  `json:"opponent_rating"`
+	Won            bool `json:"won,omitempty"`
+}
+
+// Server
+
+// signIn sets pl's account and rating from the token sent with their
+// hello. The caller holds l.mu.
+func (l *lobby) signIn(pl *lobbyPlayer, token string) {
+	pl.rating = rating{Rating: startRating}
+	l.accounts.mu.Lock()
+	defer l.accounts.mu.Unlock()
+	if a := l.accounts.lookup(token); a != nil {
+		pl.account = a.id
+		pl.rating = a.rating
+	}
+}
+
+// rated reports whether a match between players moves their ratings: all
+// of them are signed in and none is handicapped.
+func rated(players ...*lobbyPlayer) bool {
+	for _, pl := range players {
+		if pl.account == "" || pl.handicap != (handicap{}) {
+			return false
+		}
+	}
+	return true
+}
+
+// averageRating is side's rating as one player.
+func averageRating(side []*lobbyPlayer) int {
+	sum := 0
+	for _, pl := range side {
+		sum += pl.rating.Rating
+	}
+	return sum / max(1, len(side))
+}
+
+// rate records the winners beating the losers, each side rated against the
+// other's average, if the match is rated. The caller holds l.mu.
+func (l *lobby) rate(winners, losers []*lobbyPlayer) {
+	if !rated(slices.Concat(winners, losers)...) {
+		return
+	}
+	won, lost := averageRating(winners), averageRating(losers)
+	l.accounts.mu.Lock()
+	defer l.accounts.mu.Unlock()
+	record := func(side []*lobbyPlayer, opponent int, win bool) {
+		for _, pl := range side {
+			rec := accountRecord{ID: pl.account, Match: &ladderMatch{OpponentRating: opponent, Won: win}}
+			if err := l.accounts.writeLocked(rec); err != nil {
+				logger.Error("ladder", "name", pl.name, "err", err)
+				continue
+			}
+			if a := l.accounts.accounts[pl.account]; a != nil {
+				pl.rating = a.rating
+			}
+		}
+	}
+	record(winners, lost, true)
+	record(losers, won, false)
+}
+
+// ladderPage is a page of the versus board: the accounts with rated
+// matches, best rating first.
+func (s *accountStore) ladderPage(offset, limit int) boardPage {
+	p := boardPage{Mode: "versus", Offset: offset, Entries: []boardEntry{}}
+	if s == nil {
+		return p
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.catchUp(); err != nil {
+		logger.Warn("reading accounts", "err", err)
+	}
+	var ladder []boardEntry
+	for _, a := range s.accounts {
+		if a.rating.Games > 0 {
+			ladder = append(ladder, boardEntry{Name: a.name, Mode: "versus", Rating: a.rating.Rating, Games: a.rating.Games})
+		}
+	}
+	slices.SortFunc(ladder, func(a, b boardEntry) int {
+		if a.Rating != b.Rating {
+			return b.Rating - a.Rating
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	p.Total = len(ladder)
+	for i := offset; i < len(ladder) && i < offset+limit; i++ {
+		e := ladder[i]
+		e.Rank = i + 1
+		p.Entries = append(p.Entries, e)
+	}
+	return p
+}
+
+// Client
+
+// ladderToken is the account token to sign in to the lobby at addr with:
+// the profile's, if its account is kept on the lobby's host.
+func ladderToken(addr string) string {
+	link, err := loadAccount("")
+	if err != nil || link == nil {
+		return ""
+	}
+	u, err := url.Parse(link.Server)
+	if err != nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil || host == "" || host != u.Hostname() {
+		return ""
+	}
+	return link.Token
+}
+
+// withLadder sets m up for the match the lobby in lm matched us into. Both
+// ratings are the lobby's, and it says whether the match is rated.
+func (m model) withLadder(lm lobbyModel) model {
+	m.ladder = true
+	m.ladderRated = lm.matched.Rated
+	m.rating = lm.rating
+	m.opponent.rating = lm.matched.Rating
+	return m
+}
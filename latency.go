@@ -0,0 +1,82 @@
+package main
+
+import (
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Latency and lag compensation. Every heartbeat "ping" carries the
+// sender's clock in At and is answered with a "pong" echoing it in Echo,
+// next to the answering side's own clock in At. The round trip is the
+// latency shown in the multiplayer panels, and its midpoint gives the
+// offset between the two clocks.
+//
+// Garbage is stamped with the sender's clock as well and lands
+// garbageDelay after it was sent, by the receiver's clock. However the
+// network jitters, it hits the same moment after the attack on both sides,
+// unless it arrives later than that. The lobby moves the stamp onto its
+// own clock when relaying, and stamps the garbage it hands out in a team
+// match.
+
+// garbageDelay is how long after being sent garbage lands; it covers the
+// one-way trip on all but the worst connections
+const garbageDelay = 750 * time.Millisecond
+
+// stamp is t as sent in At and Echo, Unix milliseconds.
+func stamp(t time.Time) int64 {
+	return t.UnixMilli()
+}
+
+// pong takes in the answer to one of our pings, received at now.
+func (p *peer) pong(msg netMsg, now time.Time) {
+	sent := time.UnixMilli(msg.Echo)
+	rtt := now.Sub(sent)
+	if rtt < 0 {
+		return
+	}
+	old := time.Duration(p.rtt.Load())
+	smoothed := rtt
+	if old != 0 {
+		smoothed = old + (rtt-old)/8
+	}
+	p.rtt.Store(int64(smoothed))
+	if old == 0 || rtt <= smoothed {
+		// Quick round trips waited least in queues, so their midpoint is
+		// the better guess
+		p.offset.Store(int64(time.UnixMilli(msg.At).Sub(sent.Add(rtt / 2))))
+	}
+}
+
+// latency is the smoothed round trip to the other side; zero until the
+// first pong.
+func (p *peer) latency() time.Duration {
+	return time.Duration(p.rtt.Load())
+}
+
+// localTime is the other side's stamp at by our clock.
+func (p *peer) localTime(at int64) time.Time {
+	return time.UnixMilli(at).Add(-time.Duration(p.offset.Load()))
+}
+
+// receiveGarbage queues the garbage in msg to land garbageDelay after it
+// was sent, or right away if it carries no stamp.
+func (m model) receiveGarbage(msg netMsg) model {
+	lands := time.Now()
+	if msg.At != 0 {
+		lands = m.peer.localTime(msg.At).Add(garbageDelay)
+	}
+	for range msg.Count {
+		m.pendingGarbage = append(m.pendingGarbage, lands)
+	}
+	slices.SortFunc(m.pendingGarbage, time.Time.Compare)
+	return m
+}
+
+// pingText is a latency for the panels.
+func pingText(ms int) string {
+	if ms == 0 {
+		return "-"
+	}
+	return strconv.Itoa(ms) + " ms"
+}
@@ -0,0 +1,602 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// boardModes are the separate leaderboards; solo games have no session
+// mode. The weekly board starts over every week.
+var boardModes = []string{"solo", "coop", "versus", "weekly"}
+
+const (
+	boardPageSize = 10
+	maxBoardName  = 16
+	// maxBoardBody bounds a submission, keystroke log included
+	maxBoardBody = 4 << 20
+)
+
+// boardEntry is one submitted score.
+type boardEntry struct {
+	Name     string  `json:"name"`
+	Mode     string  `json:"mode"`
+	Score    int     `json:"score"`
+	Level    int     `json:"level"`
+	Words    int     `json:"words"`
+	WPM      int     `json:"wpm"`
+	Accuracy float64 `json:"accuracy"`
+	// Rating and Games are an account's ladder rating and rated matches,
+	// on the versus board, which the server fills in from the accounts
+	Rating int       `json:"rating,omitempty"`
+	Games  int       `json:"games,omitempty"`
+	Time   time.Time `json:"time"`
+	// Rank is filled in when the entry is served
+	Rank int `json:"rank,omitempty"`
+	// Seed, Start, Ticks, Dict and Keys let the server replay the game;
+	// only the keystroke log's Digest is kept once the entry is accepted
+	Seed int64 `json:"seed"`
+	// Start is the starting level of games that didn't start at 1
+	Start int `json:"start_level,omitempty"`
+	// LevelTicks is set for games with time-based leveling
+	LevelTicks int `json:"level_ticks,omitempty"`
+	// Enter is set for games where words were finished with enter
+	Enter bool `json:"require_enter,omitempty"`
+	// Modifiers are what set the game apart from the standard rules, see
+	// modifiers.go
+	Modifiers []string `json:"modifiers,omitempty"`
+	// Week is the ISO week of a weekly challenge score
+	Week   string      `json:"week,omitempty"`
+	Ticks  int         `json:"ticks"`
+	Dict   string      `json:"dict,omitempty"`
+	Keys   []keystroke `json:"keys,omitempty"`
+	Digest string      `json:"digest,omitempty"`
+}
+
+// boardPage is one page of a board as served by GET /scores.
+type boardPage struct {
+	Mode string `json:"mode"`
+	// Week is the week a weekly page is for
+	Week    string       `json:"week,omitempty"`
+	Offset  int          `json:"offset"`
+	Total   int          `json:"total"`
+	Entries []boardEntry `json:"entries"`
+}
+
+// boardRank is the reply to a submission.
+type boardRank struct {
+	Rank  int `json:"rank"`
+	Total int `json:"total"`
+}
+
+func boardMode(mode string) string {
+	if mode == "" {
+		return "solo"
+	}
+	return mode
+}
+
+// boardKey names the board e belongs on: its mode, and for weekly scores
+// the week as well.
+func boardKey(mode, week string) string {
+	if mode == "weekly" {
+		return mode + "/" + week
+	}
+	return mode
+}
+
+func validBoardMode(mode string) bool {
+	for _, m := range boardModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// Server
+
+// boardStore keeps every board sorted by score, best first. Submissions
+// are appended to a JSONL file and replayed on startup.
+type boardStore struct {
+	mu     sync.Mutex
+	path   string
+	boards map[string][]boardEntry
+	// dict replays submissions, see verify.go
+	dict *dictionary
+	// accounts, when set, puts submissions from account holders under the
+	// account's name
+	accounts *accountStore
+}
+
+func openBoardStore(path string) (*boardStore, error) {
+	s := &boardStore{path: path, boards: make(map[string][]boardEntry)}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e boardEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			// Skip a line torn by a crash rather than refusing to start
+			continue
+		}
+		s.insert(e)
+	}
+	return s, scanner.Err()
+}
+
+// insert places e after any equal scores, so earlier scores keep their
+// rank, and returns its 1-based rank.
+func (s *boardStore) insert(e boardEntry) int {
+	key := boardKey(e.Mode, e.Week)
+	b := s.boards[key]
+	i := sort.Search(len(b), func(i int) bool { return b[i].Score < e.Score })
+	b = append(b, boardEntry{})
+	copy(b[i+1:], b[i:])
+	b[i] = e
+	s.boards[key] = b
+	return i + 1
+}
+
+func (s *boardStore) submit(e boardEntry) (boardRank, error) {
+	e.Keys = nil
+	line, err := json.Marshal(e)
+	if err != nil {
+		return boardRank{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return boardRank{}, err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return boardRank{}, err
+	}
+	if err := f.Close(); err != nil {
+		return boardRank{}, err
+	}
+	rank := s.insert(e)
+	return boardRank{Rank: rank, Total: len(s.boards[boardKey(e.Mode, e.Week)])}, nil
+}
+
+func (s *boardStore) page(mode, week string, offset, limit int) boardPage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b := s.boards[boardKey(mode, week)]
+	p := boardPage{Mode: mode, Week: week, Offset: offset, Total: len(b), Entries: []boardEntry{}}
+	for i := offset; i < len(b) && i < offset+limit; i++ {
+		e := b[i]
+		e.Rank = i + 1
+		p.Entries = append(p.Entries, e)
+	}
+	return p
+}
+
+func (s *boardStore) handleScores(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		q := r.URL.Query()
+		mode := boardMode(q.Get("mode"))
+		if !validBoardMode(mode) {
+			http.Error(w, "unknown mode", http.StatusBadRequest)
+			return
+		}
+		offset, _ := strconv.Atoi(q.Get("offset"))
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		if offset < 0 {
+			offset = 0
+		}
+		if limit <= 0 || limit > 100 {
+			limit = boardPageSize
+		}
+		week := ""
+		if mode == "weekly" {
+			if week = q.Get("week"); week == "" {
+				week = weekID(time.Now())
+			}
+		}
+		if mode == "versus" {
+			writeJSON(w, s.accounts.ladderPage(offset, limit))
+			return
+		}
+		writeJSON(w, s.page(mode, week, offset, limit))
+	case http.MethodPost:
+		var e boardEntry
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBoardBody)).Decode(&e); err != nil {
+			http.Error(w, "bad entry", http.StatusBadRequest)
+			return
+		}
+		e.Name = strings.TrimSpace(e.Name)
+		if s.accounts != nil {
+			if a := s.accounts.byRequest(r); a != nil {
+				e.Name = a.name
+			} else if s.accounts.named(e.Name) {
+				http.Error(w, "that name belongs to an account; sign in to submit under it", http.StatusForbidden)
+				return
+			}
+		}
+		e.Mode = boardMode(e.Mode)
+		if e.Name == "" || len([]rune(e.Name)) > maxBoardName || !validBoardMode(e.Mode) || e.Score < 0 {
+			http.Error(w, "bad entry", http.StatusBadRequest)
+			return
+		}
+		if e.Mode != "weekly" {
+			e.Week = ""
+		} else if !weeklyOpen(e.Week, time.Now()) || e.Seed != weeklySeed(e.Week) || e.Start > 1 || e.LevelTicks > 0 {
+			http.Error(w, "not this week's challenge", http.StatusUnprocessableEntity)
+			return
+		}
+		if err := s.verify(e); err != nil {
+			logger.Warn("leaderboard reject", "name", e.Name, "mode", e.Mode, "score", e.Score, "err", err)
+			http.Error(w, "score rejected: "+err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		e.Rank, e.Rating, e.Games = 0, 0, 0
+		e.Time = time.Now().UTC()
+		rank, err := s.submit(e)
+		if err != nil {
+			logger.Error("leaderboard submit", "err", err)
+			http.Error(w, "storing score failed", http.StatusInternalServerError)
+			return
+		}
+		logger.Info("leaderboard submit", "name", e.Name, "mode", e.Mode, "score", e.Score, "rank", rank.Rank)
+		writeJSON(w, rank)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.Warn("writing response", "err", err)
+	}
+}
+
+// leaderboardOptions are the flags accepted by the leaderboard command.
+type leaderboardOptions struct {
+	addr     string
+	db       string
+	dictPath string
+	// accounts is the accounts file and replays the replay archive, next
+	// to db unless set
+	accounts string
+	replays  string
+}
+
+func addLeaderboardFlags(fs *flag.FlagSet, opts *leaderboardOptions) {
+	fs.StringVar(&opts.addr, "addr", ":8080", "Address to serve the leaderboard on")
+	fs.StringVar(&opts.db, "db", "", "Scores file (default: leaderboard.jsonl in the data directory)")
+	fs.StringVar(&opts.dictPath, "d", "/usr/share/dict/words", "Dictionary to replay submitted games with; games played with another are rejected")
+	fs.StringVar(&opts.accounts, "accounts", "", "Player accounts file (default: accounts.jsonl next to the scores file)")
+	fs.StringVar(&opts.replays, "replays", "", "Directory for the replay archive (default: replays next to the scores file)")
+}
+
+// serveLeaderboard runs the HTTP leaderboard until it fails.
+func serveLeaderboard(opts leaderboardOptions) error {
+	if opts.db == "" {
+		dir, err := dataDir()
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+		opts.db = filepath.Join(dir, "leaderboard.jsonl")
+	}
+	store, err := openBoardStore(opts.db)
+	if err != nil {
+		return fmt.Errorf("loading scores: %w", err)
+	}
+	if store.dict, err = loadDictionary(opts.dictPath); err != nil {
+		return fmt.Errorf("loading dictionary: %w", err)
+	}
+	if opts.accounts == "" {
+		opts.accounts = filepath.Join(filepath.Dir(opts.db), "accounts.jsonl")
+	}
+	if store.accounts, err = openAccountStore(opts.accounts); err != nil {
+		return fmt.Errorf("loading accounts: %w", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/scores", store.handleScores)
+	mux.HandleFunc("/accounts", store.accounts.handleRegister)
+	mux.HandleFunc("/account", store.accounts.handleAccount)
+	mux.HandleFunc("/account/", store.accounts.handleAccount)
+	if opts.replays == "" {
+		opts.replays = filepath.Join(filepath.Dir(opts.db), "replays")
+	}
+	replays, err := openReplayStore(opts.replays, store)
+	if err != nil {
+		return fmt.Errorf("loading replays: %w", err)
+	}
+	mux.HandleFunc("/replays", replays.handleReplays)
+	mux.HandleFunc("/replays/", replays.handleReplay)
+	fmt.Printf("Serving the leaderboard on %s\n", opts.addr)
+	return http.ListenAndServe(opts.addr, mux)
+}
+
+// Client
+
+// leaderboard is a player's connection to a leaderboard server and what
+// the game over screen shows of it.
+type leaderboard struct {
+	url  string
+	name string
+	// token is the player's account on the server, if they have one
+	token string
+	// submitting is set while a submission is in flight; rank is set once
+	// it has been accepted
+	submitting bool
+	rank       *boardRank
+	err        error
+	// uploading is set while the replay is being uploaded to the archive,
+	// and replay once it has been; see replays.go
+	uploading bool
+	replay    *replaySummary
+	replayErr error
+	// open shows the board browser instead of the results
+	open bool
+	page boardPage
+}
+
+type boardRankMsg struct {
+	rank boardRank
+	err  error
+}
+
+type boardPageMsg struct {
+	page boardPage
+	err  error
+}
+
+func (l leaderboard) endpoint() string {
+	return strings.TrimSuffix(l.url, "/") + "/scores"
+}
+
+func submitScoreCmd(l leaderboard, e boardEntry) tea.Cmd {
+	return func() tea.Msg {
+		body, err := json.Marshal(e)
+		if err != nil {
+			return boardRankMsg{err: err}
+		}
+		req, err := http.NewRequest(http.MethodPost, l.endpoint(), bytes.NewReader(body))
+		if err != nil {
+			return boardRankMsg{err: err}
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if l.token != "" {
+			req.Header.Set("Authorization", "Bearer "+l.token)
+		}
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return boardRankMsg{err: err}
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return boardRankMsg{err: serverError("leaderboard", resp)}
+		}
+		var r boardRank
+		err = json.NewDecoder(resp.Body).Decode(&r)
+		return boardRankMsg{rank: r, err: err}
+	}
+}
+
+func fetchBoardCmd(l leaderboard, mode string, offset int) tea.Cmd {
+	return func() tea.Msg {
+		q := url.Values{"mode": {mode}, "offset": {strconv.Itoa(offset)}, "limit": {strconv.Itoa(boardPageSize)}}
+		resp, err := httpClient.Get(l.endpoint() + "?" + q.Encode())
+		if err != nil {
+			return boardPageMsg{err: err}
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return boardPageMsg{err: fmt.Errorf("leaderboard: %s", resp.Status)}
+		}
+		var p boardPage
+		err = json.NewDecoder(resp.Body).Decode(&p)
+		return boardPageMsg{page: p, err: err}
+	}
+}
+
+// serverError is a failed response as an error, with the server's reason
+// when it gave one.
+func serverError(server string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if reason := cleanChat(string(body)); reason != "" {
+		return fmt.Errorf("%s: %s", server, reason)
+	}
+	return fmt.Errorf("%s: %s", server, resp.Status)
+}
+
+// boardEntry is what gets submitted for the finished game.
+func (m model) boardEntry() boardEntry {
+	s := m.result()
+	e := boardEntry{
+		Name:       m.board.name,
+		Mode:       boardMode(s.Mode),
+		Score:      s.Score,
+		Level:      s.Level,
+		Words:      s.WordsTyped,
+		WPM:        s.WPM,
+		Accuracy:   s.Accuracy,
+		Seed:       m.seed,
+		Start:      m.startLevel,
+		LevelTicks: m.levelTicks,
+		Enter:      m.requireEnter,
+		Week:       s.Week,
+		Modifiers:  s.Modifiers,
+		Ticks:      m.ticks,
+		Dict:       m.dict.digest(),
+		Keys:       m.keys,
+		Digest:     keyDigest(m.keys),
+	}
+	return e
+}
+
+// updateBoard handles keys on the game over screen when a leaderboard is
+// configured.
+func (m model) updateBoard(key string) (model, tea.Cmd) {
+	if m.board.open {
+		p := m.board.page
+		switch key {
+		case "esc", "l":
+			m.board.open = false
+		case "right", "n", "pgdown":
+			if p.Offset+boardPageSize < p.Total {
+				return m, fetchBoardCmd(m.board, p.Mode, p.Offset+boardPageSize)
+			}
+		case "left", "p", "pgup":
+			if p.Offset > 0 {
+				return m, fetchBoardCmd(m.board, p.Mode, max(0, p.Offset-boardPageSize))
+			}
+		case "tab":
+			next := boardModes[0]
+			for i, mode := range boardModes {
+				if mode == p.Mode {
+					next = boardModes[(i+1)%len(boardModes)]
+				}
+			}
+			return m, fetchBoardCmd(m.board, next, 0)
+		}
+		return m, nil
+	}
+	switch key {
+	case "s":
+		if m.board.submitting || m.board.rank != nil || !m.submittable() {
+			return m, nil
+		}
+		m.board.submitting = true
+		m.board.err = nil
+		return m, submitScoreCmd(m.board, m.boardEntry())
+	case "u":
+		if m.board.uploading || m.board.replay != nil || !m.replayable() {
+			return m, nil
+		}
+		m.board.uploading = true
+		m.board.replayErr = nil
+		return m, uploadReplayCmd(m.board, m.boardEntry())
+	case "l":
+		m.board.open = true
+		mode := boardMode(m.result().Mode)
+		if m.board.rank != nil {
+			// Open on the page holding the player's own score
+			return m, fetchBoardCmd(m.board, mode, (m.board.rank.Rank-1)/boardPageSize*boardPageSize)
+		}
+		return m, fetchBoardCmd(m.board, mode, 0)
+	}
+	return m, nil
+}
+
+// submittable reports whether the finished game may go on the boards.
+func (m model) submittable() bool {
+	return m.peer == nil && !m.assisted && !m.resumed && !m.fed && !m.evented && !m.narrowed && !m.reloaded && !m.controlled && m.keyDrill == "" && !m.shift && m.hints == 0 && !m.slowed() && !m.customRules()
+}
+
+// renderBoardStatus is the game over screen's leaderboard line.
+func (m model) renderBoardStatus() string {
+	switch {
+	case m.board.submitting:
+		return "Submitting score..."
+	case m.board.rank != nil:
+		return fmt.Sprintf("Global rank: #%d of %d on the %s board", m.board.rank.Rank, m.board.rank.Total, boardMode(m.result().Mode))
+	case m.board.err != nil:
+		return fmt.Sprintf("Leaderboard unavailable: %v", m.board.err)
+	case m.peer != nil:
+		return "Versus matches are ranked by the lobby; press l for the ladder"
+	case m.assisted:
+		return "Assisted games can't be submitted"
+	case m.resumed:
+		return "Resumed games can't be submitted"
+	case m.fed:
+		return "Games with chat words can't be submitted"
+	case m.evented:
+		return "Games with seasonal event words can't be submitted"
+	case m.controlled:
+		return "Games whose level was set over the control socket can't be submitted"
+	case m.reloaded:
+		return "Games that reloaded their dictionary or settings can't be submitted"
+	case m.narrowed:
+		return "Games played on a narrowed playfield can't be submitted"
+	case m.oneHand:
+		return "One-handed games can't be submitted"
+	case m.keyDrill != "":
+		return "Key drills can't be submitted"
+	case m.shift:
+		return "Shift training games can't be submitted"
+	case m.slowed():
+		return "Slow-motion games can't be submitted"
+	case m.hints > 0:
+		return "Games with hints can't be submitted"
+	case m.customRules():
+		return "Games with a custom difficulty profile can't be submitted"
+	}
+	return ""
+}
+
+// renderBoard is the paginated board browser.
+func (m model) renderBoard() string {
+	var b strings.Builder
+	p := m.board.page
+	b.WriteString("\n\n")
+	title := "LEADERBOARD - " + strings.ToUpper(boardMode(p.Mode))
+	if p.Week != "" {
+		title += " " + p.Week
+	}
+	b.WriteString(m.styles.title.Render(title))
+	b.WriteString("\n\n")
+	switch {
+	case m.board.err != nil:
+		b.WriteString(m.styles.stats.Render(fmt.Sprintf("Leaderboard unavailable: %v", m.board.err)) + "\n")
+	case p.Total == 0:
+		b.WriteString(m.styles.stats.Render("No scores yet") + "\n")
+	default:
+		versus := p.Mode == "versus"
+		header := fmt.Sprintf("%5s  %-*s %7s %5s %4s %6s", "RANK", maxBoardName, "NAME", "SCORE", "LEVEL", "WPM", "ACC")
+		if versus {
+			header = fmt.Sprintf("%5s  %-*s %6s %7s", "RANK", maxBoardName, "NAME", "RATING", "MATCHES")
+		}
+		b.WriteString(m.styles.help.Render(header) + "\n")
+		for _, e := range p.Entries {
+			line := fmt.Sprintf("%5d  %-*s %7d %5d %4d %5.1f%%", e.Rank, maxBoardName, e.Name, e.Score, e.Level, e.WPM, e.Accuracy)
+			if versus {
+				line = fmt.Sprintf("%5d  %-*s %6d %7d", e.Rank, maxBoardName, e.Name, e.Rating, e.Games)
+			}
+			if len(e.Modifiers) > 0 {
+				line += "  " + strings.Join(e.Modifiers, ", ")
+			}
+			if m.board.rank != nil && e.Rank == m.board.rank.Rank {
+				b.WriteString(m.styles.highlight.Render(line) + "\n")
+			} else {
+				b.WriteString(m.styles.stats.Render(line) + "\n")
+			}
+		}
+		pages := (p.Total + boardPageSize - 1) / boardPageSize
+		b.WriteString("\n" + m.styles.stats.Render(fmt.Sprintf("Page %d of %d", p.Offset/boardPageSize+1, pages)) + "\n")
+	}
+	b.WriteString("\n" + m.styles.help.Render("[n/p: page | TAB: mode | l/ESC: back | q: quit]"))
+	return b.String()
+}
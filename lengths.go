@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Early levels deal short words and later ones work up to long ones. At a
+// level, words are dealt from a band of lengths whose top starts at
+// wordLengths.Start letters and grows by Growth letters a level, and which
+// reaches Spread letters down from the top. The band is part of the
+// difficulty profile.
+type wordLengths struct {
+	Start  int     `json:"start"`
+	Growth float64 `json:"growth"`
+	Spread int     `json:"spread"`
+}
+
+var defaultWordLengths = wordLengths{Start: 5, Growth: 1, Spread: maxWordLen}
+
+// band is the shortest and longest word dealt at level.
+func (l wordLengths) band(level int) (lo, hi int) {
+	hi = l.Start + int(l.Growth*float64(max(0, level-1)))
+	hi = min(max(hi, minWordLen), maxWordLen)
+	return max(minWordLen, hi-l.Spread+1), hi
+}
+
+// lengthBand is the band of lengths to deal from d at the current level,
+// no longer than maxLen. A band d has no words in is dropped rather than
+// leaving nothing to deal.
+func (m model) lengthBand(d *dictionary, maxLen int) (lo, hi int) {
+	lo, hi = m.lengths.band(m.level)
+	hi = min(hi, maxLen)
+	if lo > hi || d.start[lo] == d.start[hi+1] {
+		return minWordLen, maxLen
+	}
+	return lo, hi
+}
+
+// lengthKey is a 'config set' setter for a whole number of letters.
+func lengthKey(field func(s *settings) *int) func(s *settings, value string) error {
+	return func(s *settings, value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxWordLen {
+			return fmt.Errorf("word lengths are from 1 to %d letters, not %q", maxWordLen, value)
+		}
+		*field(s) = n
+		return nil
+	}
+}
+
+func setLengthGrowth(s *settings, value string) error {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f < 0 || math.IsInf(f, 0) {
+		return fmt.Errorf("word-length-growth is letters per level, 0 or more, not %q", value)
+	}
+	s.Lengths.Growth = f
+	return nil
+}
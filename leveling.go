@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// The level goes up every wordsPerLevel words, or with time-based leveling
+// every so many ticks whatever the player's speed, so slow but accurate
+// players still see the game escalate. Leveling counts ticks rather than
+// wall time so the server can replay the game.
+
+const (
+	wordsPerLevel = 15
+	// defaultLevelTime is how long a level lasts with time-based leveling
+	// when the difficulty profile doesn't say
+	defaultLevelTime = 45 * time.Second
+)
+
+// levelUp moves to the next level.
+func (m model) levelUp() model {
+	m.level++
+	logger.Info("level up", "level", m.level)
+	m.emit("level_up", nil)
+	m = m.note("level_up", nil)
+	m = m.applyReload()
+	m = m.announceLevel()
+	if m.speedrun > 0 {
+		m = m.takeSplit()
+	}
+	return m
+}
+
+// levelTicks is how many ticks a level lasts with time-based leveling, or
+// zero when leveling goes by words.
+func (s settings) levelTicks() int {
+	if s.Leveling != "time" {
+		return 0
+	}
+	every := s.LevelTime
+	if every <= 0 {
+		every = defaultLevelTime
+	}
+	return max(1, int(every/tickRate))
+}
+
+// levelRule explains leveling on the title screen.
+func (m model) levelRule() string {
+	if m.levelTicks > 0 {
+		return fmt.Sprintf("Every %v the level goes up.", time.Duration(m.levelTicks)*tickRate)
+	}
+	return fmt.Sprintf("Every %d words the level goes up.", wordsPerLevel)
+}
+
+// levelBarCells is the width of the progress bar to the next level.
+const levelBarCells = 6
+
+// levelProgress is how far the game is into the current level, as done out
+// of total words or ticks, and the unit they count.
+func (m model) levelProgress() (done, total int, unit string) {
+	if m.levelTicks > 0 {
+		return m.ticks % m.levelTicks, m.levelTicks, "s"
+	}
+	return (m.wordsTyped + m.partner.words) % wordsPerLevel, wordsPerLevel, "w"
+}
+
+// renderNextLevel draws a bar filling up towards the next level, with the
+// words or seconds still to go.
+func (m model) renderNextLevel() string {
+	done, total, unit := m.levelProgress()
+	left := total - done
+	if unit == "s" {
+		left = int((time.Duration(left) * tickRate).Seconds())
+	}
+	filled := done * levelBarCells / total
+	return m.styles.status.Render("Next ") +
+		m.styles.popup.Render(strings.Repeat("▰", filled)) +
+		m.styles.help.Render(strings.Repeat("▱", levelBarCells-filled)) +
+		m.styles.status.Render(fmt.Sprintf(" %d%s", left, unit))
+}
@@ -0,0 +1,769 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The lobby pairs versus players who can't reach each other directly.
+// Players either host a room, join one from the browser, or queue to be
+// matched with someone of a similar rating. Once paired the lobby relays
+// the match, so both sides speak the usual versus protocol through it.
+//
+// Lobby messages: "hello" (Version, Name, Account, Handicap) to join,
+// answered by "welcome" (Token, Ladder) or "error" (Text), "host",
+// "queue" and "cancel" to change what you're waiting for, "join" (Room) to
+// take a room, "handicap" (Handicap) to change yours, "enter" to enter the
+// tournament and "chat" (Text) to talk to everyone in the lobby. The lobby
+// answers with "rooms" (Rooms, Players) whenever the list changes,
+// "bracket" (Bracket), "matched" (Name, Rating, Handicap of the opponent,
+// Rated),
+// "chat" (Name, Text), "announce" (Text) and "error" (Text). After a
+// match the client sends "leave" and the lobby confirms with "left" before
+// anything else reaches it, then "rooms" with the player's Ladder rating. A player whose connection drops mid-match may
+// "resume" it with the token, see protocol.go; the others in the match get
+// "reconnecting" and "resumed" (Name) meanwhile.
+
+const (
+	// matchWindow is the rating gap accepted straight away; it widens by
+	// matchWiden every second a player spends in the queue
+	matchWindow = 50
+	matchWiden  = 10
+)
+
+// lobbyRoom is a player waiting in the lobby for someone to join them.
+type lobbyRoom struct {
+	ID       int      `json:"id"`
+	Name     string   `json:"name"`
+	Rating   int      `json:"rating"`
+	Handicap handicap `json:"handicap"`
+	// Teams lists the players on each side of a 2v2 room; nil for a 1v1
+	// room
+	Teams [][]string `json:"teams,omitempty"`
+}
+
+// Server
+
+type lobbyPlayer struct {
+	id     int
+	p      *peer
+	name   string
+	rating rating
+	// account is the id of the account the player signed in with, see
+	// ladder.go; empty if they didn't
+	account string
+	// handicap is the one the player chose, shown with their room
+	handicap handicap
+	// state is "hosting", "queued" or empty while browsing
+	state string
+	since time.Time
+	// match is set from pairing until the player leaves the match screen
+	match *lobbyMatch
+	chat  floodGate
+	// room is the 2v2 room the player waits in, and teamMatch their team
+	// match until they leave its screen, see team.go
+	room      *teamRoom
+	teamMatch *teamMatch
+	// token resumes the player's connection, handed over on resumed
+	token   string
+	resumed chan *peer
+	// out queues the messages for the player's connection, see send, and
+	// closing gone stops delivering them
+	out  chan netMsg
+	gone chan struct{}
+}
+
+// outboxSize is how many messages a lobby player can fall behind by before
+// they're hung up on as stalled.
+const outboxSize = 256
+
+// send queues msg for pl without waiting on their connection, so a player
+// who stops reading can't hold up the lobby while it's locked.
+func (pl *lobbyPlayer) send(msg netMsg) {
+	select {
+	case pl.out <- msg:
+	default:
+		logger.Warn("lobby player stalled", "name", pl.name)
+		// Closing waits for a write in progress to time out
+		go pl.p.close()
+	}
+}
+
+// deliver writes pl's queued messages in order until they leave.
+func (pl *lobbyPlayer) deliver() {
+	for {
+		select {
+		case msg := <-pl.out:
+			if err := pl.p.send(msg); err != nil && !pl.p.resuming.Load() {
+				// The reading side notices and resumes or leaves
+				pl.p.close()
+			}
+		case <-pl.gone:
+			return
+		}
+	}
+}
+
+// busy reports whether pl is in a match of either kind.
+func (pl *lobbyPlayer) busy() bool {
+	return pl.match != nil || pl.teamMatch != nil
+}
+
+// lobbyMatch is a match the lobby is relaying.
+type lobbyMatch struct {
+	players [2]*lobbyPlayer
+	loser   *lobbyPlayer
+	// slot is the bracket match being played; nil outside the tournament
+	slot *bracketSlot
+}
+
+func (m *lobbyMatch) other(pl *lobbyPlayer) *lobbyPlayer {
+	if m.players[0] == pl {
+		return m.players[1]
+	}
+	return m.players[0]
+}
+
+type lobby struct {
+	mu      sync.Mutex
+	players map[int]*lobbyPlayer
+	nextID  int
+	// tourney is the lobby's bracket; nil when the lobby runs none
+	tourney *tournament
+	// teamRooms are the 2v2 rooms filling up, oldest first
+	teamRooms []*teamRoom
+	// accounts are the leaderboard server's, which players sign in with
+	// and the lobby keeps their ratings in
+	accounts *accountStore
+}
+
+// rooms lists open rooms, oldest first. The caller holds l.mu.
+func (l *lobby) rooms() []lobbyRoom {
+	var rooms []lobbyRoom
+	for _, pl := range l.players {
+		if pl.state == "hosting" {
+			rooms = append(rooms, lobbyRoom{ID: pl.id, Name: pl.name, Rating: pl.rating.Rating, Handicap: pl.handicap})
+		}
+	}
+	for _, r := range l.teamRooms {
+		rooms = append(rooms, r.listing())
+	}
+	sort.Slice(rooms, func(i, j int) bool { return rooms[i].ID < rooms[j].ID })
+	return rooms
+}
+
+// announce sends the room list to everyone still in the lobby. The caller
+// holds l.mu.
+func (l *lobby) announce() {
+	msg := netMsg{Type: "rooms", Rooms: l.rooms(), Players: len(l.players)}
+	for _, pl := range l.players {
+		if !pl.busy() {
+			pl.send(msg)
+		}
+	}
+}
+
+// pair starts a match between a and b. The caller holds l.mu.
+func (l *lobby) pair(a, b *lobbyPlayer) *lobbyMatch {
+	m := &lobbyMatch{players: [2]*lobbyPlayer{a, b}}
+	l.leaveRoom(a)
+	l.leaveRoom(b)
+	a.match, b.match = m, m
+	a.state, b.state = "", ""
+	ranked := rated(a, b)
+	a.send(netMsg{Type: "matched", Name: b.name, Rating: b.rating.Rating, Handicap: &b.handicap, Rated: ranked})
+	b.send(netMsg{Type: "matched", Name: a.name, Rating: a.rating.Rating, Handicap: &a.handicap, Rated: ranked})
+	logger.Info("lobby match", "a", a.name, "b", b.name)
+	l.announce()
+	return m
+}
+
+// lose records pl as the loser of their match, unless it was already
+// decided. The caller holds l.mu.
+func (l *lobby) lose(pl *lobbyPlayer) {
+	m := pl.match
+	if m.loser != nil {
+		return
+	}
+	m.loser = pl
+	l.rate([]*lobbyPlayer{m.other(pl)}, []*lobbyPlayer{pl})
+	if m.slot != nil {
+		m.slot.winner = m.other(pl)
+		l.advance()
+	}
+}
+
+// matchQueued pairs queued players whose ratings are close enough, the
+// longest waiting first. The caller holds l.mu.
+func (l *lobby) matchQueued(now time.Time) {
+	var queue []*lobbyPlayer
+	for _, pl := range l.players {
+		if pl.state == "queued" {
+			queue = append(queue, pl)
+		}
+	}
+	sort.Slice(queue, func(i, j int) bool { return queue[i].since.Before(queue[j].since) })
+	for i, a := range queue {
+		if a.state != "queued" {
+			continue
+		}
+		window := matchWindow + matchWiden*int(now.Sub(a.since).Seconds())
+		var best *lobbyPlayer
+		for _, b := range queue[i+1:] {
+			gap := abs(a.rating.Rating - b.rating.Rating)
+			if b.state == "queued" && gap <= window && (best == nil || gap < abs(a.rating.Rating-best.rating.Rating)) {
+				best = b
+			}
+		}
+		if best != nil {
+			l.pair(a, best)
+		}
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func (l *lobby) handle(conn net.Conn) {
+	p := newPeer(conn)
+	hello, err := p.recv()
+	if err == nil && hello.Type == "resume" && p.checkVersion(hello) && l.resume(p, hello.Token) {
+		// The player's handler carries on with the connection
+		return
+	}
+	defer p.close()
+	if err != nil || hello.Type != "hello" || !p.checkVersion(hello) {
+		return
+	}
+	name := cleanName(hello.Name)
+	if name == "" {
+		name = conn.RemoteAddr().String()
+	}
+
+	l.mu.Lock()
+	l.nextID++
+	pl := &lobbyPlayer{id: l.nextID, p: p, name: name, token: newToken(), resumed: make(chan *peer),
+		out: make(chan netMsg, outboxSize), gone: make(chan struct{})}
+	go pl.deliver()
+	// The rating in hello is the client's say-so; the lobby keeps its own
+	l.signIn(pl, hello.Account)
+	if hello.Handicap != nil {
+		pl.handicap = *hello.Handicap
+	}
+	p.token = pl.token
+	p.reconnect = pl.awaitResume
+	pl.send(netMsg{Type: "welcome", Token: pl.token, Ladder: &pl.rating})
+	go p.heartbeat()
+	l.players[pl.id] = pl
+	l.announce()
+	if l.tourney != nil {
+		pl.send(netMsg{Type: "bracket", Bracket: l.tourney.view()})
+	}
+	l.mu.Unlock()
+	logger.Info("lobby join", "name", name, "rating", pl.rating.Rating, "account", pl.account)
+
+	defer func() {
+		l.mu.Lock()
+		delete(l.players, pl.id)
+		if m := pl.match; m != nil && m.loser == nil {
+			if opp := m.other(pl); opp.match == m {
+				// Leaving mid-match forfeits it
+				opp.send(netMsg{Type: "lost"})
+			}
+			l.lose(pl)
+		}
+		if pl.teamMatch != nil {
+			l.teamOut(pl)
+		}
+		l.leaveRoom(pl)
+		l.withdraw(pl)
+		l.advance()
+		l.announce()
+		l.mu.Unlock()
+		close(pl.gone)
+		logger.Info("lobby leave", "name", name)
+	}()
+
+	for {
+		msg, err := p.recv()
+		if err != nil {
+			if l.reconnect(pl) {
+				continue
+			}
+			return
+		}
+		l.mu.Lock()
+		if tm := pl.teamMatch; tm != nil {
+			if msg.Type == "leave" {
+				// Leaving before the end concedes, as in versus
+				l.teamOut(pl)
+				pl.teamMatch = nil
+				pl.send(netMsg{Type: "left"})
+				pl.send(netMsg{Type: "rooms", Rooms: l.rooms(), Players: len(l.players), Ladder: &pl.rating})
+				l.advance()
+			} else {
+				l.relayTeam(pl, msg)
+			}
+			l.mu.Unlock()
+			continue
+		}
+		if m := pl.match; m != nil {
+			switch msg.Type {
+			case "leave":
+				pl.match = nil
+				pl.send(netMsg{Type: "left"})
+				pl.send(netMsg{Type: "rooms", Rooms: l.rooms(), Players: len(l.players), Ladder: &pl.rating})
+				if l.tourney != nil {
+					pl.send(netMsg{Type: "bracket", Bracket: l.tourney.view()})
+				}
+				l.advance()
+				l.mu.Unlock()
+				continue
+			case "lost":
+				l.lose(pl)
+			}
+			// Relay everything else to the opponent while they're still on
+			// the match screen
+			opp := m.other(pl)
+			live := opp.match == m
+			l.mu.Unlock()
+			if msg.At != 0 {
+				// Onto our clock; the opponent moves it onto theirs
+				msg.At = stamp(p.localTime(msg.At))
+			}
+			if live {
+				opp.send(msg)
+			}
+			continue
+		}
+		switch msg.Type {
+		case "host":
+			l.leaveRoom(pl)
+			pl.state = "hosting"
+			l.announce()
+		case "host_team":
+			l.hostTeam(pl)
+		case "switch":
+			l.switchSides(pl)
+		case "queue":
+			l.leaveRoom(pl)
+			pl.state = "queued"
+			pl.since = time.Now()
+			l.matchQueued(pl.since)
+		case "cancel":
+			l.leaveRoom(pl)
+			pl.state = ""
+			l.announce()
+		case "chat":
+			if text := cleanChat(msg.Text); text == "" {
+				break
+			} else if !pl.chat.allow(time.Now()) {
+				pl.send(netMsg{Type: "error", Text: floodNotice})
+			} else {
+				for _, other := range l.players {
+					if !other.busy() {
+						other.send(netMsg{Type: "chat", Name: pl.name, Text: text})
+					}
+				}
+			}
+		case "handicap":
+			if msg.Handicap != nil {
+				pl.handicap = *msg.Handicap
+				l.announce()
+			}
+		case "enter":
+			l.enter(pl)
+		case "join":
+			if host := l.players[msg.Room]; host != nil && host != pl && host.state == "hosting" {
+				l.pair(host, pl)
+			} else if r := l.teamRoom(msg.Room); r != nil && r != pl.room {
+				l.joinTeam(pl, r)
+			} else {
+				pl.send(netMsg{Type: "error", Text: "that room is gone"})
+			}
+		}
+		l.mu.Unlock()
+	}
+}
+
+// resume hands p to the player dropped mid-match with token, and reports
+// whether they were still waiting for it.
+func (l *lobby) resume(p *peer, token string) bool {
+	l.mu.Lock()
+	var pl *lobbyPlayer
+	for _, other := range l.players {
+		if other.token == token {
+			pl = other
+		}
+	}
+	l.mu.Unlock()
+	if pl != nil {
+		select {
+		case pl.resumed <- p:
+			return true
+		default:
+		}
+	}
+	p.send(netMsg{Type: "error", Text: "that match is over"})
+	return false
+}
+
+// awaitResume is the reconnect for a lobby player: wait until the deadline
+// for them to resume. "resumed" goes out before anything else can.
+func (pl *lobbyPlayer) awaitResume(token string, deadline time.Time) (*peer, error) {
+	select {
+	case p := <-pl.resumed:
+		if err := p.send(netMsg{Type: "resumed"}); err != nil {
+			p.close()
+			return nil, err
+		}
+		return p, nil
+	case <-time.After(time.Until(deadline)):
+		return nil, errors.New("didn't reconnect in time")
+	}
+}
+
+// reconnect waits for pl to resume a match their connection dropped out
+// of, telling the others in it meanwhile. It reports whether pl is back.
+func (l *lobby) reconnect(pl *lobbyPlayer) bool {
+	l.mu.Lock()
+	busy := pl.busy()
+	if busy {
+		l.tellOthers(pl, netMsg{Type: "reconnecting", Name: pl.name})
+	}
+	l.mu.Unlock()
+	if !busy {
+		return false
+	}
+	logger.Info("lobby reconnecting", "name", pl.name)
+	if err := pl.p.resume(); err != nil {
+		return false
+	}
+	l.mu.Lock()
+	l.tellOthers(pl, netMsg{Type: "resumed", Name: pl.name})
+	l.mu.Unlock()
+	logger.Info("lobby resumed", "name", pl.name)
+	return true
+}
+
+// tellOthers sends msg to everyone else still in pl's match. The caller
+// holds l.mu.
+func (l *lobby) tellOthers(pl *lobbyPlayer, msg netMsg) {
+	if m := pl.match; m != nil {
+		if opp := m.other(pl); opp.match == m {
+			opp.send(msg)
+		}
+	}
+	if tm := pl.teamMatch; tm != nil {
+		for _, side := range tm.sides {
+			for _, other := range side {
+				if other != pl && other.teamMatch == tm {
+					other.send(msg)
+				}
+			}
+		}
+	}
+}
+
+// serveLobby accepts players until the listener fails. A bracket size
+// other than zero runs a tournament of that many players. Players sign in
+// with the accounts in the accounts file, accounts.jsonl in the data
+// directory unless set.
+func serveLobby(addr string, bracket int, accounts string) error {
+	if bracket != 0 && !slices.Contains(bracketSizes, bracket) {
+		return fmt.Errorf("bracket size must be one of %v", bracketSizes)
+	}
+	if accounts == "" {
+		dir, err := dataDir()
+		if err != nil {
+			return err
+		}
+		accounts = filepath.Join(dir, "accounts.jsonl")
+	}
+	store, err := openAccountStore(accounts)
+	if err != nil {
+		return fmt.Errorf("loading accounts: %w", err)
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	l := &lobby{players: make(map[int]*lobbyPlayer), accounts: store}
+	if bracket != 0 {
+		l.tourney = &tournament{size: bracket}
+	}
+	go func() {
+		// Widen the queue's rating windows as players wait
+		for now := range time.Tick(time.Second) {
+			l.mu.Lock()
+			l.matchQueued(now)
+			l.mu.Unlock()
+		}
+	}()
+	fmt.Printf("Serving the versus lobby on %s\n", ln.Addr())
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		go l.handle(conn)
+	}
+}
+
+func addLobbyFlags(fs *flag.FlagSet, addr *string, bracket *int, accounts *string) {
+	fs.StringVar(addr, "addr", ":4001", "Address to serve the lobby on")
+	fs.IntVar(bracket, "bracket", 0, "Run a tournament bracket of 4, 8 or 16 players")
+	fs.StringVar(accounts, "accounts", "", "The leaderboard server's accounts file, which keeps players' ratings (default: accounts.jsonl in the data directory)")
+}
+
+// Client
+
+type lobbyModel struct {
+	p       *peer
+	styles  *styles
+	rating  rating
+	rooms   []lobbyRoom
+	players int
+	cursor  int
+	// handicap is ours, changed with +
+	handicap handicap
+	// state mirrors what the lobby has us waiting for
+	state   string
+	since   time.Time
+	notice  string
+	chat    chatBox
+	bracket *bracketView
+	// showBracket switches to the bracket viewer
+	showBracket bool
+	matched     *netMsg
+	err         error
+}
+
+type lobbyMsg netMsg
+
+type lobbyGoneMsg struct{ err error }
+
+// listenLobby feeds lobby messages to the browser until a match is made,
+// leaving the rest of the connection to the match.
+func listenLobby(p *peer, prog *tea.Program) {
+	for {
+		msg, err := p.recv()
+		if err != nil {
+			prog.Send(lobbyGoneMsg{err})
+			return
+		}
+		prog.Send(lobbyMsg(msg))
+		if msg.Type == "matched" {
+			return
+		}
+	}
+}
+
+func (m lobbyModel) Init() tea.Cmd {
+	return tickCmd(tickRate)
+}
+
+func (m lobbyModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		m.notice = ""
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+		if m.chat.typing {
+			var text string
+			if m.chat, text = m.chat.key(msg); text != "" {
+				return m, sendCmd(m.p, netMsg{Type: "chat", Text: text})
+			}
+			return m, nil
+		}
+		switch msg.String() {
+		case "q":
+			return m, tea.Quit
+		case "tab":
+			m.chat.typing = true
+		case "up", "k":
+			m.cursor = max(0, m.cursor-1)
+		case "down", "j":
+			m.cursor = max(0, min(len(m.rooms)-1, m.cursor+1))
+		case "enter":
+			if m.cursor < len(m.rooms) && m.rooms[m.cursor].Teams != nil {
+				// Joining a 2v2 room leaves whatever we were waiting for
+				m.state = "team"
+				return m, sendCmd(m.p, netMsg{Type: "join", Room: m.rooms[m.cursor].ID})
+			}
+			if m.state == "" && m.cursor < len(m.rooms) {
+				return m, sendCmd(m.p, netMsg{Type: "join", Room: m.rooms[m.cursor].ID})
+			}
+		case "t":
+			return m, sendCmd(m.p, netMsg{Type: "enter"})
+		case "b":
+			m.showBracket = !m.showBracket
+		case "+":
+			m.handicap = m.handicap.next()
+			h := m.handicap
+			return m, sendCmd(m.p, netMsg{Type: "handicap", Handicap: &h})
+		case "h":
+			return m.wait("hosting")
+		case "2":
+			return m.wait("team")
+		case "s":
+			if m.state == "team" {
+				return m, sendCmd(m.p, netMsg{Type: "switch"})
+			}
+		case "m":
+			return m.wait("queued")
+		case "esc":
+			if m.state != "" {
+				m.state = ""
+				return m, sendCmd(m.p, netMsg{Type: "cancel"})
+			}
+		}
+	case lobbyMsg:
+		switch msg.Type {
+		case "rooms":
+			m.rooms = msg.Rooms
+			m.players = msg.Players
+			if msg.Ladder != nil {
+				// Back from a match the lobby may have rated
+				m.rating = *msg.Ladder
+			}
+			m.cursor = max(0, min(m.cursor, len(m.rooms)-1))
+		case "matched":
+			matched := netMsg(msg)
+			m.matched = &matched
+			return m, tea.Quit
+		case "chat":
+			m.chat = m.chat.add(msg.Name, msg.Text)
+		case "announce":
+			m.chat = m.chat.add("", msg.Text)
+		case "bracket":
+			m.bracket = msg.Bracket
+		case "error":
+			m.notice = msg.Text
+		}
+	case lobbyGoneMsg:
+		m.err = msg.err
+		return m, tea.Quit
+	case tickMsg:
+		// Keep the queue timer moving
+		return m, tickCmd(tickRate)
+	}
+	return m, nil
+}
+
+// wait hosts a room or joins the queue, cancelling whichever we were in.
+func (m lobbyModel) wait(state string) (tea.Model, tea.Cmd) {
+	if m.state == state {
+		return m, nil
+	}
+	m.state = state
+	m.since = time.Now()
+	switch state {
+	case "hosting":
+		return m, sendCmd(m.p, netMsg{Type: "host"})
+	case "team":
+		return m, sendCmd(m.p, netMsg{Type: "host_team"})
+	}
+	return m, sendCmd(m.p, netMsg{Type: "queue"})
+}
+
+func (m lobbyModel) View() string {
+	if m.showBracket {
+		return m.renderBracket()
+	}
+	var b strings.Builder
+	b.WriteString("\n" + m.styles.title.Render("VERSUS LOBBY") + "\n\n")
+	b.WriteString(m.styles.stats.Render(fmt.Sprintf("Players online: %d   Your rating: %d   Your handicap: %s   Ping: %s", m.players, m.rating.Rating, m.handicap.describe(), pingText(int(m.p.latency().Milliseconds())))) + "\n\n")
+	if len(m.rooms) == 0 {
+		b.WriteString(m.styles.stats.Render("No open rooms") + "\n")
+	}
+	for i, r := range m.rooms {
+		line := fmt.Sprintf("  %-20s rating %d", r.Name, r.Rating)
+		if r.Teams != nil {
+			line = fmt.Sprintf("  %-20s rating %d  %s: %s  %s: %s", "2v2", r.Rating,
+				teamNames[0], strings.Join(r.Teams[0], ", "), teamNames[1], strings.Join(r.Teams[1], ", "))
+		} else if r.Handicap != (handicap{}) {
+			line += "  handicap " + r.Handicap.describe()
+		}
+		if i == m.cursor {
+			b.WriteString(m.styles.highlight.Render("> "+line[2:]) + "\n")
+		} else {
+			b.WriteString(m.styles.word.Render(line) + "\n")
+		}
+	}
+	b.WriteString("\n")
+	switch m.state {
+	case "hosting":
+		b.WriteString(m.styles.pause.Render("Hosting a room - waiting for an opponent") + "\n")
+	case "team":
+		b.WriteString(m.styles.pause.Render("Waiting in a 2v2 room for four players - s switches sides") + "\n")
+	case "queued":
+		wait := time.Since(m.since).Round(time.Second)
+		b.WriteString(m.styles.pause.Render(fmt.Sprintf("Searching for an opponent near rating %d (%v)", m.rating.Rating, wait)) + "\n")
+	}
+	if m.notice != "" {
+		b.WriteString(m.styles.stats.Render(m.notice) + "\n")
+	}
+	b.WriteString("\n" + m.styles.title.Render("CHAT") + "\n")
+	b.WriteString(strings.Join(m.chat.render(m.styles), "\n") + "\n")
+	b.WriteString("\n" + m.styles.help.Render("[ENTER: join | h: host | 2: host 2v2 | m: matchmaking | ESC: cancel | +: handicap | t: tournament | b: bracket | TAB: chat | q: quit]"))
+	return b.String()
+}
+
+// joinLobby introduces the player to the lobby on p, signing in with the
+// account token if there is one.
+func joinLobby(p *peer, name string, h handicap, token string) (lobbyModel, error) {
+	if err := p.send(netMsg{Type: "hello", Version: protocolVersion, Name: name, Account: token, Handicap: &h}); err != nil {
+		return lobbyModel{}, err
+	}
+	welcome, err := p.recv()
+	if err != nil {
+		return lobbyModel{}, fmt.Errorf("joining the lobby: %w", err)
+	}
+	if welcome.Type == "error" {
+		return lobbyModel{}, errors.New(welcome.Text)
+	}
+	p.token = welcome.Token
+	r := rating{Rating: startRating}
+	if welcome.Ladder != nil {
+		r = *welcome.Ladder
+	}
+	return lobbyModel{p: p, styles: newStyles(lipgloss.DefaultRenderer()), rating: r, handicap: h}, nil
+}
+
+// browseLobby runs the lobby browser until the player is matched or
+// leaves; a match is reported in the returned model's matched field.
+func browseLobby(m lobbyModel) (lobbyModel, error) {
+	m.matched = nil
+	m.state = ""
+	prog := tea.NewProgram(m, tea.WithAltScreen())
+	go listenLobby(m.p, prog)
+	final, err := prog.Run()
+	if err != nil {
+		return m, err
+	}
+	m = final.(lobbyModel)
+	if m.err != nil {
+		return m, errors.New("lost connection to the lobby")
+	}
+	if m.matched != nil {
+		fmt.Printf("Matched with %s (rating %d)\n", m.matched.Name, m.matched.Rating)
+	}
+	return m, nil
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+)
+
+// logger records game events. It discards everything unless --log is given.
+var logger = slog.New(slog.DiscardHandler)
+
+// openLog directs game event logs to a JSON file at path. The returned
+// function closes the file.
+func openLog(path string) (func() error, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	logger = slog.New(slog.NewJSONHandler(file, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	return file.Close, nil
+}
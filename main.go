@@ -1,9 +1,10 @@
 package main
 
 import (
-	"bufio"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"math"
 	"math/rand"
 	"os"
@@ -25,65 +26,333 @@ type word struct {
 	text    string
 	x, y    int
 	matched int
+	// garbage marks words sent by a versus opponent
+	garbage bool
+	// owner is the co-op player the word belongs to
+	owner int
+	// category is the word's dictionary tag, see dictionary.category,
+	// and chat marks a word thrown in from stream chat
+	category int
+	chat     bool
+	// spawned is the game time the word appeared at
+	spawned time.Duration
+	// hinted lights the word's next letter, see hint
+	hinted bool
+	// event marks a word from the season's pack
+	event bool
 }
 
 type particle struct {
 	x, y     float64
 	vx, vy   float64
 	char     rune
-	lifetime int
+	lifetime time.Duration
+	// life is the lifetime it started with and color its fade ramp, see
+	// kind
+	life  time.Duration
+	color uint8
 }
 
 type effect struct {
 	particles []particle
 }
 
+// kind is how the word's i'th letter is styled; active is set when the
+// word is player two's current target.
+func (w word) kind(i int, active bool) cellKind {
+	switch {
+	case w.garbage:
+		return cellGarbage
+	case w.owner == 1 && active && i < w.matched:
+		return cellPartnerMatched
+	case w.owner == 1:
+		return cellPartner
+	case w.chat:
+		return cellChat
+	case w.event:
+		return cellEvent
+	case w.category > 0:
+		return cellCategory + cellKind((w.category-1)%len(categoryColors))
+	}
+	return w.urgency()
+}
+
+// urgencySteps is how many shades a plain word goes through on the way
+// down.
+const urgencySteps = 5
+
+// urgency shades a plain word by how far down the screen it has come, so
+// the words to deal with first stand out at a glance.
+func (w word) urgency() cellKind {
+	step := min(max(w.y, 0)*urgencySteps/gameHeight, urgencySteps-1)
+	if step == 0 {
+		return cellWord
+	}
+	return cellUrgent + cellKind(step-1)
+}
+
 type model struct {
 	words      []word
 	effects    []effect
+	popups     []popup
+	banner     banner
 	score      int
 	level      int
 	lives      int
 	wordsTyped int
-	dict       []string
-	current    *word
-	input      string
-	gameOver   bool
-	paused     bool
-	startTime  time.Time
-	width      int
-	height     int
+	// combo counts kills in a row without a typo or a miss
+	combo int
+	// slipped is set by a typo since the last kill
+	slipped   bool
+	scoring   scoring
+	extraLife extraLife
+	// lifeFlash counts down the ticks the lives widget blinks for after
+	// a life is lost, or gained when lifeGained is set
+	lifeFlash  int
+	lifeGained bool
+	// hud names the status line's widgets, in order
+	hud []string
+	// bags deal each player's words, see bag; recent holds the last
+	// words dealt, which aren't dealt again soon
+	bags   [2]bag
+	recent []string
+	// lengths is the band of word lengths dealt at each level, and warmUp
+	// how long the game deals shorter ones to start, see warmUpBand
+	lengths wordLengths
+	warmUp  time.Duration
+	// pace sets how fast words fall and spawn; the clocks gather rows
+	// and spawn checks between steps, see pace
+	pace                  pace
+	fallClock, spawnClock float64
+	// adaptive is how strongly the pace follows the player, see flow
+	adaptive float64
+	flow     flow
+	// mutators twist the rules, see mutator, and mutatorNames are their
+	// names
+	mutators     []mutator
+	mutatorNames []string
+	// events publishes what happens in the game to the control socket;
+	// controlled marks a game whose rules were changed through it
+	events     *eventBus
+	controlled bool
+	// fixedRules is set for shared and raced games, which always level by
+	// words and deal the default lengths whatever the difficulty profile
+	fixedRules bool
+	// reload is a changed dictionary or settings file waiting for the next
+	// level up; reloaded marks a game that applied one
+	reload   *reloadMsg
+	reloaded bool
+	// requireEnter finishes words on enter rather than their last letter
+	requireEnter bool
+	// typoFlash turns the input box red for the rest of the tick after a
+	// typo threw away rejected
+	typoFlash int
+	rejected  string
+	// highScores is the local top ten when the game started; initials
+	// are the player's for a game that beats one of them
+	highScores       []session
+	initials         string
+	enteringInitials bool
+	tally            tally
+	ticks            int
+	// frameRate is how often the screen is redrawn and particles move;
+	// sinceStep is the time the frames have run since the last step, and
+	// lastFrame when the last one ran
+	frameRate time.Duration
+	sinceStep time.Duration
+	lastFrame time.Time
+	// effectsLevel scales explosions; the zero level is the default
+	effectsLevel effectsLevel
+	// symbols are what explosions and the backdrop are drawn with, see
+	// symbols.go; the zero set is ASCII
+	symbols symbolSet
+	// minimal is the distraction-free screen, see minimal.go
+	minimal bool
+	// boundary raises the row words are lost on, see boundary.go
+	boundary int
+	// graveyard shows the words that last left the playfield, fallen,
+	// beside it, see graveyard.go
+	graveyard bool
+	fallen    []fallen
+	dict      *dictionary
+	// rng drives everything that shapes play, so a game can be replayed
+	// from its seed; purely cosmetic randomness uses the global source
+	seed int64
+	rng  *rand.Rand
+	// startLevel is the level the game began at
+	startLevel int
+	// weekly is the ISO week of a weekly challenge game
+	weekly string
+	// speedrun is the target level of a speedrun, zero otherwise; splits
+	// are the run's level up times and pb the personal best's
+	speedrun int
+	splits   []time.Duration
+	pb       []time.Duration
+	// levelTicks levels up on a timer, every levelTicks ticks; zero levels
+	// up by words typed
+	levelTicks int
+	// goal ends the game with a victory once reached
+	goal    goal
+	victory bool
+	// keys logs every keystroke that reached the game, for leaderboard
+	// verification
+	keys []keystroke
+	// player and profile identify a hosted player; both are empty for the
+	// local player
+	player   string
+	profile  string
+	autosave bool
+	// classroom is the teacher's connection in a class session
+	classroom *peer
+	// peer is the versus opponent's connection; nil outside versus mode
+	peer     *peer
+	opponent opponent
+	won      bool
+	chat     chatBox
+	// rating is the player's versus rating going into this game
+	rating rating
+	// ladder is set in lobby matches, whose ratings the lobby keeps, and
+	// ladderRated when the lobby rates this one, see ladder.go
+	ladder      bool
+	ladderRated bool
+	// practice is the streak and practice goals shown on the title screen
+	practice []goalStatus
+	// handicaps are each seat's handicap; in versus only the first, this
+	// player's, is used
+	handicaps [2]handicap
+	// teams are the standings of a team match and team our side; teams is
+	// nil outside one
+	teams []teamView
+	team  int
+	// coop is set for two players on one keyboard; partner is player two
+	// and seat is whose keystroke is being handled
+	coop    bool
+	partner seat
+	seat    int
+	pools   [2]*dictionary
+	// outgoing is garbage earned but not yet sent; pendingGarbage is
+	// when each word of garbage received but not yet spawned lands, soonest
+	// first
+	outgoing       int
+	pendingGarbage []time.Time
+	styles         *styles
+	renderer       renderer
+	frame          *frame
+	current        *word
+	input          string
+	title          bool
+	gameOver       bool
+	// assisted is set once pasted or burst input is detected
+	assisted bool
+	// resumed marks a game restored from an autosave, which can't be
+	// replayed from its seed
+	resumed bool
+	// thrown holds words from live stream chat, spawned ahead of the
+	// dictionary; fed marks a game that spawned any of them
+	thrown []string
+	fed    bool
+	// season is the seasonal event the game is dressed for, as the
+	// seasonal setting allows, and evented marks a game that dealt any of
+	// its words, see seasons.go
+	season   *season
+	seasonal string
+	evented  bool
+	// narrowed marks a game dealt words for a playfield narrower than
+	// screenWidth, which replays can't reproduce
+	narrowed bool
+	// drill is the practice drill asked for at game over, played once this
+	// game has quit; drilling marks a drill game, see drill.go
+	drill    []string
+	drilling bool
+	// keyDrill restricts the words dealt to one hand or row of layout;
+	// see keyboard.go
+	keyDrill string
+	layout   string
+	// oneHand marks the one-handed preset, a key drill at a gentler pace
+	oneHand bool
+	// shift keeps the dictionary's capitals to train shift; see shift.go
+	shift  bool
+	paused bool
+	// pauseReason explains an automatic pause; empty when the player paused
+	pauseReason string
+	// rtl mirrors the screen around the playfield, see mirror.go
+	rtl bool
+	// slowMotion is the game speed in slow motion, zero at full speed;
+	// see slowmotion.go
+	slowMotion float64
+	// hints counts the hints asked for and hintPoints what they cost
+	hints      int
+	hintPoints int
+	// defScroll is how far the pause screen's definitions panel is
+	// scrolled
+	defScroll int
+	// breakEvery is how much play comes between break reminders, and
+	// breaks how many have been taken; see breaks.go
+	breakEvery time.Duration
+	breaks     int
+	// metronome is the metronome's keystrokes a minute, zero when off, and
+	// rhythm how the keystrokes have fallen against it; see metronome.go
+	metronome    int
+	metronomeCue string
+	rhythm       rhythm
+	debug        bool
+	startTime    time.Time
+	endTime      time.Time
+	lastInput    time.Time
+	// idleTimeout auto-pauses after this long without a keystroke; zero
+	// disables it
+	idleTimeout  time.Duration
+	checkUpdates bool
+	// latestVersion is set when the update check finds a newer release
+	latestVersion string
+	// spectators receives every rendered frame; nil when nobody can watch
+	spectators *broadcaster
+	// presence shows the game in Discord; nil when it is off
+	presence *presence
+	// recording streams the game to a cast file, see record.go; nil when
+	// the game isn't recorded
+	recording *recorder
+	saving    bool
+	saved     recordingSavedMsg
+	// term is the player's terminal, for escapes like clipboard copies
+	// that the renderer doesn't send
+	term   io.Writer
+	copied *copiedMsg
+	// shot is the last screenshot saved and shotAt when, see
+	// screenshot.go; screenshotANSI keeps the colors in them
+	shot           screenshotMsg
+	shotAt         time.Time
+	screenshotANSI bool
+	// dashboard is the history shown on the dashboard while it's open
+	// from the title screen
+	dashboard []session
+	// board is the leaderboard scores can be submitted to; its url is
+	// empty when none is configured
+	board  leaderboard
+	width  int
+	height int
 }
 
+// maxKeyChunk is the most runes accepted in a single key message. Humans
+// occasionally land two keystrokes in one terminal read; more than that is
+// a paste or an input script.
+const maxKeyChunk = 2
+
 type tickMsg time.Time
 
-func tickCmd() tea.Cmd {
-	return tea.Tick(1000*time.Millisecond, func(t time.Time) tea.Msg {
-		return tickMsg(t)
-	})
-}
+// tickRate is how often words fall a row.
+const tickRate = time.Second
 
-func loadDictionary(path string) ([]string, error) {
-	file, err := os.Open(path)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
+// startLives is how many lives a game starts with.
+const startLives = 3
 
-	var words []string
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		word := strings.TrimSpace(scanner.Text())
-		// Filter for reasonable word lengths (1-12 chars) for better gameplay
-		if len(word) >= 1 && len(word) <= 12 {
-			words = append(words, strings.ToLower(word))
-		}
-	}
-	return words, scanner.Err()
+func tickCmd(every time.Duration) tea.Cmd {
+	return tea.Tick(every, func(t time.Time) tea.Msg {
+		return tickMsg(t)
+	})
 }
 
-func createExplosion(x, y int, wordLen int) effect {
-	chars := []rune{'*', '+', '#', 'o', '.', '~', '^', 'x'}
+func createExplosion(x, y int, wordLen int, chars []rune) effect {
 	particles := []particle{}
 
 	// Create particles radiating outward
@@ -97,82 +366,407 @@ func createExplosion(x, y int, wordLen int) effect {
 			vx:       speed * math.Cos(angle),
 			vy:       speed * math.Sin(angle),
 			char:     chars[rand.Intn(len(chars))],
-			lifetime: 3 + rand.Intn(3),
+			lifetime: time.Duration(3+rand.Intn(3)) * tickRate,
 		})
 	}
 
 	return effect{particles: particles}
 }
 
-func initialModel(dict []string) model {
+func initialModel(dict *dictionary) model {
+	// Seeds fit in 32 bits so they fit in a challenge code
+	seed := time.Now().UnixNano() & 0xffffffff
 	return model{
-		words:     []word{},
-		effects:   []effect{},
-		score:     0,
-		level:     1,
-		lives:     3,
-		dict:      dict,
-		startTime: time.Now(),
-		width:     screenWidth,
-		height:    screenHeight,
+		seed:       seed,
+		startLevel: 1,
+		scoring:    defaultScoring,
+		extraLife:  defaultExtraLife,
+		lengths:    defaultWordLengths,
+		pace:       defaultPace,
+		hud:        defaultHUD,
+		rng:        rand.New(rand.NewSource(seed)),
+		words:      []word{},
+		effects:    []effect{},
+		score:      0,
+		level:      1,
+		title:      true,
+		styles:     newStyles(lipgloss.DefaultRenderer()),
+		renderer:   ansiRenderer{},
+		frame:      &frame{},
+		frameRate:  defaultTickMs * time.Millisecond,
+		lives:      startLives,
+		dict:       dict,
+		startTime:  time.Now(),
+		lastInput:  time.Now(),
+		width:      screenWidth,
+		height:     screenHeight,
+		term:       os.Stdout,
+	}
+}
+
+// elapsed is the game time so far, frozen once the game is over.
+func (m model) elapsed() time.Duration {
+	if m.gameOver {
+		return m.endTime.Sub(m.startTime)
 	}
+	return time.Since(m.startTime)
+}
+
+// running reports whether words are currently falling.
+func (m model) running() bool {
+	return !m.title && !m.paused && !m.gameOver
+}
+
+// idle reports whether words are falling with no keystroke for idleTimeout.
+func (m model) idle() bool {
+	return m.idleTimeout > 0 && len(m.words) > 0 && time.Since(m.lastInput) >= m.idleTimeout
 }
 
 func (m model) Init() tea.Cmd {
-	return tickCmd()
+	cmds := []tea.Cmd{tickCmd(m.frameRate)}
+	if m.checkUpdates {
+		cmds = append(cmds, checkUpdateCmd())
+	}
+	if m.metronome > 0 {
+		cmds = append(cmds, m.beatCmd())
+	}
+	return tea.Batch(cmds...)
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		key := msg.String()
+		now := time.Now()
+		m.lastInput = now
+		if m.gameOver && m.peer != nil && !m.board.open && key != "ctrl+c" {
+			// Post-match chat with the opponent
+			if m.chat.typing {
+				var text string
+				if m.chat, text = m.chat.key(msg); text != "" {
+					m.chat = m.chat.add("you", text)
+					return m, sendCmd(m.peer, netMsg{Type: "chat", Text: text})
+				}
+				return m, nil
+			}
+			if key == "tab" {
+				m.chat.typing = true
+				return m, nil
+			}
+		}
+		if m.enteringInitials && key != "ctrl+c" {
+			return m.typeInitial(msg), nil
+		}
+		if key == screenshotKey && m.screenshotable() {
+			return m.screenshot()
+		}
 		if m.gameOver {
-			if msg.String() == "q" || msg.String() == "ctrl+c" {
+			if key == "q" || key == "ctrl+c" {
+				logger.Info("quit")
+				return m, tea.Quit
+			}
+			if key == "c" && !m.board.open {
+				return m, copyCmd(m.term, m.shareCard())
+			}
+			if key == "d" && !m.board.open && m.drillable() {
+				m.drill = m.drillWords()
+				return m, tea.Quit
+			}
+			if key == "r" && m.giffable() && !m.board.open && !m.saving {
+				m.saving = true
+				return m, saveGIFCmd(m.recording)
+			}
+			if m.board.url != "" {
+				return m.updateBoard(key)
+			}
+			return m, nil
+		}
+		if m.title {
+			if key == "q" || key == "ctrl+c" {
 				return m, tea.Quit
 			}
+			if m.dashboard != nil {
+				m.dashboard = nil
+				return m, nil
+			}
+			if key == "tab" {
+				return m.openDashboard(), nil
+			}
+			// Any other key starts the game
+			m.title = false
+			m.startTime = time.Now()
+			gameStarted()
+			logger.Info("game start")
 			return m, nil
 		}
 
-		switch msg.String() {
+		switch key {
 		case "ctrl+c":
+			logger.Info("quit", "score", m.score, "level", m.level)
 			return m, tea.Quit
 		case "ctrl+l":
 			return m, tea.ClearScreen
+		case "ctrl+z":
+			// The terminal is in raw mode, so ctrl+z arrives as a key rather
+			// than SIGTSTP. Pause first so no lives drain while stopped.
+			m.paused = true
+			logger.Info("suspend")
+			return m, tea.Suspend
+		case "f3":
+			m.debug = !m.debug
+			return m, nil
+		case "?":
+			if m.hintable() {
+				return m.hint(), nil
+			}
+			return m, nil
 		case " ":
+			if m.peer != nil {
+				// No pausing a live match
+				return m, nil
+			}
 			// Space bar pauses - can't conflict with typing words
 			m.paused = !m.paused
+			m.pauseReason = ""
+			m.defScroll = 0
+			logger.Info("pause", "paused", m.paused)
 			return m, nil
-		case "backspace":
-			if len(m.input) > 0 {
-				m.input = m.input[:len(m.input)-1]
+		case "up", "down":
+			if m.paused {
+				delta := 1
+				if key == "up" {
+					delta = -1
+				}
+				return m.scrollDefinitions(delta), nil
 			}
-			m.current = nil
 			return m, nil
+		case "backspace":
+			m = m.logKey('\b', now)
+			return m.backspace(), nil
+		case "enter":
+			if !m.requireEnter {
+				return m, nil
+			}
+			m = m.logKey('\n', now)
+			return m.commit(), nil
 		default:
-			// Handle letter input (including 'p')
-			if len(msg.String()) == 1 && msg.String() >= "a" && msg.String() <= "z" {
-				m.input += msg.String()
-				m = m.matchWord()
+			if msg.Paste || len(msg.Runes) > maxKeyChunk {
+				// Pasted or machine-fed input: refuse it and mark the run
+				m.assisted = true
+				logger.Warn("input rejected", "paste", msg.Paste, "runes", len(msg.Runes))
 				return m, nil
 			}
+			// Handle letter input (including 'p'); a slow link may batch a
+			// couple of real keystrokes into one message
+			for _, r := range msg.Runes {
+				if (r >= 'a' && r <= 'z' || m.shift && r >= 'A' && r <= 'Z') && !msg.Alt {
+					m = m.logKey(byte(r), now)
+					m = m.keyOnBeat(now)
+					m = m.typeLetter(r)
+				}
+			}
+			if m.outgoing > 0 {
+				var n int
+				if m, n = m.flushGarbage(); n > 0 {
+					logger.Info("garbage sent", "count", n)
+					return m, sendCmd(m.peer, netMsg{Type: "garbage", Count: n, At: stamp(time.Now())})
+				}
+			}
+			return m, nil
 		}
 
 	case tickMsg:
-		if !m.paused && !m.gameOver {
-			m = m.moveWords()
-			m = m.updateEffects()
-			m = m.maybeAddWord()
+		m, cmd := m.onTick(time.Time(msg))
+		if m.recording != nil && !m.title {
+			m.recording.record(m.renderer.finish(m.render()), time.Time(msg), m.width, m.height)
+		}
+		return m, cmd
+
+	case beatMsg:
+		return m.onBeatMsg()
+
+	case opponentMsg:
+		return m.handleOpponent(msg)
+
+	case opponentGoneMsg:
+		m.opponent.gone = true
+		logger.Warn("opponent gone", "err", msg.err)
+		if !m.gameOver {
+			// Leaving mid-match forfeits it
+			m.won = true
+			m = m.endGame()
+		}
+		return m, nil
+
+	case boardRankMsg:
+		m.board.submitting = false
+		m.board.err = msg.err
+		if msg.err == nil {
+			m.board.rank = &msg.rank
+		} else {
+			logger.Warn("leaderboard submit", "err", msg.err)
+		}
+		return m, nil
+
+	case replayUploadMsg:
+		m.board.uploading = false
+		m.board.replayErr = msg.err
+		if msg.err == nil {
+			m.board.replay = &msg.replay
+		} else {
+			logger.Warn("replay upload", "err", msg.err)
+		}
+		return m, nil
+
+	case boardPageMsg:
+		m.board.err = msg.err
+		if msg.err == nil {
+			m.board.page = msg.page
+		}
+		return m, nil
+
+	case copiedMsg:
+		m.copied = &msg
+		return m, nil
+
+	case recordingSavedMsg:
+		m.saving = false
+		m.saved = msg
+		return m, nil
+
+	case screenshotMsg:
+		m.shot, m.shotAt = msg, time.Now()
+		return m, nil
+
+	case thrownMsg:
+		return m.throw(msg), nil
+
+	case reloadMsg:
+		return m.queueReload(msg), nil
+
+	case controlMsg:
+		var reply controlReply
+		m, reply = m.control(msg)
+		msg.reply <- reply
+		return m, nil
+
+	case updateAvailableMsg:
+		m.latestVersion = string(msg)
+		return m, nil
+
+	case tea.BlurMsg:
+		// Alt-tabbing away shouldn't cost lives
+		if m.running() && m.peer == nil {
+			m.paused = true
+			m.pauseReason = "focus lost"
+			logger.Info("focus lost")
 		}
-		return m, tickCmd()
+		return m, nil
+
+	case tea.ResumeMsg:
+		// Back from ctrl+z: the shell may have drawn over the alt screen
+		logger.Info("resume from suspend")
+		return m, tea.ClearScreen
 
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
-		return m, nil
+		return m.clampWords(), nil
 	}
 
 	return m, nil
 }
 
+// onTick advances the game to the frame at now and schedules the next.
+func (m model) onTick(now time.Time) (model, tea.Cmd) {
+	if m.running() && m.idle() {
+		// Stepped away - don't let the words bleed lives
+		m.paused = true
+		m.pauseReason = "idle"
+		logger.Info("idle pause")
+	}
+	if m.running() && m.breakDue() {
+		m = m.takeBreak()
+	}
+	if !m.running() {
+		// Time paused doesn't count towards the next step
+		m.lastFrame = time.Time{}
+	}
+	if m.running() {
+		var stepped bool
+		if m, stepped = m.advance(now); !stepped {
+			return m, tickCmd(m.frameRate)
+		}
+		if m.peer != nil {
+			msg := m.stateMsg()
+			if m.gameOver {
+				msg.Type = "lost"
+			}
+			return m, tea.Batch(tickCmd(m.frameRate), sendCmd(m.peer, msg))
+		}
+		if m.classroom != nil {
+			return m, tea.Batch(tickCmd(m.frameRate), sendCmd(m.classroom, m.progressMsg()))
+		}
+		if m.autosave && m.ticks%autosaveEvery == 0 && !m.gameOver {
+			return m, tea.Batch(tickCmd(m.frameRate), autosaveCmd(m.snapshot()))
+		}
+	}
+	return m, tickCmd(m.frameRate)
+}
+
+// step advances the game by one tick.
+func (m model) step() model {
+	m.lifeFlash = max(0, m.lifeFlash-1)
+	m.typoFlash = max(0, m.typoFlash-1)
+	m.banner.life = max(0, m.banner.life-1)
+	m = m.adapt()
+	m = m.fall()
+	m = m.updatePopups()
+	m = m.spawn()
+	m = m.spawnGarbage()
+	m.ticks++
+	if m.levelTicks > 0 && m.ticks%m.levelTicks == 0 {
+		m = m.levelUp()
+	}
+	if m.ticks%timelineEvery == 0 {
+		m.tally.Timeline = append(m.tally.Timeline, wpm(m.wordsTyped, m.elapsed()))
+	}
+	return m
+}
+
+// backspace drops the last typed letter and the current target.
+func (m model) backspace() model {
+	if len(m.input) > 0 {
+		m.input = m.input[:len(m.input)-1]
+	}
+	m.current = nil
+	if m.coop {
+		// Backspace can't tell the players apart, so it clears both
+		m.input = ""
+		m.partner.input = ""
+		m.partner.current = nil
+	}
+	return m
+}
+
+// typeLetter adds r to the typing player's input and matches it.
+func (m model) typeLetter(r rune) model {
+	if m.coop && seatFor(r) == 1 {
+		return m.asPartner(func(m model) model {
+			if m.lives <= 0 {
+				return m
+			}
+			m.input += string(r)
+			return m.matchWord()
+		})
+	}
+	if m.coop && m.lives <= 0 {
+		return m
+	}
+	m.input += string(r)
+	return m.matchWord()
+}
+
 func (m model) matchWord() model {
 	if len(m.input) == 0 {
 		m.current = nil
@@ -180,40 +774,98 @@ func (m model) matchWord() model {
 	}
 
 	// Try to find a word that matches the input
-	for i := range m.words {
+	typed := m.input[len(m.input)-1]
+	if i := m.target(); i >= 0 {
 		w := &m.words[i]
-		if strings.HasPrefix(w.text, m.input) {
-			m.current = w
-			w.matched = len(m.input)
+		m.tally.record(typed, true)
+		m.emit("key", func(e *gameEvent) { e.Key, e.Hit = string(typed), true })
+		if m.current != nil && m.current != w {
+			m.current.matched = 0
+		}
+		m.current = w
+		w.matched = len(m.input)
+
+		// Check if word is complete
+		if m.input == w.text && !m.requireEnter {
+			m = m.kill(i)
+		}
+		return m
+	}
 
-			// Check if word is complete
-			if m.input == w.text {
-				m.score += len(w.text) * (m.level + 1)
-				m.wordsTyped++
+	// No match found - reset
+	m.tally.record(typed, false)
+	if m.shift && m.shiftSlip() {
+		m.tally.ShiftErrors++
+	}
+	m.emit("key", func(e *gameEvent) { e.Key = string(typed) })
+	return m.mismatch()
+}
 
-				// Create explosion effect at word position
-				m.effects = append(m.effects, createExplosion(w.x, w.y, len(w.text)))
+// kill destroys the i'th word, which the input has just completed.
+func (m model) kill(i int) model {
+	w := &m.words[i]
+	m.wordsTyped++
+	m = m.bury(w.text, false)
+	m.tally.killed(w.text, m.gameTime()-w.spawned)
+	m.combo++
+	points := m.mutatePoints(*w, m.scoring.points(w.text, w.y, m.level, m.combo, !m.slipped))
+	m.score += points
+	m = m.awardLives(m.score - points)
+	m.slipped = false
+	m = m.addPopup(*w, points, m.scoring.heightBonus(w.text, w.y))
+	if m.peer != nil {
+		m.outgoing += m.garbageEarned(w.text)
+	}
+	metrics.wordsTyped.Add(1)
+	logger.Info("kill", "word", w.text, "y", w.y, "score", m.score)
+	m.emit("kill", func(e *gameEvent) { e.Word, e.Points, e.Combo = w.text, points, m.combo })
+	if m.combo >= 2 {
+		m.emit("combo", func(e *gameEvent) { e.Combo = m.combo })
+		m = m.noteCombo()
+	}
 
-				m.words = append(m.words[:i], m.words[i+1:]...)
-				m.input = ""
-				m.current = nil
+	// Create explosion effect at word position
+	m = m.addEffect(createExplosion(w.x, w.y, m.shownWidth(w.text), m.sparks()))
 
-				// Level up every 15 words
-				if m.wordsTyped%15 == 0 {
-					m.level++
-				}
-			}
-			return m
-		}
+	m.words = append(m.words[:i], m.words[i+1:]...)
+	m.input = ""
+	m.current = nil
+
+	// Level up every 15 words, counting both players in co-op
+	if m.levelTicks == 0 && (m.wordsTyped+m.partner.words)%wordsPerLevel == 0 {
+		m = m.levelUp()
 	}
+	return m.checkGoal()
+}
 
-	// No match found - reset
+// mismatch throws away input that matches no word.
+func (m model) mismatch() model {
+	m.combo = 0
+	m.slipped = true
+	logger.Debug("mismatch", "input", m.input)
+	m = m.typoMissed()
 	m.input = ""
 	m.current = nil
 	return m
 }
 
-func (m model) updateEffects() model {
+// commit handles enter with require-enter: it kills the word the input
+// spells out, or counts a typo if the input isn't a whole word.
+func (m model) commit() model {
+	if m.input == "" {
+		return m
+	}
+	for i, w := range m.words {
+		if w.owner == m.seat && w.text == m.input {
+			return m.kill(i)
+		}
+	}
+	return m.mismatch()
+}
+
+// updateEffects moves the particles on by dt.
+func (m model) updateEffects(dt time.Duration) model {
+	f := float64(dt) / float64(tickRate)
 	// Update all particles in all effects
 	for i := len(m.effects) - 1; i >= 0; i-- {
 		effect := &m.effects[i]
@@ -221,9 +873,9 @@ func (m model) updateEffects() model {
 		// Update each particle
 		for j := len(effect.particles) - 1; j >= 0; j-- {
 			p := &effect.particles[j]
-			p.x += p.vx
-			p.y += p.vy
-			p.lifetime--
+			p.x += p.vx * f
+			p.y += p.vy * f
+			p.lifetime -= dt
 
 			// Remove dead particles
 			if p.lifetime <= 0 {
@@ -242,67 +894,168 @@ func (m model) updateEffects() model {
 func (m model) moveWords() model {
 	for i := len(m.words) - 1; i >= 0; i-- {
 		m.words[i].y++
-		if m.words[i].y >= gameHeight {
+		m.words[i] = m.mutateMove(m.words[i])
+		if m.words[i].y >= m.bottom() {
 			// Word reached bottom - lose a life
+			w := m.words[i]
+			m.tally.Missed = append(m.tally.Missed, w.text)
+			m = m.bury(w.text, true)
+			m.combo = 0
 			m.words = append(m.words[:i], m.words[i+1:]...)
+			if m.coop {
+				m = m.coopMiss(w)
+				continue
+			}
+			logger.Warn("miss", "word", w.text, "lives", m.lives-1)
 			m.lives--
+			m.emit("life_lost", func(e *gameEvent) { e.Word = w.text })
+			m = m.note("life_lost", func(e *moment) { e.Word = w.text })
+			m = m.lifeChanged(false)
 			if m.lives <= 0 {
-				m.gameOver = true
+				m = m.endGame()
 			}
 		}
 	}
 	return m
 }
 
+// endGame moves to the game over screen.
+func (m model) endGame() model {
+	m.gameOver = true
+	m.endTime = time.Now()
+	if m.autosave {
+		if err := discardAutosave(m.startTime); err != nil {
+			logger.Error("autosave", "err", err)
+		}
+	}
+	gameEnded(wpm(m.wordsTyped, m.elapsed()))
+	logger.Info("game over", "score", m.score, "level", m.level, "words", m.wordsTyped, "won", m.won)
+	m.emit("game_over", nil)
+	return m.askInitials()
+}
+
 func (m model) maybeAddWord() model {
 	if len(m.words) >= 8 {
 		return m
 	}
 
 	// Ensure minimum words on screen, then use probability for additional spawns
-	minWords := 1 + m.level/3
-	shouldSpawn := len(m.words) < minWords || rand.Float64() < 0.08+float64(m.level)*0.01
+	shouldSpawn := len(m.words) < m.minWords() || m.rng.Float64() < m.spawnChance()
 
 	if shouldSpawn {
-		newWord := m.dict[rand.Intn(len(m.dict))]
-		maxX := screenWidth - len(newWord) - 1
+		var newWord string
+		owner, chat, event, ok := 0, false, false, true
+		if m.coop {
+			// Deal words to whichever players are still in the game
+			if owner = m.rng.Intn(2); !m.alive(owner) {
+				owner = 1 - owner
+			}
+			m, newWord, ok = m.deal(owner)
+		} else if m, newWord, chat = m.nextThrown(); chat {
+			m.fed = true
+		} else if m, newWord, event = m.eventWord(); !event {
+			m, newWord, ok = m.deal(0)
+		}
+		if !ok {
+			// Nothing fits without clashing; try again next spawn
+			return m
+		}
+		maxX := m.fieldWidth() - m.shownWidth(newWord) - 1
 		if maxX < 0 {
 			maxX = 0
 		}
-		m.words = append(m.words, word{
-			text: newWord,
-			x:    rand.Intn(maxX + 1),
-			y:    0,
-		})
+		x := m.rng.Intn(maxX + 1)
+		m.words = append(m.words, m.mutateSpawn(word{
+			text:     newWord,
+			x:        x,
+			y:        0,
+			owner:    owner,
+			category: m.dict.category(newWord),
+			chat:     chat,
+			event:    event,
+			spawned:  m.gameTime(),
+		}))
+		metrics.wordsServed.Add(1)
+		m.emit("spawn", func(e *gameEvent) { e.Word = m.words[len(m.words)-1].text })
+		logger.Debug("spawn", "word", newWord, "x", x, "level", m.level)
 	}
 	return m
 }
 
+// minWords is the number of words kept on screen regardless of chance.
+func (m model) minWords() int {
+	return 1 + m.level/3
+}
+
+// spawnChance is the probability of an additional spawn at each spawn
+// check.
+func (m model) spawnChance() float64 {
+	return 0.08 + float64(m.level)*0.01
+}
+
 func (m model) View() string {
+	start := time.Now()
+	v := m.renderer.finish(m.render())
+	m.frame.renderTime = time.Since(start)
+	m.presence.set(m.activity())
+	if m.spectators != nil {
+		m.spectators.publish(v)
+	}
+	return v
+}
+
+func (m model) render() string {
+	if m.title && m.dashboard != nil {
+		return renderDashboard(m.styles, m.dashboard) + "\n" + m.styles.help.Render("Press any key to go back")
+	}
+	if m.title {
+		return m.renderTitle()
+	}
+	if m.gameOver && m.board.open {
+		return m.renderBoard()
+	}
+	if m.enteringInitials {
+		return m.renderInitials()
+	}
 	if m.gameOver {
 		return m.renderGameOver()
 	}
 
-	// Create empty screen
-	screen := make([][]rune, gameHeight)
-	for i := range screen {
-		screen[i] = make([]rune, screenWidth)
-		for j := range screen[i] {
-			screen[i][j] = ' '
+	// Clear the screen buffer
+	screen := &m.frame.cells
+	kinds := &m.frame.kinds
+	for y := range screen {
+		for x := range screen[y] {
+			screen[y][x] = ' '
+			kinds[y][x] = cellWord
 		}
 	}
+	m.drawDecor()
+	m.drawSnow()
+	m.drawBoundary()
 
-	// Draw words with cyan/white/grey color scheme
-	highlightStyle := lipgloss.NewStyle().Background(lipgloss.Color("#00FFFF")).Foreground(lipgloss.Color("#000000")).Bold(true)
-	wordStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#00CED1"))
-
-	for _, w := range m.words {
+	// Draw words, marking the letters typed so far on every word they
+	// could still become
+	for i := range m.words {
+		w := &m.words[i]
+		active := w == m.partner.current
+		typed := m.typedOn(*w)
+		landing := m.landing(*w)
 		if w.y >= 0 && w.y < gameHeight {
-			for i, ch := range w.text {
+			shown := m.shown(w.text)
+			for i, ch := range shown {
 				if w.x+i < screenWidth {
+					t := typedAt(i, len(shown), len(w.text))
 					screen[w.y][w.x+i] = ch
+					kinds[w.y][w.x+i] = w.kind(t, active)
+					if t < typed && !active {
+						kinds[w.y][w.x+i] = cellCandidate
+					} else if landing {
+						kinds[w.y][w.x+i] = cellLanding
+					}
 				}
 			}
+			m.markHint(*w)
 		}
 	}
 
@@ -311,96 +1064,656 @@ func (m model) View() string {
 		for _, p := range effect.particles {
 			px, py := int(p.x), int(p.y)
 			if px >= 0 && px < screenWidth && py >= 0 && py < gameHeight {
-				screen[py][px] = p.char
+				m.put(px, py, p.char, p.kind())
+			}
+		}
+	}
+
+	// Draw score popups over everything else
+	for _, p := range m.popups {
+		for i, ch := range p.text {
+			if p.x+i < screenWidth && p.y >= 0 && p.y < gameHeight {
+				screen[p.y][p.x+i] = ch
+				kinds[p.y][p.x+i] = cellPopup
+			}
+		}
+	}
+
+	m.drawBanner()
+
+	// The target goes over everything, typed letters highlighted
+	if w := m.current; w != nil && w.y >= 0 && w.y < gameHeight {
+		shown := m.shown(w.text)
+		for i, ch := range shown {
+			if w.x+i < screenWidth {
+				t := typedAt(i, len(shown), len(w.text))
+				screen[w.y][w.x+i] = ch
+				kinds[w.y][w.x+i] = w.kind(t, false)
+				if t < w.matched {
+					kinds[w.y][w.x+i] = cellMatched
+				} else if m.landing(*w) {
+					kinds[w.y][w.x+i] = cellLanding
+				}
 			}
 		}
+		m.markHint(*w)
 	}
+	m.settleWide()
 
 	// Render screen to string
 	var b strings.Builder
+	b.Grow(screenHeight * screenWidth * 4)
 	b.WriteString("\n")
+	boxed := m.boxed()
+	side := ""
+	if boxed {
+		side = m.styles.border.Render("│")
+		b.WriteString(m.styles.border.Render("┌"+strings.Repeat("─", screenWidth)+"┐") + "\n")
+	}
+	var panel []string
+	if m.teams != nil {
+		panel = m.renderTeams()
+	} else if m.peer != nil {
+		panel = m.renderOpponent()
+	} else if m.speedrun > 0 {
+		panel = m.renderSplits()
+	} else {
+		panel = m.renderGraveyard()
+	}
+	if m.paused {
+		if defs := m.renderDefinitions(); defs != nil {
+			panel = defs
+		}
+	}
+	fw := m.fieldWidth()
+	// indent lines the rest up with the playfield when a mirrored panel
+	// sits left of it
+	indent := ""
+	if m.rtl && panel != nil {
+		panel = panelRows(panel)
+		indent = strings.Repeat(" ", lipgloss.Width(panel[0])+2)
+	}
 	for y := 0; y < gameHeight; y++ {
-		line := string(screen[y])
-		// Highlight current word if it's on this line
-		if m.current != nil && m.current.y == y {
-			before := line[:m.current.x]
-			matched := m.current.text[:m.current.matched]
-			unmatched := m.current.text[m.current.matched:]
-			after := ""
-			if m.current.x+len(m.current.text) < len(line) {
-				after = line[m.current.x+len(m.current.text):]
+		if indent != "" {
+			if y < len(panel) {
+				b.WriteString(panel[y] + "  ")
+			} else {
+				b.WriteString(indent)
 			}
-			line = before + highlightStyle.Render(matched) + wordStyle.Render(unmatched) + after
-		} else {
-			// Color all words on non-current lines
-			line = wordStyle.Render(line)
 		}
-		b.WriteString(line)
+		b.WriteString(side)
+		m.renderer.row(&b, m, y)
+		b.WriteString(side)
+		if !m.rtl && y < len(panel) {
+			b.WriteString("  " + panel[y])
+		}
 		b.WriteString("\n")
 	}
+	fieldEnd := b.Len()
 
-	// Status line with color scheme
-	separatorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#00CED1"))
-	statusStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#CCCCCC"))
-	pauseStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#00FFFF")).Bold(true)
-	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#888888"))
-
-	b.WriteString(separatorStyle.Render(strings.Repeat("─", screenWidth)))
+	// Status line
+	if boxed {
+		b.WriteString(m.styles.border.Render("└" + strings.Repeat("─", screenWidth) + "┘"))
+	} else if fw < screenWidth {
+		b.WriteString(m.styles.border.Render(strings.Repeat("─", fw)))
+	} else {
+		b.WriteString(m.styles.separator)
+	}
 	b.WriteString("\n")
-	elapsed := time.Since(m.startTime).Seconds()
-	wpm := 0
-	if elapsed > 0 {
-		wpm = int(float64(m.wordsTyped) * 60.0 / elapsed)
+	switch {
+	case m.minimal:
+		b.WriteString(m.alignLine(m.renderMinimalStatus()))
+	case m.coop:
+		b.WriteString(m.alignLine(m.renderCoopStatus()))
+	default:
+		b.WriteString(m.alignLine(m.withInputBox(m.renderStatus())))
+	}
+	if legend := m.renderLegend(); legend != "" && !m.minimal {
+		b.WriteString("\n" + m.alignLine(legend))
+	}
+	if mods := m.renderModifiers(); mods != "" && !m.minimal {
+		b.WriteString("\n" + mods)
 	}
-	status := fmt.Sprintf("Score: %d  Level: %d  Lives: %d  Words: %d  WPM: %d  Input: %s",
-		m.score, m.level, m.lives, m.wordsTyped, wpm, m.input)
-	b.WriteString(statusStyle.Render(status))
 
-	if m.paused {
-		b.WriteString("\n\n" + pauseStyle.Render("[PAUSED - Press SPACE to resume]"))
+	if m.paused && m.pauseReason == "break" {
+		b.WriteString("\n\n" + m.alignLine(m.styles.pause.Render("[BREAK - Press SPACE when you're ready]")))
+		b.WriteString("\n" + m.alignLine(m.styles.stats.Render("Time to stretch: "+m.stretch())))
+	} else if m.paused && m.pauseReason != "" {
+		b.WriteString("\n\n" + m.alignLine(m.styles.pause.Render("[PAUSED ("+m.pauseReason+") - Press SPACE to resume]")))
+	} else if m.paused {
+		b.WriteString("\n\n" + m.alignLine(m.styles.pause.Render("[PAUSED - Press SPACE to resume]")))
+	}
+
+	help := "[ctrl+c: quit | SPACE: pause | ctrl+l: redraw | F3: debug]"
+	if !m.coop && m.peer == nil && m.classroom == nil {
+		help = "[ctrl+c: quit | SPACE: pause | ?: hint | ctrl+l: redraw | F3: debug]"
+	}
+	if m.shotRecent() {
+		b.WriteString("\n\n" + m.alignLine(m.styles.help.Render(m.screenshotStatus())))
+	} else if !m.minimal {
+		b.WriteString("\n\n" + m.alignLine(m.styles.help.Render(help)))
 	}
 
-	b.WriteString("\n\n" + helpStyle.Render("[ctrl+c: quit | SPACE: pause | ctrl+l: redraw]"))
+	if m.debug {
+		b.WriteString("\n\n" + m.renderDebug())
+	}
+	if indent != "" {
+		view := b.String()
+		b.Reset()
+		b.WriteString(view[:fieldEnd] + indentLines(view[fieldEnd:], indent))
+	}
 
+	if boxed {
+		// Center the box, keeping the lines under it aligned with its edge
+		margin := strings.Repeat(" ", (m.width-lipgloss.Width(b.String()))/2)
+		lines := strings.Split(b.String(), "\n")
+		for i := range lines {
+			lines[i] = margin + lines[i]
+		}
+		return lipgloss.PlaceVertical(m.height, lipgloss.Center, strings.Join(lines, "\n"))
+	}
 	return b.String()
 }
 
-func (m model) renderGameOver() string {
-	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#00FFFF")).Bold(true)
-	statsStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#CCCCCC"))
-	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#888888"))
+// boxed reports whether the terminal has room to frame the playfield,
+// which is then drawn in a border in the middle of the screen. Smaller
+// terminals get the flush layout that fits 80x24.
+func (m model) boxed() bool {
+	return m.width >= screenWidth+2 && m.height >= screenHeight+4
+}
+
+// renderRuns writes row y of the frame, switching style wherever the kind
+// of cell changes.
+func (m model) renderRuns(b *strings.Builder, y int) {
+	row := m.frame.cells[y][:m.fieldWidth()]
+	kinds := m.frame.kinds[y][:m.fieldWidth()]
+	start := 0
+	for x := 1; x <= len(row); x++ {
+		if x < len(row) && kinds[x] == kinds[start] {
+			continue
+		}
+		b.WriteString(m.styles.cell(kinds[start]).Render(cellText(row[start:x])))
+		start = x
+	}
+}
 
+func (m model) renderTitle() string {
 	var b strings.Builder
 	b.WriteString("\n\n")
-	b.WriteString(titleStyle.Render("GAME OVER"))
+	b.WriteString(m.styles.title.Render("LETTER INVADERS"))
+	b.WriteString("\n")
+	b.WriteString(m.styles.help.Render(version))
+	if m.player != "" {
+		b.WriteString("\n" + m.styles.help.Render("Playing as "+m.player))
+	}
+	if m.rating.Games > 0 {
+		b.WriteString("\n" + m.styles.help.Render(fmt.Sprintf("Versus rating: %d", m.rating.Rating)))
+	}
+	for _, st := range m.practice {
+		style := m.styles.help
+		if st.met {
+			style = m.styles.pause
+		}
+		b.WriteString("\n" + style.Render(st.text))
+	}
+	if m.latestVersion != "" {
+		b.WriteString("\n" + m.styles.pause.Render(fmt.Sprintf("Update available: %s (run '%s self-update')", m.latestVersion, progName())))
+	}
+	if m.weekly != "" {
+		b.WriteString("\n" + m.styles.pause.Render("This is the weekly challenge for "+m.weekly))
+	} else if m.player == "" && m.peer == nil && m.classroom == nil {
+		b.WriteString("\n" + m.styles.help.Render(weeklyCountdown(time.Now())))
+	}
+	if banner := m.seasonBanner(); banner != "" {
+		b.WriteString("\n" + m.styles.event.Render(banner))
+	}
 	b.WriteString("\n\n")
-	b.WriteString(statsStyle.Render(fmt.Sprintf("Final Score: %d\n", m.score)))
-	b.WriteString(statsStyle.Render(fmt.Sprintf("Level Reached: %d\n", m.level)))
-	b.WriteString(statsStyle.Render(fmt.Sprintf("Words Typed: %d\n", m.wordsTyped)))
-	b.WriteString("\n\n" + helpStyle.Render("Press 'q' to quit"))
+	b.WriteString(m.styles.stats.Render("Type the falling words before they reach the bottom.\n"))
+	b.WriteString(m.styles.stats.Render(fmt.Sprintf("You have %d lives. %s\n", m.lives, m.levelRule())))
+	if m.coop {
+		b.WriteString("\n" + m.styles.word.Render("Player 1 types the cyan words with the left hand ("+leftHandKeys+")"))
+		b.WriteString("\n" + m.styles.partner.Render("Player 2 types the pink words with the right hand ("+rightHandKeys+")"))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n\n" + m.styles.help.Render("Press any key to start, tab for your stats, 'q' to quit"))
 	return b.String()
 }
 
-func main() {
-	dictPath := flag.String("d", "/usr/share/dict/words", "Path to dictionary file")
-	flag.Parse()
+func (m model) renderGameOver() string {
+	var b strings.Builder
+	b.WriteString("\n\n")
+	if m.victory {
+		b.WriteString(m.styles.title.Render("VICTORY"))
+		b.WriteString("  " + m.styles.pause.Render(fmt.Sprintf("%s reached in %s", m.goal, formatSplit(m.elapsed()))))
+	} else {
+		b.WriteString(m.styles.title.Render("GAME OVER"))
+	}
+	if m.peer != nil {
+		result := "YOU LOSE"
+		switch {
+		case m.won:
+			result = "YOU WIN"
+		case m.teammateIn():
+			result = "YOUR TEAMMATE PLAYS ON"
+		}
+		b.WriteString("  " + m.styles.pause.Render(result))
+		b.WriteString("\n\n")
+		if m.teams != nil {
+			ours := m.teams[m.team]
+			b.WriteString(m.styles.teams[m.team].Render(fmt.Sprintf("%s team: score %d, %d words", teamNames[m.team], ours.Score, ours.Words)) + "\n")
+		}
+		b.WriteString(m.styles.stats.Render(fmt.Sprintf("%s: score %d, %d words", m.opponent.name, m.opponent.score, m.opponent.words)))
+		if m.rated() {
+			after := m.rating.update(m.opponent.rating, m.won)
+			b.WriteString("\n" + m.styles.stats.Render(fmt.Sprintf("Rating: %d -> %d (%+d)", m.rating.Rating, after.Rating, after.Rating-m.rating.Rating)))
+		}
+	}
+	b.WriteString("\n\n")
+	if m.coop {
+		b.WriteString(m.styles.word.Render(fmt.Sprintf("Player 1 Score: %d, %d words", m.score, m.wordsTyped)) + "\n")
+		b.WriteString(m.styles.partner.Render(fmt.Sprintf("Player 2 Score: %d, %d words", m.partner.score, m.partner.words)) + "\n")
+	}
+	b.WriteString(m.styles.stats.Render(fmt.Sprintf("Final Score: %d\n", m.score+m.partner.score)))
+	b.WriteString(m.styles.stats.Render(fmt.Sprintf("Level Reached: %d\n", m.level)))
+	b.WriteString(m.styles.stats.Render(fmt.Sprintf("Words Typed: %d\n", m.wordsTyped)))
+	b.WriteString(m.styles.stats.Render(fmt.Sprintf("Speed: %d WPM, %d CPM raw\n",
+		wpm(m.wordsTyped+m.partner.words, m.elapsed()), cpm(m.tally.Keystrokes, m.elapsed()))))
+	b.WriteString(m.styles.stats.Render(fmt.Sprintf("Accuracy: %.1f%%\n", m.tally.accuracy())))
+	if m.assisted {
+		b.WriteString(m.styles.stats.Render("Assisted: pasted input was detected\n"))
+	}
+	if m.slowed() {
+		b.WriteString(m.styles.stats.Render(fmt.Sprintf("Assisted: slow motion at %.0f%% speed\n", m.slowMotion*100)))
+	}
+	if m.hints > 0 {
+		b.WriteString(m.styles.stats.Render(m.hintStatus() + "\n"))
+	}
+	if mods := m.modifiers(); len(mods) > 0 {
+		b.WriteString(m.styles.stats.Render("Modifiers: " + strings.Join(mods, ", ") + "\n"))
+	}
+	if m.shift {
+		b.WriteString(m.styles.stats.Render(fmt.Sprintf("Shift: %d capitals, %d shift errors (%.1f%%)\n",
+			m.tally.Capitals, m.tally.ShiftErrors, m.tally.shiftAccuracy())))
+	}
+	if m.rhythm.keys > 0 {
+		b.WriteString(m.styles.stats.Render(fmt.Sprintf("Rhythm: %.0f%% on the beat at %d KPM, %dms off on average\n",
+			m.rhythm.onBeatPct(), m.metronome, m.rhythm.meanOff().Milliseconds())))
+	}
+	if m.speedrun > 0 {
+		b.WriteString("\n" + m.renderSplitTable())
+	}
+	if timeline := m.renderTimeline(); timeline != "" {
+		b.WriteString("\n" + timeline)
+	}
+	if code := m.challenge(); code != "" {
+		b.WriteString(m.styles.stats.Render("Challenge code: ") + m.styles.highlight.Render(code) + "\n")
+	}
+	if m.peer != nil {
+		b.WriteString("\n" + strings.Join(m.chat.render(m.styles), "\n") + "\n")
+	}
+	var keys []string
+	if m.board.url != "" {
+		if status := m.renderBoardStatus(); status != "" {
+			b.WriteString("\n" + m.styles.stats.Render(status) + "\n")
+		}
+		if status := m.renderReplayStatus(); status != "" {
+			b.WriteString(m.styles.stats.Render(status) + "\n")
+		}
+		keys = append(keys, "'s' to submit your score", "'l' for the leaderboard")
+		if m.replayable() {
+			keys = append(keys, "'u' to upload the replay")
+		}
+	}
+	if m.recording != nil {
+		if status := m.recordingStatus(); status != "" {
+			b.WriteString("\n" + m.styles.stats.Render(status) + "\n")
+		}
+		if m.giffable() {
+			keys = append(keys, "'r' to save it as a GIF")
+		}
+	}
+	switch {
+	case m.copied == nil:
+	case m.copied.err != nil:
+		b.WriteString("\n" + m.styles.stats.Render(fmt.Sprintf("Couldn't copy: %v", m.copied.err)) + "\n")
+	default:
+		b.WriteString("\n" + m.styles.stats.Render("Result card copied to the clipboard") + "\n")
+	}
+	if status := m.screenshotStatus(); status != "" {
+		b.WriteString("\n" + m.styles.stats.Render(status) + "\n")
+	}
+	if m.drillable() {
+		keys = append(keys, "'d' to drill your weak words")
+	}
+	keys = append(keys, "'c' to copy a result card")
+	if m.screenshotable() {
+		keys = append(keys, "F12 for a screenshot")
+	}
+	keys = append(keys, "'q' to quit")
+	b.WriteString("\n\n" + m.styles.help.Render("Press "+strings.Join(keys, ", ")))
+	return b.String()
+}
+
+// playOptions are the flags accepted by the play command.
+type playOptions struct {
+	dictPath    string
+	pprofAddr   string
+	idleTimeout time.Duration
+	logPath     string
+	metricsAddr string
+	// checkUpdates asks GitHub for a newer release on startup
+	checkUpdates bool
+	// resultsOut receives the end-of-game report as JSON or CSV
+	resultsOut string
+	coop       bool
+	// handicap and handicap2 are player one's and two's in co-op
+	handicap, handicap2 handicap
+	// leaderboard is the server scores may be submitted to at game over
+	leaderboard string
+	name        string
+	// spectate serves the game to spectators on this address
+	spectate string
+	// level is the level the game starts at
+	level int
+	// challenge replays the game a challenge code describes
+	challenge string
+	// weekly plays this week's challenge
+	weekly bool
+	// speedrun races to this level
+	speedrun int
+	goal     goal
+	// twitch and youtubeChat name live chats whose words are thrown in
+	twitch      string
+	youtubeChat string
+	youtubeKey  string
+	// mutators are applied to the game, see mutator
+	mutators mutatorList
+	// overlay serves game events to stream overlays, see overlay.go
+	overlay string
+	// controlSocket is where local tools can drive the game, see control
+	controlSocket string
+	// watch reloads the dictionary and settings when their files change
+	watch bool
+	// renderer names how the screen is drawn, see renderers
+	renderer string
+	// tickMs is the time between frames, see frameRate
+	tickMs int
+	// effects overrides the effects setting when set
+	effects string
+	// symbols overrides the symbols setting when set
+	symbols string
+	// minimal hides everything but the words and a WPM counter, see
+	// minimal.go
+	minimal bool
+	// keys deals only words typable on one hand or row, see keyDrills
+	keys string
+	// shift keeps capitals, see shift.go
+	shift bool
+	// oneHand is the one-handed preset's hand, see withOneHand
+	oneHand string
+	// benchDemo times a scripted game instead of playing, see benchDemo
+	benchDemo bool
+	// record streams the game to a cast file, see record.go
+	record bool
+}
+
+func addPlayFlags(fs *flag.FlagSet, opts *playOptions) {
+	fs.StringVar(&opts.dictPath, "d", "/usr/share/dict/words", "Path to dictionary file")
+	fs.StringVar(&opts.pprofAddr, "pprof", "", "Serve net/http/pprof on this address (e.g. :6060)")
+	fs.DurationVar(&opts.idleTimeout, "idle", 30*time.Second, "Auto-pause after this long without a keystroke (0 disables)")
+	fs.StringVar(&opts.logPath, "log", "", "Write structured game event logs to this file")
+	fs.StringVar(&opts.metricsAddr, "metrics", "", "Serve Prometheus metrics at /metrics on this address (e.g. :9090)")
+	fs.StringVar(&opts.resultsOut, "results-out", "", "Write the end-of-game report to this file (.json or .csv)")
+	fs.BoolVar(&opts.coop, "coop", false, "Two players on one keyboard, split into left and right halves")
+	fs.Var(&opts.handicap, "handicap", "Player one's co-op handicap: speed=F,lives=N,shorter=N in any combination")
+	fs.Var(&opts.handicap2, "handicap2", "Player two's co-op handicap, like -handicap")
+	fs.IntVar(&opts.level, "level", 1, "Start at this level")
+	fs.IntVar(&opts.speedrun, "speedrun", 0, "Speedrun: race from level 1 to this level with splits against your best")
+	fs.Var(&opts.goal, "goal", "Win by reaching a goal: score=N or words=N")
+	fs.BoolVar(&opts.weekly, "weekly", false, "Play this week's challenge, the same game for everyone until Monday 00:00 UTC")
+	fs.StringVar(&opts.challenge, "challenge", "", "Play the exact game a challenge code from the game over screen describes")
+	fs.StringVar(&opts.leaderboard, "leaderboard", "", "Leaderboard server to offer score submission to at game over (e.g. http://host:8080)")
+	fs.StringVar(&opts.name, "name", os.Getenv("USER"), "Name shown on the leaderboard")
+	fs.StringVar(&opts.spectate, "spectate", "", "Let others watch at ws://ADDR/watch with the watch command (e.g. :8090)")
+	fs.StringVar(&opts.twitch, "twitch", "", "Spawn words typed in this Twitch channel's chat")
+	fs.StringVar(&opts.youtubeChat, "youtube-chat", "", "Spawn words typed in this YouTube live chat (liveChatId)")
+	fs.StringVar(&opts.youtubeKey, "youtube-key", os.Getenv("YOUTUBE_API_KEY"), "YouTube Data API key for -youtube-chat (default: $YOUTUBE_API_KEY)")
+	fs.Var(&opts.mutators, "mutators", "Twist the rules with these mutators, comma separated (zigzag, long-shot, backwards)")
+	fs.StringVar(&opts.overlay, "overlay", "", "Serve game events for stream overlays at http://ADDR/events (e.g. :8091)")
+	fs.StringVar(&opts.controlSocket, "control-socket", "", "Accept JSON-RPC commands and stream game events on this unix socket")
+	fs.BoolVar(&opts.watch, "watch", false, "Reload the dictionary and settings at the next level up when their files change")
+	fs.StringVar(&opts.renderer, "renderer", "ansi", "Draw the screen with this renderer: ansi, crt for a retro green screen, or plain for ASCII without colors")
+	fs.IntVar(&opts.tickMs, "tick-ms", defaultTickMs, "Milliseconds between frames: lower is smoother, higher is lighter on slow links; the game speed is the same")
+	fs.BoolVar(&opts.shift, "shift", false, "Shift training: keep the dictionary's capitals and count shift errors apart")
+	fs.StringVar(&opts.oneHand, "one-hand", "", "One-handed preset: only words typable with this hand, left or right, falling slower")
+	fs.StringVar(&opts.keys, "keys", "", "Deal only words typable with one hand or row of your layout: left, right, top, home or bottom")
+	fs.StringVar(&opts.effects, "effects", "", "Explosion particles: low, med or high (default: the effects setting, or high)")
+	fs.BoolVar(&opts.minimal, "minimal", false, "Distraction-free: just the falling words and a small WPM counter, no explosions, popups, input box or status line")
+	fs.StringVar(&opts.symbols, "symbols", "", "Draw explosions and the backdrop with ascii, unicode or emoji symbols, falling back to what the terminal can show (default: the symbols setting, or ascii)")
+	fs.BoolVar(&opts.record, "record", false, "Record the game as it's played to an asciinema cast in the data directory's recordings folder")
+	fs.BoolVar(&opts.benchDemo, "bench-demo", false, "Time Update and View over a scripted heavy game and report frame-time percentiles")
+	fs.BoolVar(&opts.checkUpdates, "check-updates", false, "Check GitHub for a newer release and mention it on the title screen")
+}
+
+func play(opts playOptions) error {
+	if opts.logPath != "" {
+		closeLog, err := openLog(opts.logPath)
+		if err != nil {
+			return fmt.Errorf("opening log: %w", err)
+		}
+		defer closeLog()
+	}
 
-	dict, err := loadDictionary(*dictPath)
+	if opts.pprofAddr != "" {
+		servePprof(opts.pprofAddr)
+	}
+	if opts.metricsAddr != "" {
+		serveMetrics(opts.metricsAddr)
+	}
+
+	dict, err := readDictionary(opts.dictPath, opts.shift)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error loading dictionary: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("loading dictionary: %w", err)
 	}
 
-	if len(dict) == 0 {
-		fmt.Fprintln(os.Stderr, "Dictionary is empty")
-		os.Exit(1)
+	if dict.len() == 0 {
+		return errors.New("dictionary is empty")
+	}
+	if opts.benchDemo {
+		rate, err := frameRate(opts.tickMs)
+		if err != nil {
+			return err
+		}
+		return benchDemo(dict, rate)
 	}
 
 	rand.Seed(time.Now().UnixNano())
 
-	p := tea.NewProgram(initialModel(dict), tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+	if opts.level < 1 || opts.level > maxStartLevel {
+		return fmt.Errorf("-level must be between 1 and %d", maxStartLevel)
+	}
+	if !opts.coop && (opts.handicap != (handicap{}) || opts.handicap2 != (handicap{})) {
+		return errors.New("-handicap and -handicap2 are for -coop games; versus takes its own -handicap")
+	}
+	m := initialModel(dict)
+	m.startLevel, m.level = opts.level, opts.level
+	if opts.weekly {
+		if opts.coop || opts.challenge != "" || opts.level != 1 {
+			return errors.New("-weekly can't be combined with -coop, -challenge or -level")
+		}
+		m = m.withWeekly(time.Now())
+		logger.Info("weekly challenge", "week", m.weekly)
+	} else if opts.challenge != "" {
+		c, err := parseChallenge(opts.challenge)
+		if err != nil {
+			return fmt.Errorf("challenge %s: %w", opts.challenge, err)
+		}
+		if m, err = m.withChallenge(c); err != nil {
+			return err
+		}
+		logger.Info("challenge", "code", opts.challenge)
+	} else if opts.coop {
+		if m, err = m.withCoop(); err != nil {
+			return err
+		}
+		m = m.withHandicaps([2]handicap{opts.handicap, opts.handicap2})
+	} else if saved, err := loadAutosave(); err != nil {
+		fmt.Fprintf(os.Stderr, "Ignoring unreadable autosave: %v\n", err)
+	} else if saved != nil {
+		if promptResume(saved) {
+			m = resumeModel(dict, *saved)
+			logger.Info("resume", "score", saved.Score, "level", saved.Level)
+		} else if err := recordSession("", saved.session()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error recording interrupted game: %v\n", err)
+		}
+		removeAutosave()
+	}
+
+	if opts.speedrun != 0 {
+		if m, err = m.withSpeedrun(opts.speedrun); err != nil {
+			return err
+		}
+	}
+	if !m.resumed {
+		m.goal = opts.goal
+	}
+	m.mutators, m.mutatorNames = opts.mutators.list, opts.mutators.names
+	if opts.controlSocket != "" || opts.overlay != "" {
+		m.events = newEventBus()
+	}
+	if opts.overlay != "" {
+		serveOverlay(opts.overlay, m.events)
+	}
+	m.fixedRules = opts.challenge != "" || m.weekly != "" || m.speedrun != 0
+	if prefs, err := loadSettings(); err != nil {
+		fmt.Fprintf(os.Stderr, "Ignoring unreadable settings: %v\n", err)
+	} else {
+		m = m.withSettings(prefs)
+	}
+
+	if opts.shift {
+		if m.coop || m.fixedRules || m.resumed || opts.watch {
+			return errors.New("-shift can't be combined with -coop, -weekly, -challenge, -speedrun, -watch or a resumed game")
+		}
+		m.shift = true
+	}
+	if opts.keys != "" || opts.oneHand != "" {
+		if m.coop || m.fixedRules || m.resumed || opts.watch {
+			// A reload would deal off the drill's keys, and reloaded
+			// settings would drop the one-handed pace
+			return errors.New("-keys and -one-hand can't be combined with -coop, -weekly, -challenge, -speedrun, -watch or a resumed game")
+		}
+		switch {
+		case opts.keys != "" && opts.oneHand != "":
+			return errors.New("-one-hand already picks the keys; drop -keys")
+		case opts.oneHand != "":
+			m, err = m.withOneHand(opts.oneHand)
+		default:
+			m, err = m.withKeys(opts.keys)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if m, err = m.withRenderer(opts.renderer, lipgloss.DefaultRenderer()); err != nil {
+		return err
+	}
+	if m.frameRate, err = frameRate(opts.tickMs); err != nil {
+		return err
+	}
+	if opts.effects != "" {
+		if m.effectsLevel, err = parseEffects(opts.effects); err != nil {
+			return fmt.Errorf("-effects: %w", err)
+		}
+	}
+	if opts.symbols != "" {
+		if m.symbols, err = parseSymbols(opts.symbols); err != nil {
+			return fmt.Errorf("-symbols: %w", err)
+		}
+	}
+	m.symbols = m.symbols.shownOn(m.renderer)
+	m.minimal = opts.minimal
+	m = m.withSeason(time.Now())
+	m.idleTimeout = opts.idleTimeout
+	// A resumed game couldn't be typed without the capitals
+	m.autosave = !m.coop && m.speedrun == 0 && !m.shift
+	m.checkUpdates = opts.checkUpdates
+	if m.rating, err = loadRating(""); err != nil {
+		fmt.Fprintf(os.Stderr, "Ignoring unreadable rating: %v\n", err)
+	}
+	if m.highScores, err = loadHighScores(""); err != nil {
+		fmt.Fprintf(os.Stderr, "Ignoring unreadable history: %v\n", err)
+	}
+	if m.practice, err = practiceStatus("", time.Now()); err != nil {
+		fmt.Fprintf(os.Stderr, "Ignoring unreadable goals: %v\n", err)
+	}
+	if opts.spectate != "" {
+		m.spectators = newBroadcaster()
+		defer m.spectators.close()
+		serveSpectators(opts.spectate, "/watch", m.spectators)
+	}
+	m.board = leaderboard{url: opts.leaderboard, name: opts.name}
+	if link, err := loadAccount(""); err != nil {
+		fmt.Fprintf(os.Stderr, "Ignoring unreadable account: %v\n", err)
+	} else if link != nil && strings.TrimSuffix(link.Server, "/") == strings.TrimSuffix(opts.leaderboard, "/") {
+		m.board.name, m.board.token = link.Name, link.Token
+	}
+	m.presence = startPresence()
+	defer m.presence.close()
+	if opts.record {
+		if m.recording, err = newRecorder(); err != nil {
+			return fmt.Errorf("recording: %w", err)
+		}
+		defer m.recording.close()
+	}
+	if opts.youtubeChat != "" && opts.youtubeKey == "" {
+		return errors.New("-youtube-chat needs an API key: set -youtube-key or $YOUTUBE_API_KEY")
+	}
+
+	logger.Info("dictionary loaded", "dict", opts.dictPath, "words", dict.len())
+
+	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithReportFocus(), withFPS(m.frameRate))
+	if opts.watch {
+		go watchFiles(p, opts.dictPath)
+	}
+	if opts.controlSocket != "" {
+		closeControl, err := serveControl(opts.controlSocket, p, m.events)
+		if err != nil {
+			return fmt.Errorf("control socket: %w", err)
+		}
+		defer closeControl()
+	}
+	if opts.twitch != "" || opts.youtubeChat != "" {
+		feed := &chatFeed{prog: p, gates: map[string]*floodGate{}}
+		if opts.twitch != "" {
+			go feed.followTwitch(opts.twitch)
+		}
+		if opts.youtubeChat != "" {
+			go feed.followYouTube(opts.youtubeChat, opts.youtubeKey)
+		}
+	}
+	final, err := p.Run()
+	if err != nil {
+		// Leave the autosave in place so the run can be resumed
+		logger.Error("program exited", "err", err)
+		return err
+	}
+
+	fm := final.(model)
+	if !fm.title {
+		s, err := fm.finish()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error recording game: %v\n", err)
+		}
+		if opts.resultsOut != "" {
+			if err := writeResults(opts.resultsOut, s); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing results: %v\n", err)
+			}
+		}
+	}
+	if err := discardAutosave(fm.startTime); err != nil {
+		fmt.Fprintf(os.Stderr, "Error removing autosave: %v\n", err)
 	}
+	return playDrill(fm)
 }
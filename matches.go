@@ -0,0 +1,239 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// 'stats history' browses recent games: the local history, and with an
+// account the games the server has recorded on it, TAB switching between
+// the two. Each row shows the result and the opponent of a networked
+// match; games whose replay was uploaded are marked and can be watched
+// from the list.
+
+// historyRows is how many games the history screen lists at once.
+const historyRows = 15
+
+// historyRow is one game as the history screen lists it, from either
+// source.
+type historyRow struct {
+	time     time.Time
+	mode     string
+	score    int
+	level    int
+	wpm      int
+	accuracy float64
+	opponent string
+	won      bool
+	replay   string
+}
+
+func sessionRow(s session) historyRow {
+	return historyRow{time: s.Time, mode: modeName(s), score: s.Score, level: s.Level, wpm: s.WPM,
+		accuracy: s.Accuracy, opponent: s.Opponent, won: s.Won, replay: s.Replay}
+}
+
+func accountRow(g accountGame) historyRow {
+	return historyRow{time: g.Time, mode: g.Mode, score: g.Score, level: g.Level, wpm: g.WPM,
+		accuracy: g.Accuracy, opponent: g.Opponent, won: g.Won, replay: g.Replay}
+}
+
+// result is the row's outcome column.
+func (r historyRow) result() string {
+	switch {
+	case r.opponent == "":
+		return ""
+	case r.won:
+		return "beat " + r.opponent
+	}
+	return "lost to " + r.opponent
+}
+
+// historyScreen lists the local or online games, newest first.
+type historyScreen struct {
+	styles *styles
+	dict   *dictionary
+	local  []historyRow
+	// online is the account's games, fetched when first shown; link is
+	// nil without an account
+	link    *accountLink
+	online  []historyRow
+	fetched bool
+	err     error
+	showing string
+	// matchesOnly hides games without an opponent
+	matchesOnly bool
+	cursor      int
+	notice      string
+	viewer      *replayViewer
+	gen         int
+}
+
+// rows is what the screen currently lists.
+func (h historyScreen) rows() []historyRow {
+	rows := h.local
+	if h.showing == "online" {
+		rows = h.online
+	}
+	if !h.matchesOnly {
+		return rows
+	}
+	var matches []historyRow
+	for _, r := range rows {
+		if r.opponent != "" {
+			matches = append(matches, r)
+		}
+	}
+	return matches
+}
+
+func (h historyScreen) Init() tea.Cmd {
+	return nil
+}
+
+func (h historyScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	var handled bool
+	if h.viewer, cmd, handled = updateWatching(h.viewer, msg); handled {
+		return h, cmd
+	}
+	switch msg := msg.(type) {
+	case profileMsg:
+		h.fetched = true
+		h.err = msg.err
+		h.online = nil
+		for _, g := range msg.profile.History {
+			h.online = append(h.online, accountRow(g))
+		}
+	case replayFetchedMsg:
+		if msg.err != nil {
+			h.notice = fmt.Sprintf("Fetching the replay failed: %v", msg.err)
+			return h, nil
+		}
+		h.gen++
+		v, err := newReplayViewer(h.dict, msg.entry, h.gen)
+		if err != nil {
+			h.notice = err.Error()
+			return h, nil
+		}
+		h.notice = ""
+		h.viewer = &v
+		return h, v.Init()
+	case tea.KeyMsg:
+		h.notice = ""
+		rows := h.rows()
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			return h, tea.Quit
+		case "up", "k":
+			h.cursor = max(0, h.cursor-1)
+		case "down", "j":
+			h.cursor = max(0, min(len(rows)-1, h.cursor+1))
+		case "m":
+			h.matchesOnly = !h.matchesOnly
+			h.cursor = 0
+		case "tab":
+			h.cursor = 0
+			if h.showing == "online" {
+				h.showing = "local"
+				break
+			}
+			if h.link == nil {
+				h.notice = "Online history needs an account; see 'account register'"
+				break
+			}
+			h.showing = "online"
+			if !h.fetched {
+				return h, fetchProfileCmd(*h.link)
+			}
+		case "enter":
+			if h.cursor >= len(rows) {
+				break
+			}
+			if url := rows[h.cursor].replay; url != "" {
+				h.notice = "Fetching the replay..."
+				return h, fetchReplayURLCmd(url)
+			}
+			h.notice = "That game's replay wasn't uploaded"
+		}
+	}
+	return h, nil
+}
+
+func (h historyScreen) View() string {
+	if h.viewer != nil {
+		return h.viewer.View()
+	}
+	st := h.styles
+	var b strings.Builder
+	title := "GAME HISTORY - LOCAL"
+	if h.showing == "online" {
+		title = "GAME HISTORY - " + strings.ToUpper(h.link.Name) + " ON " + h.link.Server
+	}
+	if h.matchesOnly {
+		title += " (MATCHES)"
+	}
+	b.WriteString("\n" + st.title.Render(title) + "\n\n")
+	rows := h.rows()
+	switch {
+	case h.showing == "online" && !h.fetched:
+		b.WriteString(st.stats.Render("Loading...") + "\n")
+	case h.showing == "online" && h.err != nil:
+		b.WriteString(st.stats.Render(fmt.Sprintf("Online history unavailable: %v", h.err)) + "\n")
+	case len(rows) == 0:
+		b.WriteString(st.stats.Render("No games yet") + "\n")
+	default:
+		b.WriteString(st.help.Render(fmt.Sprintf("  %-16s %-9s %7s %5s %4s %6s  %s", "DATE", "MODE", "SCORE", "LEVEL", "WPM", "ACC", "RESULT")) + "\n")
+		// Keep the cursor in the window
+		start := min(max(0, h.cursor-historyRows/2), max(0, len(rows)-historyRows))
+		for i := start; i < len(rows) && i < start+historyRows; i++ {
+			r := rows[i]
+			line := fmt.Sprintf("%-16s %-9s %7d %5d %4d %5.1f%%  %s", r.time.Local().Format("2006-01-02 15:04"), r.mode, r.score, r.level, r.wpm, r.accuracy, r.result())
+			if r.replay != "" {
+				line += "  [replay]"
+			}
+			if i == h.cursor {
+				b.WriteString(st.highlight.Render("> "+line) + "\n")
+			} else {
+				b.WriteString(st.stats.Render("  "+line) + "\n")
+			}
+		}
+		b.WriteString("\n" + st.stats.Render(fmt.Sprintf("%d of %d", h.cursor+1, len(rows))) + "\n")
+	}
+	if h.notice != "" {
+		b.WriteString("\n" + st.stats.Render(h.notice) + "\n")
+	}
+	b.WriteString("\n" + st.help.Render("[ENTER: watch replay | TAB: local/online | m: matches only | q: quit]"))
+	return b.String()
+}
+
+// showHistory runs the history screen.
+func showHistory(args []string) error {
+	fs := flag.NewFlagSet("stats history", flag.ExitOnError)
+	dictPath := fs.String("d", "/usr/share/dict/words", "Dictionary to play replays back with")
+	matches := fs.Bool("matches", false, "Start with only networked matches listed")
+	fs.Parse(args)
+	dict, err := loadDictionary(*dictPath)
+	if err != nil {
+		return fmt.Errorf("loading dictionary: %w", err)
+	}
+	sessions, err := loadHistory("")
+	if err != nil {
+		return err
+	}
+	link, err := loadAccount("")
+	if err != nil {
+		return err
+	}
+	h := historyScreen{styles: newStyles(lipgloss.DefaultRenderer()), dict: dict, link: link, showing: "local", matchesOnly: *matches}
+	for i := len(sessions) - 1; i >= 0; i-- {
+		h.local = append(h.local, sessionRow(sessions[i]))
+	}
+	_, err = tea.NewProgram(h, tea.WithAltScreen()).Run()
+	return err
+}
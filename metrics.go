@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"sync/atomic"
+)
+
+// Process-wide game metrics, exported in the Prometheus text format. They
+// aggregate across every game the process hosts.
+var metrics struct {
+	activeGames      atomic.Int64
+	connectedPlayers atomic.Int64
+	gamesTotal       atomic.Int64
+	wordsServed      atomic.Int64
+	wordsTyped       atomic.Int64
+	wpmSum           atomic.Int64
+	wpmCount         atomic.Int64
+}
+
+func gameStarted() {
+	metrics.activeGames.Add(1)
+}
+
+// gameEnded records a finished game's WPM. Call it exactly once per game
+// that gameStarted was called for.
+func gameEnded(wpm int) {
+	metrics.activeGames.Add(-1)
+	metrics.gamesTotal.Add(1)
+	metrics.wpmSum.Add(int64(wpm))
+	metrics.wpmCount.Add(1)
+}
+
+func metricsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	write := func(name, kind, help string, value int64) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %d\n", name, help, name, kind, name, value)
+	}
+	write("letter_invaders_active_games", "gauge", "Games currently in progress.", metrics.activeGames.Load())
+	write("letter_invaders_connected_players", "gauge", "Players connected to this server.", metrics.connectedPlayers.Load())
+	write("letter_invaders_games_total", "counter", "Games finished.", metrics.gamesTotal.Load())
+	write("letter_invaders_words_served_total", "counter", "Words spawned onto playfields.", metrics.wordsServed.Load())
+	write("letter_invaders_words_typed_total", "counter", "Words destroyed by players.", metrics.wordsTyped.Load())
+	fmt.Fprintf(w, "# HELP letter_invaders_game_wpm Words per minute of finished games.\n"+
+		"# TYPE letter_invaders_game_wpm summary\n"+
+		"letter_invaders_game_wpm_sum %d\nletter_invaders_game_wpm_count %d\n",
+		metrics.wpmSum.Load(), metrics.wpmCount.Load())
+}
+
+// serveMetrics exposes /metrics on addr in the background.
+func serveMetrics(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", metricsHandler)
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			fmt.Fprintf(os.Stderr, "metrics server: %v\n", err)
+		}
+	}()
+}
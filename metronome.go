@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The metronome trains rhythmic typing: 'config set metronome 240' beats
+// at 240 keystrokes a minute, as a pulse on the status line, a terminal
+// bell, or both ('config set metronome-cue pulse|bell|both'). The beat
+// runs on game time, so it holds still through pauses. Each letter typed
+// is timed against the nearest beat, and the game over screen and history
+// report how many landed on it.
+
+const (
+	// beatWindow is how far either side of a beat, as a fraction of the
+	// beat, a keystroke still counts as on it
+	beatWindow = 0.15
+	// pulseFor is the fraction of each beat the pulse stays lit
+	pulseFor = 0.25
+)
+
+// rhythm tallies keystrokes against the metronome.
+type rhythm struct {
+	keys   int
+	onBeat int
+	// off totals how far each keystroke was from its nearest beat
+	off time.Duration
+}
+
+// onBeatPct is the percentage of keystrokes on the beat.
+func (r rhythm) onBeatPct() float64 {
+	if r.keys == 0 {
+		return 0
+	}
+	return float64(r.onBeat) * 100 / float64(r.keys)
+}
+
+func (r rhythm) meanOff() time.Duration {
+	if r.keys == 0 {
+		return 0
+	}
+	return r.off / time.Duration(r.keys)
+}
+
+// setMetronome is the 'config set metronome' setter.
+func setMetronome(s *settings, value string) error {
+	if value == "off" {
+		s.Metronome = 0
+		return nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 30 || n > 1200 {
+		return fmt.Errorf("metronome is keystrokes a minute from 30 to 1200, or off, not %q", value)
+	}
+	s.Metronome = n
+	return nil
+}
+
+// beat is the time between metronome beats, or zero when it's off.
+func (m model) beat() time.Duration {
+	if m.metronome == 0 {
+		return 0
+	}
+	return time.Minute / time.Duration(m.metronome)
+}
+
+// clock is the game time at now, between frames.
+func (m model) clock(now time.Time) time.Duration {
+	t := m.gameTime()
+	if !m.lastFrame.IsZero() {
+		t += m.scaled(min(max(0, now.Sub(m.lastFrame)), maxFrameGap))
+	}
+	return t
+}
+
+// keyOnBeat times a keystroke at now against the nearest beat.
+func (m model) keyOnBeat(now time.Time) model {
+	b := m.beat()
+	if b == 0 {
+		return m
+	}
+	phase := m.clock(now) % b
+	off := min(phase, b-phase)
+	m.rhythm.keys++
+	m.rhythm.off += off
+	if float64(off) <= beatWindow*float64(b) {
+		m.rhythm.onBeat++
+	}
+	return m
+}
+
+// renderPulse is the status line's metronome widget.
+func (m model) renderPulse() string {
+	b := m.beat()
+	if b == 0 || m.metronomeCue == "bell" {
+		return ""
+	}
+	if m.running() && float64(m.gameTime()%b) < pulseFor*float64(b) {
+		return m.styles.popup.Render("●")
+	}
+	return m.styles.status.Render("○")
+}
+
+// beatMsg is sent on each metronome beat.
+type beatMsg struct{}
+
+// beatCmd waits for the next beat, or a whole beat while the game isn't
+// running.
+func (m model) beatCmd() tea.Cmd {
+	b := m.beat()
+	wait := b
+	if m.running() {
+		wait = b - m.clock(time.Now())%b
+		if wait < b/10 {
+			// The timer fired a little early for the beat just rung
+			wait += b
+		}
+	}
+	return tea.Tick(wait, func(time.Time) tea.Msg { return beatMsg{} })
+}
+
+// ringCmd rings the terminal bell.
+func ringCmd(w io.Writer) tea.Cmd {
+	return func() tea.Msg {
+		fmt.Fprint(w, "\a")
+		return nil
+	}
+}
+
+// onBeatMsg rings the bell for a beat, if it's wanted, and waits for the
+// next.
+func (m model) onBeatMsg() (model, tea.Cmd) {
+	if m.beat() == 0 || m.gameOver {
+		return m, nil
+	}
+	if m.running() && m.metronomeCue != "pulse" {
+		return m, tea.Batch(ringCmd(m.term), m.beatCmd())
+	}
+	return m, m.beatCmd()
+}
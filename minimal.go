@@ -0,0 +1,15 @@
+package main
+
+import "fmt"
+
+// -minimal strips the screen down to the falling words: no explosions,
+// score popups, level banners or backdrop, no input box echoing what's
+// typed, and in place of the status line, legend and key help just a
+// small WPM counter. The typed letters still light up on the words
+// themselves, and pausing works as ever.
+
+// renderMinimalStatus is the minimal screen's only line under the
+// playfield.
+func (m model) renderMinimalStatus() string {
+	return m.styles.help.Render(fmt.Sprintf("%d wpm", wpm(m.wordsTyped+m.partner.words, m.elapsed())))
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// For right-to-left locales the screen around the playfield is mirrored:
+// the status line's widgets run from the right with the input box on the
+// left, side panels sit left of the playfield, and the lines under it are
+// right-aligned. 'config set direction rtl' or ltr forces a direction;
+// auto, the default, follows the locale in $LC_ALL, $LC_MESSAGES or $LANG.
+
+// rtlLanguages are the language codes written right to left.
+var rtlLanguages = []string{"ar", "arc", "ckb", "dv", "fa", "he", "iw", "ps", "sd", "ug", "ur", "yi"}
+
+// setDirection is the 'config set direction' setter.
+func setDirection(s *settings, value string) error {
+	switch value {
+	case "auto":
+		s.Direction = ""
+	case "ltr", "rtl":
+		s.Direction = value
+	default:
+		return fmt.Errorf("direction is auto, ltr or rtl, not %q", value)
+	}
+	return nil
+}
+
+// rightToLeft reports whether direction, or the locale when it's auto,
+// is right to left.
+func rightToLeft(direction string) bool {
+	switch direction {
+	case "rtl":
+		return true
+	case "ltr":
+		return false
+	}
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if locale := os.Getenv(env); locale != "" {
+			return rtlLocale(locale)
+		}
+	}
+	return false
+}
+
+// rtlLocale reports whether a locale like he_IL.UTF-8 is right to left.
+func rtlLocale(locale string) bool {
+	lang, _, _ := strings.Cut(locale, ".")
+	lang, _, _ = strings.Cut(lang, "_")
+	lang, _, _ = strings.Cut(lang, "@")
+	return slices.Contains(rtlLanguages, strings.ToLower(lang))
+}
+
+// alignLine right-aligns a line under the playfield when mirrored.
+func (m model) alignLine(s string) string {
+	if !m.rtl {
+		return s
+	}
+	return lipgloss.PlaceHorizontal(m.fieldWidth(), lipgloss.Right, s)
+}
+
+// indentLines puts indent before each non-empty line of s.
+func indentLines(s, indent string) string {
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		if l != "" {
+			lines[i] = indent + l
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// panelRows lays the side panel out for mirroring: each line padded to
+// the widest, so the playfield beside it stays straight.
+func panelRows(panel []string) []string {
+	width := 0
+	for _, l := range panel {
+		width = max(width, lipgloss.Width(l))
+	}
+	rows := make([]string, len(panel))
+	for i, l := range panel {
+		rows[i] = l + strings.Repeat(" ", width-lipgloss.Width(l))
+	}
+	return rows
+}
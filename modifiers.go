@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// A run's modifiers are whatever sets it apart from the standard game:
+// mutators, a later start, custom rules, training presets and the aids it
+// used. They're listed at the far edge of the line under the status line
+// while playing and on the game over screen, and stamped into the history,
+// the report and leaderboard entries, so a score is only ever compared
+// with the rules it was made under.
+
+// modifiers names the run's modifiers, mutators first in the order given.
+func (m model) modifiers() []string {
+	mods := append([]string(nil), m.mutatorNames...)
+	add := func(on bool, name string) {
+		if on {
+			mods = append(mods, name)
+		}
+	}
+	add(m.startLevel > 1, fmt.Sprintf("level %d start", m.startLevel))
+	add(m.levelTicks > 0, "timed levels")
+	add(m.requireEnter, "enter to finish")
+	add(m.scoring != defaultScoring, "custom scoring")
+	add(m.extraLife != defaultExtraLife, "custom extra lives")
+	add(m.lengths != defaultWordLengths, "custom lengths")
+	// The one-handed preset slows the pace itself
+	add(m.pace != defaultPace && !m.oneHand, "custom pace")
+	add(m.warmUp > 0, "warm-up")
+	add(m.adaptive > 0, "adaptive")
+	add(m.boundary > 0, fmt.Sprintf("boundary +%d", m.boundary))
+	add(m.handicaps != [2]handicap{}, "handicap")
+	add(m.oneHand, "one hand "+m.keyDrill)
+	add(m.keyDrill != "" && !m.oneHand, "keys "+m.keyDrill)
+	add(m.shift, "shift")
+	add(m.slowed(), fmt.Sprintf("slow motion %.0f%%", m.slowMotion*100))
+	add(m.hints > 0, "hints")
+	add(m.evented, "seasonal words")
+	add(m.fed, "chat words")
+	add(m.narrowed, "narrow field")
+	add(m.assisted, "assisted")
+	return mods
+}
+
+// customRules reports whether the difficulty profile changes how a game
+// scores or which words it deals, or mutators change the rules, which
+// keeps it off the leaderboard and out of
+// challenge codes.
+func (m model) customRules() bool {
+	return m.scoring != defaultScoring || m.extraLife != defaultExtraLife || m.lengths != defaultWordLengths ||
+		m.pace != defaultPace || m.warmUp > 0 || m.adaptive > 0 || m.boundary > 0 ||
+		m.handicaps != [2]handicap{} || len(m.mutators) > 0
+}
+
+// renderModifiers is the modifiers line under the status line, or "" for a
+// standard game. It sits at the right edge, or the left when mirrored.
+func (m model) renderModifiers() string {
+	mods := m.modifiers()
+	if len(mods) == 0 {
+		return ""
+	}
+	line := m.styles.help.Render("Modifiers: " + strings.Join(mods, ", "))
+	if m.rtl {
+		return line
+	}
+	return lipgloss.PlaceHorizontal(m.fieldWidth(), lipgloss.Right, line)
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Mutators twist the rules without touching the engine: each can rewrite
+// a word as it spawns, move it as it falls and change what killing it is
+// worth. A mod is a file in this package that registers its mutator from
+// an init function:
+//
+//	func init() { registerMutator("giant", giant{}) }
+//
+//	type giant struct{ noMutation }
+//
+//	func (giant) points(m model, w word, points int) int { return points * 2 }
+//
+// Players pick mutators with -mutators. They're applied in the order
+// given, and hooks may draw from m.rng to stay replayable. Mutated games
+// can't be submitted to the leaderboard.
+type mutator interface {
+	// spawn may change a word before it's placed on screen
+	spawn(m model, w word) word
+	// move may change a word after it has fallen a row
+	move(m model, w word) word
+	// points may change what killing w is worth
+	points(m model, w word, points int) int
+}
+
+// noMutation is a mutator that changes nothing, to embed in mutators that
+// only need some of the hooks.
+type noMutation struct{}
+
+func (noMutation) spawn(m model, w word) word             { return w }
+func (noMutation) move(m model, w word) word              { return w }
+func (noMutation) points(m model, w word, points int) int { return points }
+
+var mutators = map[string]mutator{}
+
+// registerMutator makes mu available to -mutators as name.
+func registerMutator(name string, mu mutator) {
+	if _, dup := mutators[name]; dup {
+		panic("mutator registered twice: " + name)
+	}
+	mutators[name] = mu
+}
+
+// mutatorList is the -mutators flag: a comma separated list of names.
+type mutatorList struct {
+	names []string
+	list  []mutator
+}
+
+func (l *mutatorList) String() string { return strings.Join(l.names, ",") }
+
+func (l *mutatorList) Set(value string) error {
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		mu, ok := mutators[name]
+		if !ok {
+			names := make([]string, 0, len(mutators))
+			for n := range mutators {
+				names = append(names, n)
+			}
+			sort.Strings(names)
+			return fmt.Errorf("unknown mutator %q (have %s)", name, strings.Join(names, ", "))
+		}
+		l.names = append(l.names, name)
+		l.list = append(l.list, mu)
+	}
+	return nil
+}
+
+// mutateSpawn runs the spawn hooks on w, keeping it on the playfield.
+func (m model) mutateSpawn(w word) word {
+	for _, mu := range m.mutators {
+		w = mu.spawn(m, w)
+	}
+	return m.clamp(w)
+}
+
+// mutateMove runs the move hooks on w, keeping it on the playfield.
+func (m model) mutateMove(w word) word {
+	for _, mu := range m.mutators {
+		w = mu.move(m, w)
+	}
+	return m.clamp(w)
+}
+
+// mutatePoints runs the points hooks for killing w.
+func (m model) mutatePoints(w word, points int) int {
+	for _, mu := range m.mutators {
+		points = mu.points(m, w, points)
+	}
+	return points
+}
+
+// The built-in mutators, which double as examples.
+
+func init() {
+	registerMutator("zigzag", zigzag{})
+	registerMutator("long-shot", longShot{})
+	registerMutator("backwards", backwards{})
+}
+
+// zigzag makes words weave from side to side as they fall.
+type zigzag struct{ noMutation }
+
+func (zigzag) move(m model, w word) word {
+	if (w.y/3)%2 == 0 {
+		w.x++
+	} else {
+		w.x--
+	}
+	return w
+}
+
+// longShot triples the points for words of eight letters or more.
+type longShot struct{ noMutation }
+
+func (longShot) points(m model, w word, points int) int {
+	if len(w.text) >= 8 {
+		return points * 3
+	}
+	return points
+}
+
+// backwards deals words spelled back to front.
+type backwards struct{ noMutation }
+
+func (backwards) spawn(m model, w word) word {
+	b := []byte(w.text)
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	w.text = string(b)
+	return w
+}
@@ -0,0 +1,39 @@
+package main
+
+// On a terminal narrower than screenWidth the playfield shrinks to fit, so
+// no word is ever drawn partly off the edge: new words are dealt only if
+// they fit and words already falling are pulled back in on a resize.
+// Replays always run at full width, so a game that was ever dealt words
+// for a narrower playfield can't be submitted or shared.
+
+// minFieldWidth is the narrowest playfield, which still fits any word.
+const minFieldWidth = maxWordLen + 1
+
+// fieldWidth is how many columns of the playfield are in play.
+func (m model) fieldWidth() int {
+	return min(screenWidth, max(m.width, minFieldWidth))
+}
+
+// maxFit is the longest word that fits in the playfield, for a word about
+// to be dealt.
+func (m model) maxFit() (model, int) {
+	fw := m.fieldWidth()
+	if fw < screenWidth {
+		m.narrowed = true
+	}
+	return m, fw - 1
+}
+
+// clampWords pulls the falling words back inside the playfield.
+func (m model) clampWords() model {
+	for i := range m.words {
+		m.words[i] = m.clamp(m.words[i])
+	}
+	return m
+}
+
+// clamp pulls w inside the playfield.
+func (m model) clamp(w word) word {
+	w.x = max(0, min(w.x, m.fieldWidth()-m.shownWidth(w.text)))
+	return w
+}
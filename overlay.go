@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// The overlay feed serves game events as server-sent events at
+// http://ADDR/events, for stream overlays (an OBS browser source, say) to
+// react to kills, combos, level ups and lost lives. Each event's data is a
+// gameEvent as JSON and its SSE event name is the event's type.
+
+func (b *eventBus) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// Overlays are pages served from elsewhere
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	flusher.Flush()
+
+	events, unsubscribe := b.subscribe()
+	defer unsubscribe()
+	logger.Info("overlay connected", "remote", r.RemoteAddr)
+	for {
+		select {
+		case e := <-events:
+			data, err := json.Marshal(e)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func serveOverlay(addr string, events *eventBus) {
+	mux := http.NewServeMux()
+	mux.Handle("/events", events)
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			fmt.Fprintf(os.Stderr, "overlay server: %v\n", err)
+		}
+	}()
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Words fall and spawn on clocks of their own. Every step the fall clock
+// gains the fall rate and the spawn clock the spawn rate, and each whole
+// unit either has gathered is a row fallen or a chance for a word to
+// spawn. Both rates are per second at level 1 and grow by a set amount a
+// level, so spawn cadence and fall speed can be tuned apart in the
+// difficulty profile. One of each a second is the classic game.
+type pace struct {
+	Fall        float64 `json:"fall"`
+	FallGrowth  float64 `json:"fall_growth"`
+	Spawn       float64 `json:"spawn"`
+	SpawnGrowth float64 `json:"spawn_growth"`
+}
+
+var defaultPace = pace{Fall: 1, Spawn: 1}
+
+// maxSpawns bounds the spawn checks in a step; the screen holds no more
+// words than that anyway.
+const maxSpawns = 8
+
+// perStep is the rows fallen and spawn checks made each step at level.
+func (p pace) perStep(level int) (fall, spawn float64) {
+	l, s := float64(max(0, level-1)), tickRate.Seconds()
+	fall = min((p.Fall+p.FallGrowth*l)*s, gameHeight)
+	spawn = min((p.Spawn+p.SpawnGrowth*l)*s, maxSpawns)
+	return fall, spawn
+}
+
+// fall moves the words down as many rows as the fall clock has gathered.
+func (m model) fall() model {
+	rows, _ := m.pace.perStep(m.level)
+	m.fallClock += rows * m.paceRate()
+	for ; m.fallClock >= 1 && !m.gameOver; m.fallClock-- {
+		m = m.moveWords()
+	}
+	return m
+}
+
+// spawn gives words a chance to appear for every check the spawn clock
+// has gathered.
+func (m model) spawn() model {
+	_, checks := m.pace.perStep(m.level)
+	m.spawnClock += checks * m.paceRate()
+	for ; m.spawnClock >= 1; m.spawnClock-- {
+		m = m.maybeAddWord()
+	}
+	return m
+}
+
+// paceKey is a 'config set' setter for one of the rates. Rates must be
+// above zero, growth may be zero.
+func paceKey(name string, field func(s *settings) *float64, growth bool) func(s *settings, value string) error {
+	return func(s *settings, value string) error {
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil || f < 0 || !growth && f == 0 || math.IsInf(f, 0) {
+			if growth {
+				return fmt.Errorf("%s is per second per level, 0 or more, not %q", name, value)
+			}
+			return fmt.Errorf("%s is per second, above 0, not %q", name, value)
+		}
+		*field(s) = f
+		return nil
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// An effects level scales explosions: how many particles they throw and
+// how long those last. All explosions on screen also share a budget of
+// particles, scaled the same way, and an explosion past it is cut down to
+// what's left, so a chain of kills can't bog down a slow terminal.
+type effectsLevel struct {
+	name        string
+	count, life float64
+}
+
+var effectsLevels = map[string]effectsLevel{
+	"low":  {"low", 0.3, 0.5},
+	"med":  {"med", 0.6, 0.75},
+	"high": {"high", 1, 1},
+}
+
+const (
+	defaultEffects = "high"
+	// maxParticles is the budget at high effects
+	maxParticles = 300
+)
+
+func parseEffects(name string) (effectsLevel, error) {
+	lvl, ok := effectsLevels[name]
+	if !ok {
+		return lvl, fmt.Errorf("effects are low, med or high, not %q", name)
+	}
+	return lvl, nil
+}
+
+// particleCount is the number of particles on screen.
+func (m model) particleCount() int {
+	n := 0
+	for _, e := range m.effects {
+		n += len(e.particles)
+	}
+	return n
+}
+
+// addEffect puts an explosion on screen, scaled to the effects level and
+// kept within the particle budget. The particles kept are spread around
+// the burst so it stays round.
+func (m model) addEffect(e effect) model {
+	if m.minimal {
+		return m
+	}
+	lvl := m.effectsLevel
+	if lvl.name == "" {
+		lvl = effectsLevels[defaultEffects]
+	}
+	n := int(float64(len(e.particles))*lvl.count + 0.5)
+	n = min(n, int(maxParticles*lvl.count)-m.particleCount())
+	if n <= 0 {
+		return m
+	}
+	kept := make([]particle, n)
+	for i := range kept {
+		kept[i] = e.particles[i*len(e.particles)/n]
+		kept[i].lifetime = time.Duration(float64(kept[i].lifetime) * lvl.life)
+		kept[i].life = kept[i].lifetime
+	}
+	m.effects = append(m.effects, effect{particles: kept})
+	return m
+}
+
+// Particles come in colors, each fading through fadeSteps shades of
+// particleRamps as their lifetime runs out.
+const (
+	sparkFire = iota
+	sparkHeart
+	particleColors
+)
+
+const fadeSteps = 4
+
+// kind is the cell kind the particle is drawn with, by its color and how
+// much of its life is left.
+func (p particle) kind() cellKind {
+	step := fadeSteps - 1
+	if p.life > 0 {
+		step = fadeSteps - 1 - int(float64(p.lifetime)/float64(p.life)*fadeSteps)
+	}
+	return cellParticle + cellKind(int(p.color)*fadeSteps+min(max(step, 0), fadeSteps-1))
+}
@@ -0,0 +1,42 @@
+package main
+
+import "fmt"
+
+// A popup floats the points a kill earned up from where the word died,
+// calling out the height bonus so early kills feel rewarded.
+
+// popupLife is how many ticks a popup stays up.
+const popupLife = 2
+
+type popup struct {
+	text string
+	x, y int
+	life int
+}
+
+// addPopup shows points, bonus included, over the word w.
+func (m model) addPopup(w word, points, bonus int) model {
+	if m.minimal {
+		return m
+	}
+	text := fmt.Sprintf("+%d", points)
+	if bonus > 0 {
+		text += fmt.Sprintf(" (%d high)", bonus)
+	}
+	x := min(max(0, w.x), screenWidth-len(text))
+	m.popups = append(m.popups, popup{text: text, x: max(0, x), y: w.y, life: popupLife})
+	return m
+}
+
+// updatePopups floats every popup up a row and drops the expired ones.
+func (m model) updatePopups() model {
+	kept := m.popups[:0]
+	for _, p := range m.popups {
+		p.y--
+		if p.life--; p.life > 0 && p.y >= 0 {
+			kept = append(kept, p)
+		}
+	}
+	m.popups = kept
+	return m
+}
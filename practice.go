@@ -0,0 +1,247 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Practice goals are standing targets kept across games, set with 'goals
+// add': wpm=60/5 to average 60 WPM over the last five games, or daily=10m
+// to play ten minutes a day. Progress is worked out from the stats history
+// and shown on the title screen, with the streak of consecutive days
+// played.
+
+var practiceKinds = []string{"wpm", "daily"}
+
+type practiceGoal struct {
+	Kind string `json:"kind"`
+	// WPM is the average to reach over the last Games games
+	WPM   int `json:"wpm,omitempty"`
+	Games int `json:"games,omitempty"`
+	// Daily is how long to play each day
+	Daily time.Duration `json:"daily,omitempty"`
+}
+
+func (g practiceGoal) String() string {
+	if g.Kind == "daily" {
+		return fmt.Sprintf("play %s a day", minutes(g.Daily))
+	}
+	return fmt.Sprintf("average %d WPM over %d games", g.WPM, g.Games)
+}
+
+// parsePracticeGoal parses a 'goals add' argument.
+func parsePracticeGoal(s string) (practiceGoal, error) {
+	kind, value, ok := strings.Cut(s, "=")
+	if !ok {
+		return practiceGoal{}, errors.New("expected KIND=VALUE, e.g. wpm=60/5 or daily=10m")
+	}
+	switch kind {
+	case "wpm":
+		rate, games, _ := strings.Cut(value, "/")
+		g := practiceGoal{Kind: kind, Games: 1}
+		var err error
+		if g.WPM, err = strconv.Atoi(rate); err != nil || g.WPM <= 0 {
+			return g, fmt.Errorf("wpm goal needs a positive WPM, not %q", rate)
+		}
+		if games != "" {
+			if g.Games, err = strconv.Atoi(games); err != nil || g.Games <= 0 {
+				return g, fmt.Errorf("wpm goal needs a positive number of games, not %q", games)
+			}
+		}
+		return g, nil
+	case "daily":
+		d, err := time.ParseDuration(value)
+		if err != nil || d < time.Minute {
+			return practiceGoal{}, fmt.Errorf("daily goal is a duration of at least a minute, like 10m, not %q", value)
+		}
+		return practiceGoal{Kind: kind, Daily: d}, nil
+	}
+	return practiceGoal{}, fmt.Errorf("unknown goal %q (want %s)", kind, strings.Join(practiceKinds, " or "))
+}
+
+func goalsPath(profile string) (string, error) {
+	dir, err := profileDir(profile)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "goals.json"), nil
+}
+
+// loadGoals returns the profile's practice goals.
+func loadGoals(profile string) ([]practiceGoal, error) {
+	path, err := goalsPath(profile)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var goals []practiceGoal
+	return goals, json.Unmarshal(data, &goals)
+}
+
+func saveGoals(profile string, goals []practiceGoal) error {
+	path, err := goalsPath(profile)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(goals, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// goalStatus is how a goal stands, as the title screen shows it.
+type goalStatus struct {
+	text string
+	met  bool
+}
+
+// day is the local date of t, for counting days played.
+func day(t time.Time) string {
+	return t.Local().Format(time.DateOnly)
+}
+
+// dailyTime totals the time played on each day.
+func dailyTime(sessions []session) map[string]time.Duration {
+	played := map[string]time.Duration{}
+	for _, s := range sessions {
+		played[day(s.Time)] += s.Duration
+	}
+	return played
+}
+
+// streak counts the consecutive days up to now with at least least played.
+// A day not yet played doesn't break the streak until it's over.
+func streak(played map[string]time.Duration, least time.Duration, now time.Time) int {
+	n := 0
+	d := now
+	if played[day(d)] < max(least, 1) {
+		d = d.AddDate(0, 0, -1)
+	}
+	for ; played[day(d)] >= max(least, 1); d = d.AddDate(0, 0, -1) {
+		n++
+	}
+	return n
+}
+
+// progress works out how g stands against the history at now.
+func (g practiceGoal) progress(sessions []session, played map[string]time.Duration, now time.Time) goalStatus {
+	switch g.Kind {
+	case "daily":
+		today := played[day(now)]
+		st := goalStatus{
+			text: fmt.Sprintf("%s of %s today", minutes(today), minutes(g.Daily)),
+			met:  today >= g.Daily,
+		}
+		if n := streak(played, g.Daily, now); n > 0 {
+			st.text += fmt.Sprintf(", met %d %s running", n, plural(n, "day"))
+		}
+		return st
+	case "wpm":
+		recent := sessions[max(0, len(sessions)-g.Games):]
+		if len(recent) < g.Games {
+			return goalStatus{text: fmt.Sprintf("%d of %d games played", len(recent), g.Games)}
+		}
+		total := 0
+		for _, s := range recent {
+			total += s.WPM
+		}
+		avg := total / len(recent)
+		return goalStatus{text: fmt.Sprintf("averaging %d", avg), met: avg >= g.WPM}
+	}
+	return goalStatus{}
+}
+
+// practiceStatus is the title screen's goal lines for the profile, with the
+// practice streak first.
+func practiceStatus(profile string, now time.Time) ([]goalStatus, error) {
+	goals, err := loadGoals(profile)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := loadHistory(profile)
+	if err != nil {
+		return nil, err
+	}
+	played := dailyTime(sessions)
+	var lines []goalStatus
+	if n := streak(played, 0, now); n > 0 {
+		lines = append(lines, goalStatus{text: fmt.Sprintf("Practice streak: %d %s", n, plural(n, "day"))})
+	}
+	for _, g := range goals {
+		st := g.progress(sessions, played, now)
+		st.text = fmt.Sprintf("Goal: %s (%s)", g, st.text)
+		lines = append(lines, st)
+	}
+	return lines, nil
+}
+
+// minutes formats d to the minute, as 10 min.
+func minutes(d time.Duration) string {
+	return fmt.Sprintf("%d min", int(d.Round(time.Minute).Minutes()))
+}
+
+// plural is word, with an s unless n is 1.
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
+
+// runGoals handles 'goals', 'goals add SPEC' and 'goals remove N'.
+func runGoals(args []string) error {
+	goals, err := loadGoals("")
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		sessions, err := loadHistory("")
+		if err != nil {
+			return err
+		}
+		now, played := time.Now(), dailyTime(sessions)
+		n := streak(played, 0, now)
+		fmt.Printf("Practice streak: %d %s\n", n, plural(n, "day"))
+		if len(goals) == 0 {
+			fmt.Printf("No goals set; add one with '%s goals add wpm=60/5' or 'goals add daily=10m'.\n", progName())
+		}
+		for i, g := range goals {
+			st := g.progress(sessions, played, now)
+			if st.met {
+				st.text += ", done"
+			}
+			fmt.Printf("  %d. %s (%s)\n", i+1, g, st.text)
+		}
+		return nil
+	}
+	switch {
+	case args[0] == "add" && len(args) == 2:
+		g, err := parsePracticeGoal(args[1])
+		if err != nil {
+			return err
+		}
+		goals = append(goals, g)
+	case args[0] == "remove" && len(args) == 2:
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 1 || n > len(goals) {
+			return fmt.Errorf("no goal %q; goals are numbered from 1 in the order 'goals' lists them", args[1])
+		}
+		goals = append(goals[:n-1], goals[n:]...)
+	default:
+		return fmt.Errorf("usage: %s goals [add KIND=VALUE | remove N]", progName())
+	}
+	return saveGoals("", goals)
+}
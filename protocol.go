@@ -0,0 +1,192 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// The wire protocol
+//
+// Every multiplayer mode (versus, the lobby, team matches and classrooms)
+// speaks the same protocol over TCP: netMsg values as newline-delimited
+// JSON, with Type saying what each one is. The message types of each mode
+// are listed where the mode is: versus.go for a match, lobby.go and
+// team.go for the lobby, classroom.go for a class.
+//
+// Versions. The first message from a client, "hello" (or "join" to a
+// classroom, or "resume"), carries Version. A side that speaks another
+// version answers "error" with Text explaining the mismatch and hangs up,
+// so players on different releases are told to update instead of seeing a
+// garbled match. Version 1 is everything before versions were sent, and
+// arrives as 0; version 3 added pongs and the stamps on garbage.
+//
+// Heartbeat. Both sides send "ping" (At) every heartbeatEvery and answer
+// every ping with "pong" (At, Echo), and a side that hears nothing at all
+// for heartbeatTimeout treats the connection as dead. Pings and pongs are
+// never passed on: recv handles them, see latency.go.
+//
+// Reconnection. A match gets a token: the host's "hello" carries one in a
+// direct match, and the lobby hands one out in "welcome" after "hello". If
+// the connection drops mid-match, the side that dialed dials again within
+// resumeGrace and sends "resume" (Version, Token); the other side answers
+// "resumed" and the match carries on over the new connection. Meanwhile
+// the opponent is shown as reconnecting, through "reconnecting" and
+// "resumed" messages when the lobby is in between. After resumeGrace the
+// dropped player forfeits as before. Messages sent while the connection
+// was down are lost; "state" is resent every step anyway.
+
+const (
+	protocolVersion  = 3
+	heartbeatEvery   = 5 * time.Second
+	heartbeatTimeout = 3 * heartbeatEvery
+	resumeGrace      = 30 * time.Second
+)
+
+// newToken returns a random token for resuming a match.
+func newToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// versionError is the Text of the "error" sent for a version mismatch.
+func versionError(theirs int) string {
+	if theirs == 0 {
+		theirs = 1
+	}
+	return fmt.Sprintf("incompatible versions: this side speaks protocol %d (%s) and the other side %d; both players need the same release",
+		protocolVersion, version, theirs)
+}
+
+// checkVersion answers a first message from another version with an
+// error, and reports whether the versions match.
+func (p *peer) checkVersion(msg netMsg) bool {
+	if msg.Version == protocolVersion {
+		return true
+	}
+	p.send(netMsg{Type: "error", Text: versionError(msg.Version)})
+	return false
+}
+
+// recv reads the next message, handling pings and pongs itself, and fails
+// once nothing has arrived for heartbeatTimeout.
+func (p *peer) recv() (netMsg, error) {
+	for {
+		p.mu.Lock()
+		conn, dec := p.conn, p.dec
+		p.mu.Unlock()
+		var msg netMsg
+		conn.SetReadDeadline(time.Now().Add(heartbeatTimeout))
+		if err := dec.Decode(&msg); err != nil {
+			return msg, err
+		}
+		switch msg.Type {
+		case "ping":
+			p.send(netMsg{Type: "pong", At: stamp(time.Now()), Echo: msg.At})
+		case "pong":
+			p.pong(msg, time.Now())
+		default:
+			return msg, nil
+		}
+	}
+}
+
+// heartbeat pings the other side until the connection is closed for good.
+// Failed pings while the connection is being resumed don't stop it.
+func (p *peer) heartbeat() {
+	for range time.Tick(heartbeatEvery) {
+		if err := p.send(netMsg{Type: "ping", At: stamp(time.Now())}); err != nil && !p.resuming.Load() {
+			return
+		}
+	}
+}
+
+// swap carries on over np in place of the dropped connection, keeping its
+// decoder so nothing it already buffered is lost.
+func (p *peer) swap(np *peer) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.conn.Close()
+	p.conn, p.enc, p.dec = np.conn, np.enc, np.dec
+}
+
+// close hangs up whichever connection p is on.
+func (p *peer) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.conn.Close()
+}
+
+// resume reconnects a dropped match within resumeGrace, if p can. The old
+// connection is closed first, so the other side notices the drop too.
+func (p *peer) resume() error {
+	if p.reconnect == nil || p.token == "" {
+		return errors.New("match can't be resumed")
+	}
+	p.resuming.Store(true)
+	defer p.resuming.Store(false)
+	p.close()
+	np, err := p.reconnect(p.token, time.Now().Add(resumeGrace))
+	if err != nil {
+		return err
+	}
+	p.swap(np)
+	return nil
+}
+
+// redial is the reconnect for the side that dialed addr: dial again until
+// the deadline and resume with the token.
+func redial(addr string) func(token string, deadline time.Time) (*peer, error) {
+	return func(token string, deadline time.Time) (*peer, error) {
+		for time.Now().Before(deadline) {
+			conn, err := net.DialTimeout("tcp", addr, time.Until(deadline))
+			if err != nil {
+				time.Sleep(time.Second)
+				continue
+			}
+			p := newPeer(conn)
+			var reply netMsg
+			if err = p.send(netMsg{Type: "resume", Version: protocolVersion, Token: token}); err == nil {
+				reply, err = p.recv()
+			}
+			if err == nil && reply.Type == "resumed" {
+				return p, nil
+			}
+			conn.Close()
+			if reply.Type == "error" {
+				return nil, errors.New(reply.Text)
+			}
+			time.Sleep(time.Second)
+		}
+		return nil, errors.New("opponent didn't reconnect in time")
+	}
+}
+
+// reaccept is the reconnect for the side that listened on ln: wait until
+// the deadline for a connection that resumes with the token.
+func reaccept(ln *net.TCPListener) func(token string, deadline time.Time) (*peer, error) {
+	return func(token string, deadline time.Time) (*peer, error) {
+		ln.SetDeadline(deadline)
+		defer ln.SetDeadline(time.Time{})
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return nil, errors.New("opponent didn't reconnect in time")
+			}
+			p := newPeer(conn)
+			msg, err := p.recv()
+			if err == nil && p.checkVersion(msg) {
+				if msg.Type == "resume" && msg.Token == token {
+					p.send(netMsg{Type: "resumed"})
+					return p, nil
+				}
+				p.send(netMsg{Type: "error", Text: "a match is already in progress here"})
+			}
+			conn.Close()
+		}
+	}
+}
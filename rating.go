@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// Every profile carries an Elo rating for networked versus matches.
+const (
+	startRating = 1200
+	// eloK is how far one match can move a rating; new players move
+	// faster until they have played provisionalGames
+	eloK             = 32
+	provisionalK     = 48
+	provisionalGames = 10
+)
+
+type rating struct {
+	Rating int `json:"rating"`
+	Games  int `json:"games"`
+}
+
+func ratingPath(profile string) (string, error) {
+	dir, err := profileDir(profile)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "rating.json"), nil
+}
+
+// loadRating returns the profile's rating, or the starting rating if it
+// has never played a match.
+func loadRating(profile string) (rating, error) {
+	r := rating{Rating: startRating}
+	path, err := ratingPath(profile)
+	if err != nil {
+		return r, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return r, err
+	}
+	return r, json.Unmarshal(data, &r)
+}
+
+func saveRating(profile string, r rating) error {
+	path, err := ratingPath(profile)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// update is the rating after a match against an opponent rated opp.
+func (r rating) update(opp int, won bool) rating {
+	expected := 1 / (1 + math.Pow(10, float64(opp-r.Rating)/400))
+	score := 0.0
+	if won {
+		score = 1
+	}
+	k := float64(eloK)
+	if r.Games < provisionalGames {
+		k = provisionalK
+	}
+	r.Rating += int(math.Round(k * (score - expected)))
+	r.Games++
+	return r
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// -record writes the game to an asciinema cast under recordings/ in the
+// data directory as it's played, a frame for every tick that changed the
+// screen. Frames go straight to the file, so a long game costs no memory
+// and a crash keeps what was played. On the game over screen, r turns the
+// cast into a GIF when the agg encoder (https://github.com/asciinema/agg)
+// is installed.
+
+// recorder streams the frames of one game to its cast file.
+type recorder struct {
+	mu sync.Mutex
+	// path is the cast file, created with the first frame
+	path  string
+	file  *os.File
+	enc   *json.Encoder
+	start time.Time
+	last  string
+	err   error
+	// agg is the GIF encoder, or "" when it isn't installed
+	agg string
+}
+
+// newRecorder sets up a recording in the data directory's recordings
+// folder.
+func newRecorder() (*recorder, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return nil, err
+	}
+	dir = filepath.Join(dir, "recordings")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	agg, _ := exec.LookPath("agg")
+	return &recorder{path: filepath.Join(dir, "run-"+time.Now().Format("20060102-150405")+".cast"), agg: agg}, nil
+}
+
+// record writes the frame shown at at, unless it is unchanged from the last
+// one. The first frame creates the file. A failed write stops the
+// recording rather than the game.
+func (r *recorder) record(frame string, at time.Time, width, height int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil || frame == r.last {
+		return
+	}
+	if r.file == nil {
+		r.err = r.open(at, width, height)
+	}
+	if r.err == nil {
+		r.last = frame
+		// Each frame is a full screen: home the cursor and clear first
+		out := "\x1b[H\x1b[2J" + strings.ReplaceAll(frame, "\n", "\r\n")
+		r.err = r.enc.Encode([]any{at.Sub(r.start).Seconds(), "o", out})
+	}
+	if r.err != nil {
+		logger.Warn("recording stopped", "path", r.path, "err", r.err)
+	}
+}
+
+// open creates the cast file and writes asciinema's v2 header; each frame
+// after it is a [seconds, "o", output] event.
+func (r *recorder) open(start time.Time, width, height int) error {
+	if width <= 0 || height <= 0 {
+		width, height = screenWidth, screenHeight
+	}
+	file, err := os.Create(r.path)
+	if err != nil {
+		return err
+	}
+	r.file, r.enc, r.start = file, json.NewEncoder(file), start
+	return r.enc.Encode(map[string]any{
+		"version":   2,
+		"width":     width,
+		"height":    height,
+		"timestamp": start.Unix(),
+		"title":     "Letter Invaders",
+		"env":       map[string]string{"TERM": "xterm-256color"},
+	})
+}
+
+// written is the cast's path once a frame has been saved to it, or "".
+func (r *recorder) written() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return ""
+	}
+	return r.path
+}
+
+// close finishes the cast file.
+func (r *recorder) close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	r.err = errors.New("recording closed")
+	return err
+}
+
+type recordingSavedMsg struct {
+	gif string
+	err error
+}
+
+// saveGIFCmd converts the cast written so far to a GIF beside it.
+func saveGIFCmd(r *recorder) tea.Cmd {
+	return func() tea.Msg {
+		cast := r.written()
+		gif := strings.TrimSuffix(cast, ".cast") + ".gif"
+		if out, err := exec.Command(r.agg, cast, gif).CombinedOutput(); err != nil {
+			logger.Warn("gif export", "err", err, "output", string(out))
+			return recordingSavedMsg{err: err}
+		}
+		logger.Info("recording saved", "cast", cast, "gif", gif)
+		return recordingSavedMsg{gif: gif}
+	}
+}
+
+// recordingStatus describes the recording for the game over screen.
+func (m model) recordingStatus() string {
+	cast := m.recording.written()
+	switch {
+	case cast == "":
+		return ""
+	case m.saving:
+		return "Saving GIF..."
+	case m.saved.err != nil:
+		return fmt.Sprintf("Recording saved to %s; GIF not saved: %v", cast, m.saved.err)
+	case m.saved.gif != "":
+		return "Recording saved to " + cast + " and " + m.saved.gif
+	}
+	return "Recording saved to " + cast
+}
+
+// giffable reports whether r on the game over screen can make a GIF.
+func (m model) giffable() bool {
+	return m.recording != nil && m.recording.agg != "" && m.recording.written() != ""
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// With -watch the dictionary and settings files are polled for changes,
+// which is handy when iterating on a custom word pack. A change is loaded
+// straight away but only takes effect at the next level up, so the words
+// on screen don't change under the player. Reloading makes the game
+// impossible to replay from its seed, so it can't be submitted or shared.
+
+// watchEvery is how often watched files are checked.
+const watchEvery = 2 * time.Second
+
+// reloadMsg carries the reloaded dictionary or settings; either may be
+// nil if only the other changed.
+type reloadMsg struct {
+	dict  *dictionary
+	prefs *settings
+}
+
+// watchFiles sends p a reloadMsg whenever dictPath or the settings file
+// changes. A file that fails to load is logged and the old one kept.
+func watchFiles(p *tea.Program, dictPath string) {
+	prefsPath, err := settingsPath()
+	if err != nil {
+		logger.Warn("not watching settings", "err", err)
+	}
+	modTime := func(path string) time.Time {
+		if fi, err := os.Stat(path); err == nil {
+			return fi.ModTime()
+		}
+		return time.Time{}
+	}
+	dictTime, prefsTime := modTime(dictPath), modTime(prefsPath)
+	for range time.Tick(watchEvery) {
+		var msg reloadMsg
+		if t := modTime(dictPath); !t.Equal(dictTime) {
+			dictTime = t
+			if d, err := loadDictionary(dictPath); err != nil || d.len() == 0 {
+				logger.Warn("dictionary reload failed", "dict", dictPath, "err", err)
+			} else {
+				msg.dict = d
+			}
+		}
+		if t := modTime(prefsPath); prefsPath != "" && !t.Equal(prefsTime) {
+			prefsTime = t
+			if s, err := loadSettings(); err != nil {
+				logger.Warn("settings reload failed", "err", err)
+			} else {
+				msg.prefs = &s
+			}
+		}
+		if msg.dict != nil || msg.prefs != nil {
+			p.Send(msg)
+		}
+	}
+}
+
+// queueReload holds msg until the next level up, merging it with any
+// reload already waiting.
+func (m model) queueReload(msg reloadMsg) model {
+	if m.reload != nil {
+		if msg.dict == nil {
+			msg.dict = m.reload.dict
+		}
+		if msg.prefs == nil {
+			msg.prefs = m.reload.prefs
+		}
+	}
+	m.reload = &msg
+	return m
+}
+
+// applyReload switches to the waiting dictionary and settings, if any.
+func (m model) applyReload() model {
+	if m.reload == nil {
+		return m
+	}
+	if d := m.reload.dict; d != nil {
+		var err error
+		if m.coop {
+			var pools [2]*dictionary
+			if pools, err = coopPools(d); err == nil {
+				m.pools = pools
+			}
+		}
+		if err != nil {
+			logger.Warn("dictionary reload skipped", "err", err)
+		} else {
+			m.dict = d
+			m.bags = [2]bag{}
+			logger.Info("dictionary reloaded", "words", d.len())
+		}
+	}
+	if m.reload.prefs != nil {
+		m = m.withSettings(*m.reload.prefs)
+		logger.Info("settings reloaded")
+	}
+	m.reload = nil
+	m.reloaded = true
+	return m
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// A renderer decides how the playfield becomes text. The engine fills the
+// frame and lays out the screen; the renderer writes each row of the field
+// and gets a last look at the finished screen. Renderers are picked with
+// -renderer and come with the styles they're meant to be used with.
+type renderer interface {
+	// row writes row y of the playfield
+	row(b *strings.Builder, m model, y int)
+	// finish adjusts the whole screen before it's shown
+	finish(view string) string
+}
+
+// renderers are the -renderer choices. Each builds its styles from the
+// lipgloss renderer of the terminal it draws to.
+var renderers = map[string]func(r *lipgloss.Renderer) (renderer, *styles){
+	"ansi": func(r *lipgloss.Renderer) (renderer, *styles) {
+		return ansiRenderer{}, newStyles(r)
+	},
+	"crt": func(r *lipgloss.Renderer) (renderer, *styles) {
+		return newCRTRenderer(r), newStyles(r)
+	},
+	"plain": func(*lipgloss.Renderer) (renderer, *styles) {
+		r := lipgloss.NewRenderer(io.Discard)
+		r.SetColorProfile(termenv.Ascii)
+		return plainRenderer{}, newStyles(r)
+	},
+}
+
+func rendererNames() []string {
+	names := make([]string, 0, len(renderers))
+	for name := range renderers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// withRenderer switches the game to a named renderer.
+func (m model) withRenderer(name string, r *lipgloss.Renderer) (model, error) {
+	build, ok := renderers[name]
+	if !ok {
+		return m, fmt.Errorf("unknown renderer %q (have %s)", name, strings.Join(rendererNames(), ", "))
+	}
+	m.renderer, m.styles = build(r)
+	return m, nil
+}
+
+// ansiRenderer colors each cell of the field by its kind.
+type ansiRenderer struct{}
+
+func (ansiRenderer) row(b *strings.Builder, m model, y int) {
+	m.renderRuns(b, y)
+}
+
+func (ansiRenderer) finish(view string) string {
+	return view
+}
+
+// plainRenderer draws in plain ASCII for terminals without color or
+// Unicode, screen readers and logs. Typed letters, and the next letter of
+// a hinted word, are shown in capitals.
+type plainRenderer struct{}
+
+func (plainRenderer) row(b *strings.Builder, m model, y int) {
+	kinds := m.frame.kinds[y]
+	for x, r := range m.frame.cells[y][:m.fieldWidth()] {
+		switch {
+		case kinds[x] == cellMatched, kinds[x] == cellHint:
+			r = toUpper(r)
+		case r == wideCell:
+			r = ' '
+		case r > '~':
+			// Particles and the like, one cell wide whatever they were
+			r = '*'
+		}
+		b.WriteRune(r)
+	}
+}
+
+func (plainRenderer) finish(view string) string {
+	return plainGlyphs.Replace(view)
+}
+
+func toUpper(r rune) rune {
+	if r >= 'a' && r <= 'z' {
+		return r - 'a' + 'A'
+	}
+	return r
+}
+
+// plainGlyphs are ASCII stand-ins for the symbols used around the game.
+var plainGlyphs = strings.NewReplacer(
+	"─", "-", "│", "|",
+	"┌", "+", "┐", "+", "└", "+", "┘", "+",
+	"╭", "+", "╮", "+", "╰", "+", "╯", "+",
+	"♥", "*", "♡", "-", "×", "x", "■", "#",
+	"▰", "#", "▱", "-", "⏎", "<enter>",
+	"▁", ".", "▂", ".", "▃", ".", "▄", ":", "▅", ":", "▆", ":", "▇", ":", "█", "#",
+	"·", "-", "✓", "+", "✗", "x",
+)
@@ -0,0 +1,632 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The leaderboard server also keeps an archive of replays. A replay is a
+// leaderboard submission with its keystroke log kept: 'u' on the game over
+// screen uploads one, and the 'replays' command browses the archive, best
+// first, and plays any of them back in the terminal, so the world record
+// run can be watched keystroke by keystroke. Playing back needs the
+// dictionary the game was played with. Versus and classroom games depend
+// on other players and can't be replayed.
+
+// replayModes are the modes the archive takes.
+var replayModes = []string{"solo", "coop", "weekly"}
+
+// replaySpeeds are the playback speeds, cycled with + and -.
+var replaySpeeds = []int{1, 2, 4, 8}
+
+// replaySummary is how the archive lists a replay.
+type replaySummary struct {
+	ID       string    `json:"id"`
+	Name     string    `json:"name"`
+	Mode     string    `json:"mode"`
+	Score    int       `json:"score"`
+	Level    int       `json:"level"`
+	Words    int       `json:"words"`
+	WPM      int       `json:"wpm"`
+	Accuracy float64   `json:"accuracy"`
+	Ticks    int       `json:"ticks"`
+	Dict     string    `json:"dict"`
+	Time     time.Time `json:"time"`
+	// Rank is filled in when the summary is served
+	Rank int `json:"rank,omitempty"`
+}
+
+// replayPage is one page of the archive as served by GET /replays.
+type replayPage struct {
+	Mode    string          `json:"mode"`
+	Offset  int             `json:"offset"`
+	Total   int             `json:"total"`
+	Entries []replaySummary `json:"entries"`
+}
+
+func (e boardEntry) replaySummary(id string) replaySummary {
+	return replaySummary{ID: id, Name: e.Name, Mode: e.Mode, Score: e.Score, Level: e.Level, Words: e.Words,
+		WPM: e.WPM, Accuracy: e.Accuracy, Ticks: e.Ticks, Dict: e.Dict, Time: e.Time}
+}
+
+// Server
+
+// replayStore keeps each replay in a file of its own, named by its id, and
+// the list of them in memory, best first.
+type replayStore struct {
+	mu      sync.Mutex
+	dir     string
+	board   *boardStore
+	replays []replaySummary
+}
+
+func openReplayStore(dir string, board *boardStore) (*replayStore, error) {
+	s := &replayStore{dir: dir, board: board}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var e boardEntry
+		if err := json.Unmarshal(data, &e); err != nil {
+			// Skip a file torn by a crash rather than refusing to start
+			continue
+		}
+		s.replays = append(s.replays, e.replaySummary(strings.TrimSuffix(filepath.Base(path), ".json")))
+	}
+	sort.SliceStable(s.replays, func(i, j int) bool { return s.replays[i].Score > s.replays[j].Score })
+	return s, nil
+}
+
+// add stores e and lists it after any equal scores, returning its summary
+// ranked within its mode.
+func (s *replayStore) add(e boardEntry) (replaySummary, error) {
+	id := randomHex(6)
+	data, err := json.Marshal(e)
+	if err != nil {
+		return replaySummary{}, err
+	}
+	if err := os.WriteFile(filepath.Join(s.dir, id+".json"), data, 0o644); err != nil {
+		return replaySummary{}, err
+	}
+	sum := e.replaySummary(id)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := sort.Search(len(s.replays), func(i int) bool { return s.replays[i].Score < e.Score })
+	s.replays = append(s.replays, replaySummary{})
+	copy(s.replays[i+1:], s.replays[i:])
+	s.replays[i] = sum
+	sum.Rank = 1
+	for _, r := range s.replays[:i] {
+		if r.Mode == sum.Mode {
+			sum.Rank++
+		}
+	}
+	return sum, nil
+}
+
+func (s *replayStore) page(mode string, offset, limit int) replayPage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := replayPage{Mode: mode, Offset: offset, Entries: []replaySummary{}}
+	for _, r := range s.replays {
+		if r.Mode != mode {
+			continue
+		}
+		p.Total++
+		if p.Total > offset && len(p.Entries) < limit {
+			r.Rank = p.Total
+			p.Entries = append(p.Entries, r)
+		}
+	}
+	return p
+}
+
+// handleReplays lists the archive on GET and takes an upload on POST.
+func (s *replayStore) handleReplays(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		q := r.URL.Query()
+		mode := boardMode(q.Get("mode"))
+		offset, _ := strconv.Atoi(q.Get("offset"))
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		if limit <= 0 || limit > 100 {
+			limit = boardPageSize
+		}
+		writeJSON(w, s.page(mode, max(offset, 0), limit))
+	case http.MethodPost:
+		var e boardEntry
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBoardBody)).Decode(&e); err != nil {
+			http.Error(w, "bad replay", http.StatusBadRequest)
+			return
+		}
+		e.Name = strings.TrimSpace(e.Name)
+		if s.board.accounts != nil {
+			if a := s.board.accounts.byRequest(r); a != nil {
+				e.Name = a.name
+			}
+		}
+		e.Mode = boardMode(e.Mode)
+		if e.Name == "" || len([]rune(e.Name)) > maxBoardName || !slices.Contains(replayModes, e.Mode) || e.Score < 0 || len(e.Keys) == 0 {
+			http.Error(w, "bad replay", http.StatusBadRequest)
+			return
+		}
+		if err := s.board.verify(e); err != nil {
+			logger.Warn("replay reject", "name", e.Name, "mode", e.Mode, "score", e.Score, "err", err)
+			http.Error(w, "replay rejected: "+err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		e.Rank = 0
+		e.Time = time.Now().UTC()
+		sum, err := s.add(e)
+		if err != nil {
+			logger.Error("replay upload", "err", err)
+			http.Error(w, "storing replay failed", http.StatusInternalServerError)
+			return
+		}
+		logger.Info("replay upload", "id", sum.ID, "name", e.Name, "mode", e.Mode, "score", e.Score)
+		writeJSON(w, sum)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleReplay serves one replay, keystrokes and all, on GET /replays/ID.
+func (s *replayStore) handleReplay(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/replays/")
+	for _, c := range id {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			http.NotFound(w, r)
+			return
+		}
+	}
+	data, err := os.ReadFile(filepath.Join(s.dir, id+".json"))
+	if id == "" || errors.Is(err, os.ErrNotExist) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, "reading replay failed", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// Client: uploading
+
+type replayUploadMsg struct {
+	replay replaySummary
+	err    error
+}
+
+func uploadReplayCmd(l leaderboard, e boardEntry) tea.Cmd {
+	return func() tea.Msg {
+		body, err := json.Marshal(e)
+		if err != nil {
+			return replayUploadMsg{err: err}
+		}
+		req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(l.url, "/")+"/replays", bytes.NewReader(body))
+		if err != nil {
+			return replayUploadMsg{err: err}
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if l.token != "" {
+			req.Header.Set("Authorization", "Bearer "+l.token)
+		}
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return replayUploadMsg{err: err}
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return replayUploadMsg{err: fmt.Errorf("replay archive: %s", resp.Status)}
+		}
+		var sum replaySummary
+		err = json.NewDecoder(resp.Body).Decode(&sum)
+		return replayUploadMsg{replay: sum, err: err}
+	}
+}
+
+// replayable reports whether the finished game can go in the archive.
+func (m model) replayable() bool {
+	return m.submittable() && m.peer == nil && m.classroom == nil
+}
+
+// renderReplayStatus is the game over screen's replay upload line.
+func (m model) renderReplayStatus() string {
+	switch {
+	case m.board.uploading:
+		return "Uploading replay..."
+	case m.board.replay != nil:
+		return fmt.Sprintf("Replay uploaded: #%d in the %s archive", m.board.replay.Rank, m.board.replay.Mode)
+	case m.board.replayErr != nil:
+		return fmt.Sprintf("Replay upload failed: %v", m.board.replayErr)
+	}
+	return ""
+}
+
+// Client: browsing and playback
+
+// replayViewer plays a replay back by stepping its game on the game's own
+// clock, sped up by speed, and typing its keystrokes as they were logged.
+type replayViewer struct {
+	entry  boardEntry
+	m      model
+	keys   []keystroke
+	speed  int
+	paused bool
+	done   bool
+	// gen tells this viewer's ticks from those of one closed earlier
+	gen int
+}
+
+type replayTickMsg struct{ gen int }
+
+func newReplayViewer(dict *dictionary, e boardEntry, gen int) (replayViewer, error) {
+	if e.Dict != dict.digest() {
+		return replayViewer{}, errors.New("this replay was played with a different dictionary; pass that one with -d")
+	}
+	m, err := replayModel(dict, e)
+	if err != nil {
+		return replayViewer{}, err
+	}
+	return replayViewer{entry: e, m: m, keys: e.Keys, speed: 1, gen: gen}, nil
+}
+
+func (v replayViewer) tick() tea.Cmd {
+	gen := v.gen
+	return tea.Tick(tickRate/time.Duration(v.speed), func(time.Time) tea.Msg {
+		return replayTickMsg{gen}
+	})
+}
+
+func (v replayViewer) Init() tea.Cmd {
+	return v.tick()
+}
+
+func (v replayViewer) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case replayTickMsg:
+		if msg.gen != v.gen || v.done {
+			return v, nil
+		}
+		if !v.paused {
+			v.m, v.keys = v.m.replayKeys(v.keys)
+			if v.m.ticks >= v.entry.Ticks || v.m.gameOver {
+				v.done = true
+				return v, nil
+			}
+			v.m = v.m.step()
+		}
+		return v, v.tick()
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			return v, tea.Quit
+		case " ":
+			v.paused = !v.paused
+		case "+", "=":
+			v.speed = nextSpeed(v.speed, 1)
+		case "-":
+			v.speed = nextSpeed(v.speed, -1)
+		}
+	}
+	return v, nil
+}
+
+// nextSpeed steps speed up or down the replaySpeeds.
+func nextSpeed(speed, step int) int {
+	for i, s := range replaySpeeds {
+		if s == speed {
+			return replaySpeeds[min(max(i+step, 0), len(replaySpeeds)-1)]
+		}
+	}
+	return 1
+}
+
+func (v replayViewer) View() string {
+	st := v.m.styles
+	e := v.entry
+	if v.done {
+		var b strings.Builder
+		b.WriteString("\n" + st.title.Render("REPLAY OVER") + "\n\n")
+		b.WriteString(st.stats.Render(fmt.Sprintf("%s scored %d: level %d, %d words, %d WPM, %.1f%% accuracy", e.Name, e.Score, e.Level, e.Words, e.WPM, e.Accuracy)) + "\n")
+		b.WriteString(st.stats.Render(fmt.Sprintf("The playback reached %d with %d words", v.m.score+v.m.partner.score, v.m.wordsTyped+v.m.partner.words)) + "\n")
+		b.WriteString("\n" + st.help.Render("[q/ESC: leave]") + "\n")
+		return b.String()
+	}
+	state := fmt.Sprintf("%dx", v.speed)
+	if v.paused {
+		state = "paused"
+	}
+	header := st.title.Render("REPLAY") + st.stats.Render(fmt.Sprintf("  %s  %s  %d points  %s", e.Name, e.Mode, e.Score, state))
+	help := st.help.Render("[SPACE: pause | +/-: speed | q/ESC: leave]")
+	return header + "\n" + v.m.View() + "\n" + help
+}
+
+// replayBrowser lists the archive a page at a time, and plays or saves
+// the chosen replay.
+type replayBrowser struct {
+	styles *styles
+	server string
+	dict   *dictionary
+	page   replayPage
+	cursor int
+	notice string
+	err    error
+	// viewer is the replay being watched, if any; gen counts viewers
+	viewer *replayViewer
+	gen    int
+}
+
+type replayPageMsg struct {
+	page replayPage
+	err  error
+}
+
+// replayFetchedMsg delivers a whole replay, to watch or to save.
+type replayFetchedMsg struct {
+	id    string
+	entry boardEntry
+	save  bool
+	err   error
+}
+
+func fetchReplaysCmd(server, mode string, offset int) tea.Cmd {
+	return func() tea.Msg {
+		q := url.Values{"mode": {mode}, "offset": {strconv.Itoa(offset)}, "limit": {strconv.Itoa(boardPageSize)}}
+		var p replayPage
+		err := getJSON(strings.TrimSuffix(server, "/")+"/replays?"+q.Encode(), &p)
+		return replayPageMsg{p, err}
+	}
+}
+
+func fetchReplayCmd(server, id string, save bool) tea.Cmd {
+	return func() tea.Msg {
+		var e boardEntry
+		err := getJSON(strings.TrimSuffix(server, "/")+"/replays/"+id, &e)
+		return replayFetchedMsg{id, e, save, err}
+	}
+}
+
+// fetchReplayURLCmd fetches a replay by its archive URL, as the game
+// history records it.
+func fetchReplayURLCmd(u string) tea.Cmd {
+	return func() tea.Msg {
+		var e boardEntry
+		err := getJSON(u, &e)
+		return replayFetchedMsg{entry: e, err: err}
+	}
+}
+
+func getJSON(u string, out any) error {
+	resp, err := httpClient.Get(u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("replay archive: %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (b replayBrowser) Init() tea.Cmd {
+	return fetchReplaysCmd(b.server, replayModes[0], 0)
+}
+
+func (b replayBrowser) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	var handled bool
+	if b.viewer, cmd, handled = updateWatching(b.viewer, msg); handled {
+		return b, cmd
+	}
+	switch msg := msg.(type) {
+	case replayPageMsg:
+		b.err = msg.err
+		if msg.err == nil {
+			b.page = msg.page
+			b.cursor = max(0, min(b.cursor, len(b.page.Entries)-1))
+		}
+	case replayFetchedMsg:
+		switch {
+		case msg.err != nil:
+			b.notice = fmt.Sprintf("Fetching the replay failed: %v", msg.err)
+		case msg.save:
+			name := "replay-" + msg.id + ".json"
+			data, err := json.Marshal(msg.entry)
+			if err == nil {
+				err = os.WriteFile(name, data, 0o644)
+			}
+			if err != nil {
+				b.notice = fmt.Sprintf("Saving the replay failed: %v", err)
+			} else {
+				b.notice = fmt.Sprintf("Saved %s; watch it with '%s replays play %s'", name, progName(), name)
+			}
+		default:
+			b.gen++
+			v, err := newReplayViewer(b.dict, msg.entry, b.gen)
+			if err != nil {
+				b.notice = err.Error()
+				return b, nil
+			}
+			b.viewer = &v
+			return b, v.Init()
+		}
+	case tea.KeyMsg:
+		b.notice = ""
+		p := b.page
+		switch msg.String() {
+		case "q", "ctrl+c":
+			return b, tea.Quit
+		case "up", "k":
+			b.cursor = max(0, b.cursor-1)
+		case "down", "j":
+			b.cursor = max(0, min(len(p.Entries)-1, b.cursor+1))
+		case "right", "n", "pgdown":
+			if p.Offset+boardPageSize < p.Total {
+				return b, fetchReplaysCmd(b.server, p.Mode, p.Offset+boardPageSize)
+			}
+		case "left", "p", "pgup":
+			if p.Offset > 0 {
+				return b, fetchReplaysCmd(b.server, p.Mode, max(0, p.Offset-boardPageSize))
+			}
+		case "tab":
+			next := replayModes[0]
+			for i, mode := range replayModes {
+				if mode == p.Mode {
+					next = replayModes[(i+1)%len(replayModes)]
+				}
+			}
+			b.cursor = 0
+			return b, fetchReplaysCmd(b.server, next, 0)
+		case "enter", "d":
+			if b.cursor < len(p.Entries) {
+				b.notice = "Fetching the replay..."
+				return b, fetchReplayCmd(b.server, p.Entries[b.cursor].ID, msg.String() == "d")
+			}
+		}
+	}
+	return b, nil
+}
+
+// updateWatching passes msg to a replay being watched inside another
+// screen, closing it on q or ESC, and reports whether msg was the
+// viewer's.
+func updateWatching(v *replayViewer, msg tea.Msg) (*replayViewer, tea.Cmd, bool) {
+	if v == nil {
+		return nil, nil, false
+	}
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if key := msg.String(); key == "q" || key == "esc" {
+			return nil, nil, true
+		}
+	case replayTickMsg:
+	default:
+		return v, nil, false
+	}
+	next, cmd := v.Update(msg)
+	rv := next.(replayViewer)
+	return &rv, cmd, true
+}
+
+func (b replayBrowser) View() string {
+	if b.viewer != nil {
+		return b.viewer.View()
+	}
+	st := b.styles
+	p := b.page
+	var out strings.Builder
+	out.WriteString("\n" + st.title.Render("REPLAYS - "+strings.ToUpper(boardMode(p.Mode))) + "\n\n")
+	switch {
+	case b.err != nil:
+		out.WriteString(st.stats.Render(fmt.Sprintf("Replay archive unavailable: %v", b.err)) + "\n")
+	case p.Total == 0:
+		out.WriteString(st.stats.Render("No replays yet") + "\n")
+	default:
+		out.WriteString(st.help.Render(fmt.Sprintf("  %5s  %-*s %7s %5s %4s %6s  %-10s", "RANK", maxBoardName, "NAME", "SCORE", "LEVEL", "WPM", "ACC", "DATE")) + "\n")
+		for i, r := range p.Entries {
+			line := fmt.Sprintf("%5d  %-*s %7d %5d %4d %5.1f%%  %s", r.Rank, maxBoardName, r.Name, r.Score, r.Level, r.WPM, r.Accuracy, r.Time.Format("2006-01-02"))
+			if r.Dict != b.dict.digest() {
+				line += "  (other dictionary)"
+			}
+			if i == b.cursor {
+				out.WriteString(st.highlight.Render("> "+line) + "\n")
+			} else {
+				out.WriteString(st.stats.Render("  "+line) + "\n")
+			}
+		}
+		pages := (p.Total + boardPageSize - 1) / boardPageSize
+		out.WriteString("\n" + st.stats.Render(fmt.Sprintf("Page %d of %d", p.Offset/boardPageSize+1, pages)) + "\n")
+	}
+	if b.notice != "" {
+		out.WriteString("\n" + st.stats.Render(b.notice) + "\n")
+	}
+	out.WriteString("\n" + st.help.Render("[ENTER: watch | d: download | n/p: page | TAB: mode | q: quit]"))
+	return out.String()
+}
+
+// runReplays is the replays command: browse the archive on a server, or
+// play a downloaded replay.
+func runReplays(args []string) error {
+	fs := flag.NewFlagSet("replays", flag.ExitOnError)
+	server := fs.String("server", "", "Leaderboard server whose archive to browse (default: your account's)")
+	dictPath := fs.String("d", "/usr/share/dict/words", "Dictionary the replays were played with")
+	play := len(args) > 0 && args[0] == "play"
+	if play {
+		args = args[1:]
+	}
+	fs.Parse(args)
+	if play {
+		if fs.NArg() != 1 {
+			return fmt.Errorf("usage: %s replays play [-d dict] FILE", progName())
+		}
+		return playReplayFile(*dictPath, fs.Arg(0))
+	}
+	dict, err := loadDictionary(*dictPath)
+	if err != nil {
+		return fmt.Errorf("loading dictionary: %w", err)
+	}
+	st := newStyles(lipgloss.DefaultRenderer())
+
+	if *server == "" {
+		if link, err := loadAccount(""); err == nil && link != nil {
+			*server = link.Server
+		}
+	}
+	if *server == "" {
+		return fmt.Errorf("usage: %s replays -server URL, or 'replays play FILE'", progName())
+	}
+	b := replayBrowser{styles: st, server: *server, dict: dict}
+	_, err = tea.NewProgram(b, tea.WithAltScreen()).Run()
+	return err
+}
+
+// playReplayFile watches a downloaded replay, played with the dictionary at
+// dictPath.
+func playReplayFile(dictPath, path string) error {
+	dict, err := loadDictionary(dictPath)
+	if err != nil {
+		return fmt.Errorf("loading dictionary: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var e boardEntry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return fmt.Errorf("reading replay: %w", err)
+	}
+	v, err := newReplayViewer(dict, e, 0)
+	if err != nil {
+		return err
+	}
+	_, err = tea.NewProgram(v, tea.WithAltScreen()).Run()
+	return err
+}
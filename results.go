@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timelineEvery is how many game seconds pass between WPM timeline samples.
+const timelineEvery = 10
+
+// letterStat counts keystrokes of one letter that did and didn't match a
+// falling word.
+type letterStat struct {
+	Hits   int `json:"hits"`
+	Misses int `json:"misses"`
+}
+
+// tally accumulates the per-keystroke detail behind the end-of-game report.
+type tally struct {
+	Keystrokes int            `json:"keystrokes"`
+	Typos      int            `json:"typos"`
+	Letters    [26]letterStat `json:"letters"`
+	// Timeline holds the running WPM sampled every timelineEvery seconds
+	Timeline []int    `json:"timeline"`
+	Missed   []string `json:"missed"`
+	// KillTimes maps each word killed to its milliseconds on screen
+	KillTimes map[string]int64 `json:"kill_times_ms,omitempty"`
+	// Capitals counts capitals typed right in shift training, and
+	// ShiftErrors letters typed in the wrong case
+	Capitals    int `json:"capitals,omitempty"`
+	ShiftErrors int `json:"shift_errors,omitempty"`
+	// Events are the game's notable moments, see timeline.go
+	Events []moment `json:"events,omitempty"`
+}
+
+// record counts a typed letter as a hit or a typo.
+func (t *tally) record(letter byte, hit bool) {
+	t.Keystrokes++
+	if upper(letter) {
+		if hit {
+			t.Capitals++
+		}
+		letter += 'a' - 'A'
+	}
+	if letter >= 'a' && letter <= 'z' {
+		if hit {
+			t.Letters[letter-'a'].Hits++
+		} else {
+			t.Letters[letter-'a'].Misses++
+		}
+	}
+	if !hit {
+		t.Typos++
+	}
+}
+
+// accuracy is the percentage of keystrokes that matched a word.
+func (t tally) accuracy() float64 {
+	if t.Keystrokes == 0 {
+		return 100
+	}
+	return float64(t.Keystrokes-t.Typos) * 100 / float64(t.Keystrokes)
+}
+
+// letterMap keeps only the letters that were typed, keyed by letter.
+func (t tally) letterMap() map[string]letterStat {
+	letters := make(map[string]letterStat)
+	for i, ls := range t.Letters {
+		if ls.Hits+ls.Misses > 0 {
+			letters[string(rune('a'+i))] = ls
+		}
+	}
+	return letters
+}
+
+// writeResults saves one game's report to path, as CSV when the path ends
+// in .csv and JSON otherwise.
+func writeResults(path string, s session) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return writeCSV(file, []session{s})
+	}
+	enc := json.NewEncoder(file)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// writeCSV writes one summary row per session. The nested per-letter stats
+// and timeline only appear in the JSON form.
+func writeCSV(w io.Writer, sessions []session) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"time", "duration_s", "score", "level", "words_typed", "wpm", "cpm",
+		"accuracy", "keystrokes", "typos", "missed", "assisted", "recovered"})
+	for _, s := range sessions {
+		cw.Write([]string{
+			s.Time.Format(time.RFC3339),
+			strconv.FormatFloat(s.Duration.Seconds(), 'f', 1, 64),
+			strconv.Itoa(s.Score),
+			strconv.Itoa(s.Level),
+			strconv.Itoa(s.WordsTyped),
+			strconv.Itoa(s.WPM),
+			strconv.Itoa(s.CPM),
+			strconv.FormatFloat(s.Accuracy, 'f', 1, 64),
+			strconv.Itoa(s.Keystrokes),
+			strconv.Itoa(s.Typos),
+			strings.Join(s.Missed, " "),
+			strconv.FormatBool(s.Assisted),
+			strconv.FormatBool(s.Recovered),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// exportStats implements `stats export`: the recorded history as JSON or
+// CSV on stdout or to a file.
+func exportStats(args []string) error {
+	fs := flag.NewFlagSet("stats export", flag.ExitOnError)
+	format := fs.String("format", "json", "Output format: json or csv")
+	out := fs.String("o", "", "Write to this file instead of stdout")
+	last := fs.Int("last", 0, "Only export the most recent N games (0 for all)")
+	fs.Parse(args)
+
+	sessions, err := loadHistory("")
+	if err != nil {
+		return err
+	}
+	if *last > 0 && len(sessions) > *last {
+		sessions = sessions[len(sessions)-*last:]
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	switch *format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(sessions)
+	case "csv":
+		return writeCSV(w, sessions)
+	default:
+		return fmt.Errorf("unknown format %q (want json or csv)", *format)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// scoring weighs what a kill is worth. A word scores
+//
+//	letters × letter × (1 + level × current level) × (1 + combo × (kills in a row - 1))
+//
+// plus speed × letters, scaled from nothing at the bottom of the screen to
+// the full amount at the top, plus accuracy × letters for a word typed
+// without a typo. The weights are part of the difficulty profile.
+type scoring struct {
+	Letter   float64 `json:"letter"`
+	Level    float64 `json:"level"`
+	Speed    float64 `json:"speed"`
+	Accuracy float64 `json:"accuracy"`
+	Combo    float64 `json:"combo"`
+}
+
+var defaultScoring = scoring{Letter: 1, Level: 1, Speed: 1}
+
+// points is what killing text at row y is worth.
+func (s scoring) points(text string, y, level, combo int, clean bool) int {
+	n := float64(len(text))
+	p := n * s.Letter * (1 + s.Level*float64(level)) * (1 + s.Combo*float64(max(0, combo-1)))
+	p += s.Speed * n * s.height(y)
+	if clean {
+		p += s.Accuracy * n
+	}
+	return int(math.Round(p))
+}
+
+// heightBonus is the part of points earned by killing text at row y.
+func (s scoring) heightBonus(text string, y int) int {
+	return int(math.Round(s.Speed * float64(len(text)) * s.height(y)))
+}
+
+// height is 1 for a word killed on the top row, falling to 0 on the last.
+func (s scoring) height(y int) float64 {
+	return float64(max(0, gameHeight-1-y)) / float64(gameHeight-1)
+}
+
+// scoringKey is a 'config set' setter for one weight.
+func scoringKey(weight func(s *settings) *float64) func(s *settings, value string) error {
+	return func(s *settings, value string) error {
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil || f < 0 || math.IsInf(f, 0) {
+			return fmt.Errorf("scoring weights are numbers of 0 or more, not %q", value)
+		}
+		*weight(s) = f
+		return nil
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// F12 saves the screen as it is to a timestamped file in the data
+// directory's screenshots folder, to share a bug or a close call. Plain
+// text by default, or with the colors' ANSI codes kept, for cat or a
+// terminal to show, with 'config set screenshot ansi'.
+
+const (
+	screenshotKey = "f12"
+	// shotNotice is how long the saved screenshot's path shows in place
+	// of the key help
+	shotNotice = 3 * time.Second
+)
+
+type screenshotMsg struct {
+	path string
+	err  error
+}
+
+// screenshotable reports whether F12 takes a screenshot. Hosted players'
+// files would land on the host.
+func (m model) screenshotable() bool {
+	return m.profile == ""
+}
+
+// screenshot saves the screen as the player sees it now.
+func (m model) screenshot() (model, tea.Cmd) {
+	view, ext := m.renderer.finish(m.render()), ".ans"
+	if !m.screenshotANSI {
+		view, ext = ansi.Strip(view), ".txt"
+	}
+	return m, saveScreenshotCmd(view, ext)
+}
+
+func saveScreenshotCmd(view, ext string) tea.Cmd {
+	return func() tea.Msg {
+		dir, err := dataDir()
+		if err != nil {
+			return screenshotMsg{err: err}
+		}
+		dir = filepath.Join(dir, "screenshots")
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return screenshotMsg{err: err}
+		}
+		path := filepath.Join(dir, "screen-"+time.Now().Format("20060102-150405.000")+ext)
+		if err := os.WriteFile(path, []byte(view+"\n"), 0o644); err != nil {
+			return screenshotMsg{err: err}
+		}
+		logger.Info("screenshot saved", "path", path)
+		return screenshotMsg{path: path}
+	}
+}
+
+// screenshotStatus says where the last screenshot went, or why it
+// didn't.
+func (m model) screenshotStatus() string {
+	switch {
+	case m.shot.err != nil:
+		return "Screenshot failed: " + m.shot.err.Error()
+	case m.shot.path != "":
+		return "Screenshot saved to " + m.shot.path
+	}
+	return ""
+}
+
+// shotRecent reports whether the last screenshot was saved recently enough
+// to still mention during play.
+func (m model) shotRecent() bool {
+	return !m.shotAt.IsZero() && time.Since(m.shotAt) < shotNotice
+}
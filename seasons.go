@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Some weeks of the year dress the game up: snow drifts down the playfield
+// through December, and the words turn pumpkin orange in the run-up to
+// Halloween. While a season lasts, solo games also mix in a limited-time
+// pack of words for it, in a color of their own. Packs change what is
+// dealt, so games that got any of their words can't be submitted or
+// shared as challenges, and the history names the season they came from.
+// 'config set seasonal skins' keeps the looks without the words, and
+// 'off' turns seasons off altogether.
+
+type season struct {
+	name string
+	// from and to are the first and last days of the season, each year
+	from, to monthDay
+	// snow drifts flakes down the playfield
+	snow bool
+	// wordColor recolors the calm plain words when set, and accent is
+	// the color of the pack's words
+	wordColor, accent string
+	pack              []string
+}
+
+type monthDay struct {
+	month time.Month
+	day   int
+}
+
+var seasons = []season{
+	{
+		name: "halloween", from: monthDay{time.October, 24}, to: monthDay{time.October, 31},
+		wordColor: "#FF8C00", accent: "#B388FF",
+		pack: []string{"pumpkin", "ghost", "witch", "spooky", "candy", "broom", "cauldron", "bat",
+			"skeleton", "haunted", "costume", "lantern", "potion", "spider", "cobweb", "zombie"},
+	},
+	{
+		name: "winter", from: monthDay{time.December, 1}, to: monthDay{time.December, 31},
+		snow: true, accent: "#E0FFFF",
+		pack: []string{"snow", "sleigh", "frost", "mitten", "cocoa", "icicle", "snowman", "blizzard",
+			"scarf", "tinsel", "reindeer", "chimney", "sled", "flurry", "igloo", "carol"},
+	},
+}
+
+const (
+	// eventChance is how often a spawn in season deals from its pack
+	eventChance = 0.15
+	// snowEvery is how sparse the snow is, one column in snowEvery
+	snowEvery = 4
+	// snowFall is how many rows a second the flakes drift down
+	snowFall = 2
+)
+
+// seasonAt is the season now falls in, or nil.
+func seasonAt(now time.Time) *season {
+	today := monthDay{now.Month(), now.Day()}
+	for i, s := range seasons {
+		if !today.before(s.from) && !s.to.before(today) {
+			return &seasons[i]
+		}
+	}
+	return nil
+}
+
+func (d monthDay) before(o monthDay) bool {
+	return d.month < o.month || (d.month == o.month && d.day < o.day)
+}
+
+// withSeason dresses the game for the season at now, as the seasonal
+// setting allows. It goes after the renderer is picked, since it restyles
+// the renderer's styles.
+func (m model) withSeason(now time.Time) model {
+	m.season = nil
+	if m.seasonal == "off" {
+		return m
+	}
+	s := seasonAt(now)
+	if s == nil {
+		return m
+	}
+	m.season = s
+	st := *m.styles
+	if s.wordColor != "" {
+		st.word = st.word.Foreground(lipgloss.Color(s.wordColor))
+	}
+	st.event = st.event.Foreground(lipgloss.Color(s.accent))
+	m.styles = &st
+	logger.Info("season", "name", s.name, "words", m.eventPack())
+	return m
+}
+
+// eventPack reports whether the game deals the season's words: only solo
+// games with the usual rules do.
+func (m model) eventPack() bool {
+	return m.season != nil && m.seasonal != "skins" && m.peer == nil && m.classroom == nil && !m.coop && !m.fixedRules &&
+		!m.drilling && m.keyDrill == "" && !m.shift && !m.resumed
+}
+
+// eventWord maybe deals a word from the season's pack in place of the
+// dictionary.
+func (m model) eventWord() (model, string, bool) {
+	if !m.eventPack() || m.rng.Float64() >= eventChance {
+		return m, "", false
+	}
+	var fits []string
+	for _, w := range m.season.pack {
+		if len(w) < m.fieldWidth() && !m.clashes(w, 0) {
+			fits = append(fits, w)
+		}
+	}
+	if len(fits) == 0 {
+		return m, "", false
+	}
+	text := fits[m.rng.Intn(len(fits))]
+	m.recent = append(m.recent, text)
+	if len(m.recent) > recentSpawns {
+		m.recent = m.recent[1:]
+	}
+	m.evented = true
+	return m, text, true
+}
+
+// drawSnow drifts the season's snow down the cleared playfield, behind the
+// words.
+func (m model) drawSnow() {
+	if m.season == nil || !m.season.snow || m.minimal {
+		return
+	}
+	flake := '*'
+	if m.symbols.name != "" && m.symbols.name != "ascii" {
+		flake = '❄'
+	}
+	drift := int(m.gameTime().Seconds() * snowFall)
+	for x := range m.fieldWidth() {
+		h := uint32(x) * 2654435761
+		h ^= h >> 16
+		if h%snowEvery != 0 {
+			continue
+		}
+		// Two flakes a column, half the field apart
+		for _, offset := range []int{0, gameHeight / 2} {
+			y := (int(h>>8) + offset + drift) % gameHeight
+			m.put(x, y, flake, cellSnow)
+		}
+	}
+}
+
+// seasonBanner is the title screen's line about the season's event.
+func (m model) seasonBanner() string {
+	if !m.eventPack() {
+		return ""
+	}
+	return fmt.Sprintf("The %s event is on: its words fall now and then until %s %d", m.season.name, m.season.to.month, m.season.to.day)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+)
+
+// serverOptions are the flags accepted by the server command.
+type serverOptions struct {
+	sshAddr     string
+	hostKeyPath string
+	dictPath    string
+	metricsAddr string
+	idleTimeout time.Duration
+	// spectateAddr serves every live game to spectators
+	spectateAddr string
+	// webAddr serves games to browsers
+	webAddr string
+	// frameRate is -tick-ms, see frameRate
+	frameRate time.Duration
+	tickMs    int
+}
+
+func addServerFlags(fs *flag.FlagSet, opts *serverOptions) {
+	fs.StringVar(&opts.sshAddr, "ssh", ":2222", "Address to serve the game over SSH")
+	fs.StringVar(&opts.hostKeyPath, "host-key", "", "SSH host key file, created if missing (default: in the data directory)")
+	fs.StringVar(&opts.dictPath, "d", "/usr/share/dict/words", "Path to dictionary file")
+	fs.StringVar(&opts.metricsAddr, "metrics", "", "Serve Prometheus metrics at /metrics on this address (e.g. :9090)")
+	fs.DurationVar(&opts.idleTimeout, "idle", 30*time.Second, "Auto-pause after this long without a keystroke (0 disables)")
+	fs.StringVar(&opts.spectateAddr, "spectate", "", "List live games at http://ADDR/watch/ for the watch command (e.g. :8090)")
+	fs.IntVar(&opts.tickMs, "tick-ms", defaultTickMs, "Milliseconds between frames for every player; raise it to save bandwidth")
+	fs.StringVar(&opts.webAddr, "web", "", "Also serve the game to browsers at http://ADDR/ (e.g. :8080)")
+}
+
+// serve hosts the game over SSH, and to browsers with -web, until
+// interrupted.
+func serve(opts serverOptions) (err error) {
+	if opts.frameRate, err = frameRate(opts.tickMs); err != nil {
+		return err
+	}
+	dict, err := loadDictionary(opts.dictPath)
+	if err != nil {
+		return fmt.Errorf("loading dictionary: %w", err)
+	}
+	if dict.len() == 0 {
+		return errors.New("dictionary is empty")
+	}
+
+	if opts.metricsAddr != "" {
+		serveMetrics(opts.metricsAddr)
+	}
+	var hub *spectatorHub
+	if opts.spectateAddr != "" {
+		hub = newSpectatorHub()
+		serveSpectators(opts.spectateAddr, "/watch/", hub)
+	}
+	if opts.webAddr != "" {
+		serveWeb(opts.webAddr, dict, opts, hub)
+		fmt.Printf("Serving Letter Invaders to browsers on %s\n", opts.webAddr)
+	}
+
+	return serveSSH(opts, dict, hub)
+}
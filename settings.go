@@ -0,0 +1,342 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// settings are the player's saved preferences, kept in config.json in the
+// data directory and changed with 'config set'.
+type settings struct {
+	// Discord publishes the current game to Discord Rich Presence
+	Discord bool `json:"discord,omitempty"`
+	// DiscordApp is the Discord application the presence is shown under
+	DiscordApp string `json:"discord_app,omitempty"`
+
+	// Difficulty profile
+
+	// Leveling is "words" (the default) or "time"
+	Leveling string `json:"leveling,omitempty"`
+	// LevelTime is how long a level lasts with time-based leveling
+	LevelTime time.Duration `json:"level_time,omitempty"`
+	Scoring   scoring       `json:"scoring"`
+	ExtraLife extraLife     `json:"extra_life"`
+	Lengths   wordLengths   `json:"word_lengths"`
+	Pace      pace          `json:"pace"`
+	// Adaptive is how strongly the pace adapts to the player, 0 to 1;
+	// unset is off
+	Adaptive float64 `json:"adaptive,omitempty"`
+	// WarmUp is how long a game eases in with shorter words; unset is off
+	WarmUp time.Duration `json:"warm_up,omitempty"`
+	// Boundary is how many rows above the bottom words are lost
+	Boundary int `json:"boundary,omitempty"`
+
+	// HUD lists the status line's widgets in order; unset is defaultHUD
+	HUD []string `json:"hud,omitempty"`
+	// Effects is the explosion level, low, med or high; unset is high
+	Effects string `json:"effects,omitempty"`
+	// Symbols is the symbol set explosions and the backdrop are drawn
+	// with, ascii, unicode or emoji; unset is ascii
+	Symbols string `json:"symbols,omitempty"`
+	// Graveyard lists the words that last left the playfield beside it
+	Graveyard bool `json:"graveyard,omitempty"`
+	// Screenshot is "ansi" to keep colors in screenshots; unset is plain
+	// text
+	Screenshot string `json:"screenshot,omitempty"`
+	// Seasonal is "skins" to dress the game for seasons without dealing
+	// their words, or "off"; unset is both
+	Seasonal string `json:"seasonal,omitempty"`
+	// Metronome is the metronome's keystrokes a minute; unset is off.
+	// MetronomeCue is "pulse", "bell" or "both", unset meaning both
+	Metronome    int    `json:"metronome,omitempty"`
+	MetronomeCue string `json:"metronome_cue,omitempty"`
+	// Layout is the keyboard layout key drills are worked out on; unset
+	// is qwerty
+	Layout string `json:"layout,omitempty"`
+	// SlowMotion is the game speed for slow motion, from 0.5 to 0.75;
+	// unset is full speed
+	SlowMotion float64 `json:"slow_motion,omitempty"`
+	// Direction is "ltr" or "rtl" to mirror the screen or not; unset
+	// follows the locale
+	Direction string `json:"direction,omitempty"`
+	// BreakEvery is how much play comes between break reminders; unset
+	// is off
+	BreakEvery time.Duration `json:"break_every,omitempty"`
+	// Submit is "auto" (the default) to finish a word on its last letter
+	// or "enter" to finish it with enter
+	Submit string `json:"submit,omitempty"`
+}
+
+func settingsPath() (string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.json"), nil
+}
+
+// loadSettings returns the saved settings, or the defaults if none have
+// been saved.
+func loadSettings() (settings, error) {
+	s := settings{Scoring: defaultScoring, ExtraLife: defaultExtraLife, Lengths: defaultWordLengths, Pace: defaultPace}
+	path, err := settingsPath()
+	if err != nil {
+		return s, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	return s, json.Unmarshal(data, &s)
+}
+
+func saveSettings(s settings) error {
+	path, err := settingsPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// settingKeys maps each 'config set' key to a setter.
+var settingKeys = map[string]func(s *settings, value string) error{
+	"discord": func(s *settings, value string) error {
+		on, err := parseToggle(value)
+		s.Discord = on
+		return err
+	},
+	"leveling": func(s *settings, value string) error {
+		if value != "words" && value != "time" {
+			return fmt.Errorf("leveling is words or time, not %q", value)
+		}
+		s.Leveling = value
+		return nil
+	},
+	"level-time": func(s *settings, value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil || d < tickRate {
+			return fmt.Errorf("level-time is a duration of at least %v, like 45s", tickRate)
+		}
+		s.LevelTime = d
+		return nil
+	},
+	"score-letter":   scoringKey(func(s *settings) *float64 { return &s.Scoring.Letter }),
+	"score-level":    scoringKey(func(s *settings) *float64 { return &s.Scoring.Level }),
+	"score-speed":    scoringKey(func(s *settings) *float64 { return &s.Scoring.Speed }),
+	"score-accuracy": scoringKey(func(s *settings) *float64 { return &s.Scoring.Accuracy }),
+	"score-combo":    scoringKey(func(s *settings) *float64 { return &s.Scoring.Combo }),
+	"extra-life-every": func(s *settings, value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("extra-life-every is a number of points, or 0 for none, not %q", value)
+		}
+		s.ExtraLife.Every = n
+		return nil
+	},
+	"max-lives": func(s *settings, value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return fmt.Errorf("max-lives is a number of at least 1, not %q", value)
+		}
+		s.ExtraLife.Max = n
+		return nil
+	},
+	"adaptive": setAdaptive,
+	"boundary": setBoundary,
+	"warm-up": func(s *settings, value string) error {
+		if value == "off" {
+			s.WarmUp = 0
+			return nil
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			return fmt.Errorf("warm-up is a duration like 1m, or off, not %q", value)
+		}
+		s.WarmUp = d
+		return nil
+	},
+	"break-every": func(s *settings, value string) error {
+		if value == "off" {
+			s.BreakEvery = 0
+			return nil
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil || d < time.Minute {
+			return fmt.Errorf("break-every is a duration of at least a minute, like 30m, or off, not %q", value)
+		}
+		s.BreakEvery = d
+		return nil
+	},
+	"metronome":   setMetronome,
+	"slow-motion": setSlowMotion,
+	"direction":   setDirection,
+	"layout": func(s *settings, value string) error {
+		if _, ok := keyboardLayouts[value]; !ok {
+			return fmt.Errorf("layout is one of %s, not %q", strings.Join(layoutNames(), ", "), value)
+		}
+		s.Layout = value
+		return nil
+	},
+	"metronome-cue": func(s *settings, value string) error {
+		if value != "pulse" && value != "bell" && value != "both" {
+			return fmt.Errorf("metronome-cue is pulse, bell or both, not %q", value)
+		}
+		s.MetronomeCue = value
+		return nil
+	},
+	"effects": func(s *settings, value string) error {
+		_, err := parseEffects(value)
+		s.Effects = value
+		return err
+	},
+	"graveyard": func(s *settings, value string) error {
+		on, err := parseToggle(value)
+		s.Graveyard = on
+		return err
+	},
+	"screenshot": func(s *settings, value string) error {
+		switch value {
+		case "plain":
+			s.Screenshot = ""
+		case "ansi":
+			s.Screenshot = value
+		default:
+			return fmt.Errorf("screenshot is plain or ansi, not %q", value)
+		}
+		return nil
+	},
+	"seasonal": func(s *settings, value string) error {
+		switch value {
+		case "on":
+			s.Seasonal = ""
+		case "skins", "off":
+			s.Seasonal = value
+		default:
+			return fmt.Errorf("seasonal is on, skins or off, not %q", value)
+		}
+		return nil
+	},
+	"symbols": func(s *settings, value string) error {
+		_, err := parseSymbols(value)
+		s.Symbols = value
+		return err
+	},
+	"submit": func(s *settings, value string) error {
+		if value != "auto" && value != "enter" {
+			return fmt.Errorf("submit is auto or enter, not %q", value)
+		}
+		s.Submit = value
+		return nil
+	},
+	"word-length-start":  lengthKey(func(s *settings) *int { return &s.Lengths.Start }),
+	"word-length-spread": lengthKey(func(s *settings) *int { return &s.Lengths.Spread }),
+	"word-length-growth": setLengthGrowth,
+	"fall-rate":          paceKey("fall-rate", func(s *settings) *float64 { return &s.Pace.Fall }, false),
+	"fall-growth":        paceKey("fall-growth", func(s *settings) *float64 { return &s.Pace.FallGrowth }, true),
+	"spawn-rate":         paceKey("spawn-rate", func(s *settings) *float64 { return &s.Pace.Spawn }, false),
+	"spawn-growth":       paceKey("spawn-growth", func(s *settings) *float64 { return &s.Pace.SpawnGrowth }, true),
+	"hud": func(s *settings, value string) error {
+		if value == "default" {
+			s.HUD = nil
+			return nil
+		}
+		names, err := parseHUD(value)
+		s.HUD = names
+		return err
+	},
+	"discord-app": func(s *settings, value string) error {
+		if _, err := strconv.ParseUint(value, 10, 64); err != nil && value != "" {
+			return fmt.Errorf("discord-app is a numeric application ID, not %q", value)
+		}
+		s.DiscordApp = value
+		return nil
+	},
+}
+
+// withSettings applies the player's preferences to a game.
+func (m model) withSettings(prefs settings) model {
+	m.scoring = prefs.Scoring
+	m.extraLife = prefs.ExtraLife
+	m.hud = defaultHUD
+	if prefs.HUD != nil {
+		m.hud = prefs.HUD
+	}
+	if lvl, err := parseEffects(prefs.Effects); err == nil {
+		m.effectsLevel = lvl
+	}
+	if set, err := parseSymbols(prefs.Symbols); err == nil {
+		m.symbols = set.shownOn(m.renderer)
+	}
+	m.layout = prefs.Layout
+	m.seasonal = prefs.Seasonal
+	m.graveyard = prefs.Graveyard
+	m.screenshotANSI = prefs.Screenshot == "ansi"
+	m.rtl = rightToLeft(prefs.Direction)
+	m.breakEvery = prefs.BreakEvery
+	m.slowMotion = prefs.SlowMotion
+	m.metronome, m.metronomeCue = prefs.Metronome, prefs.MetronomeCue
+	// Enter can't tell the co-op players apart
+	m.requireEnter = prefs.Submit == "enter" && !m.coop
+	if !m.fixedRules {
+		m.levelTicks = prefs.levelTicks()
+		m.lengths = prefs.Lengths
+		m.pace = prefs.Pace
+		m.warmUp = prefs.WarmUp
+		m.adaptive = prefs.Adaptive
+		m.boundary = prefs.Boundary
+	}
+	return m
+}
+
+// parseToggle accepts on/off as well as the usual boolean spellings.
+func parseToggle(value string) (bool, error) {
+	switch value {
+	case "on", "yes":
+		return true, nil
+	case "off", "no":
+		return false, nil
+	}
+	on, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("expected on or off, not %q", value)
+	}
+	return on, nil
+}
+
+// setSetting handles 'config set KEY VALUE'.
+func setSetting(args []string) error {
+	if len(args) != 2 {
+		keys := make([]string, 0, len(settingKeys))
+		for k := range settingKeys {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		return fmt.Errorf("usage: %s config set KEY VALUE (keys: %v)", progName(), keys)
+	}
+	set, ok := settingKeys[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown setting %q", args[0])
+	}
+	s, err := loadSettings()
+	if err != nil {
+		return fmt.Errorf("reading settings: %w", err)
+	}
+	if err := set(&s, args[1]); err != nil {
+		return err
+	}
+	return saveSettings(s)
+}
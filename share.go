@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The share card is a few lines summing up a run, with a row of squares
+// for the WPM timeline, small enough to paste into a chat. 'c' on the game
+// over screen copies it to the clipboard with an OSC 52 escape, which most
+// terminals (and tmux with set-clipboard on) pass to the system clipboard,
+// even over SSH.
+
+// shareSquares is the most timeline samples shown on the card.
+const shareSquares = 10
+
+func (m model) shareCard() string {
+	s := m.result()
+	mode := "Solo"
+	switch s.Mode {
+	case "coop":
+		mode = "Co-op"
+	case "versus":
+		mode = "Versus"
+		if s.Won {
+			mode += " (won vs " + s.Opponent + ")"
+		} else {
+			mode += " (lost vs " + s.Opponent + ")"
+		}
+	}
+	lines := []string{
+		"Letter Invaders · " + mode,
+		fmt.Sprintf("🏆 %d · level %d", s.Score, s.Level),
+		fmt.Sprintf("⌨️ %d WPM · 🎯 %.1f%%", s.WPM, s.Accuracy),
+	}
+	if row := timelineSquares(s.Timeline); row != "" {
+		lines = append(lines, row)
+	}
+	if code := m.challenge(); code != "" {
+		lines = append(lines, "Beat it: -challenge "+code)
+	} else {
+		lines = append(lines, fmt.Sprintf("seed %d", m.seed))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// timelineSquares grades each stretch of the timeline against the best:
+// green at 75% of the peak WPM, yellow at 40%, red below.
+func timelineSquares(timeline []int) string {
+	if len(timeline) == 0 {
+		return ""
+	}
+	per := (len(timeline) + shareSquares - 1) / shareSquares
+	var avgs []int
+	peak := 1
+	for i := 0; i < len(timeline); i += per {
+		chunk := timeline[i:min(i+per, len(timeline))]
+		sum := 0
+		for _, w := range chunk {
+			sum += w
+		}
+		avg := sum / len(chunk)
+		avgs = append(avgs, avg)
+		peak = max(peak, avg)
+	}
+	var b strings.Builder
+	for _, avg := range avgs {
+		switch {
+		case avg*4 >= peak*3:
+			b.WriteString("🟩")
+		case avg*5 >= peak*2:
+			b.WriteString("🟨")
+		default:
+			b.WriteString("🟥")
+		}
+	}
+	return b.String()
+}
+
+type copiedMsg struct{ err error }
+
+// copyCmd puts text on the clipboard of the terminal behind w.
+func copyCmd(w io.Writer, text string) tea.Cmd {
+	return func() tea.Msg {
+		_, err := fmt.Fprintf(w, "\x1b]52;c;%s\x07", base64.StdEncoding.EncodeToString([]byte(text)))
+		return copiedMsg{err}
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Shift training, -shift, keeps the dictionary's capitals, so proper nouns
+// like Paris have to be typed with shift. A letter that would have been
+// right in the other case counts as a shift error as well as a typo, and
+// those are tallied apart from the rest along with the capitals typed.
+// The game deals from a different dictionary than the boards and challenge
+// codes know, so it stays off them.
+
+// shiftSlip reports whether the input would match a word of the player's
+// but for the case of its last letter.
+func (m model) shiftSlip() bool {
+	for _, w := range m.words {
+		if w.owner == m.seat && len(w.text) >= len(m.input) && strings.EqualFold(w.text[:len(m.input)], m.input) {
+			return true
+		}
+	}
+	return false
+}
+
+// upper reports whether k is a capital letter.
+func upper(k byte) bool {
+	return k >= 'A' && k <= 'Z'
+}
+
+// shiftAccuracy is the percentage of capitals typed without a shift error.
+func (t tally) shiftAccuracy() float64 {
+	if t.Capitals+t.ShiftErrors == 0 {
+		return 100
+	}
+	return float64(t.Capitals) * 100 / float64(t.Capitals+t.ShiftErrors)
+}
+
+// printShift writes the shift training part of the stats view.
+func printShift(sessions []session) {
+	var t tally
+	games := 0
+	for _, s := range sessions {
+		if s.Mode == "shift" || s.Capitals+s.ShiftErrors > 0 {
+			games++
+			t.Capitals += s.Capitals
+			t.ShiftErrors += s.ShiftErrors
+		}
+	}
+	if games == 0 {
+		return
+	}
+	fmt.Printf("\nShift training: %d capitals, %d shift errors (%.1f%%) over %d %s\n",
+		t.Capitals, t.ShiftErrors, t.shiftAccuracy(), games, plural(games, "game"))
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Slow motion is an accessibility setting for players who can't keep up
+// with the full speed: 'config set slow-motion 60%' runs the whole game,
+// falling, spawning and levelling, at 60% speed, from 50% to 75%. The
+// scoring doesn't change, but the results are marked assisted and the
+// game stays off the boards. Matches against other players always run at
+// full speed.
+
+const (
+	minSlowMotion = 0.5
+	maxSlowMotion = 0.75
+)
+
+// setSlowMotion is the 'config set slow-motion' setter; it takes a
+// fraction or a percentage.
+func setSlowMotion(s *settings, value string) error {
+	if value == "off" {
+		s.SlowMotion = 0
+		return nil
+	}
+	f, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	if err == nil && strings.HasSuffix(value, "%") {
+		f /= 100
+	}
+	if err != nil || f < minSlowMotion || f > maxSlowMotion {
+		return fmt.Errorf("slow-motion is a speed from 50%% to 75%%, or off, not %q", value)
+	}
+	s.SlowMotion = f
+	return nil
+}
+
+// slowed reports whether the game runs in slow motion.
+func (m model) slowed() bool {
+	return m.slowMotion > 0 && m.peer == nil && m.classroom == nil
+}
+
+// scaled is real time d as game time.
+func (m model) scaled(d time.Duration) time.Duration {
+	if !m.slowed() {
+		return d
+	}
+	return time.Duration(float64(d) * m.slowMotion)
+}
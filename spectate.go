@@ -0,0 +1,270 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// broadcaster relays one game's rendered frames to its spectators. Slow
+// spectators skip frames rather than holding the game up.
+type broadcaster struct {
+	mu      sync.Mutex
+	last    string
+	watches map[*wsConn]chan string
+	closed  bool
+}
+
+func newBroadcaster() *broadcaster {
+	return &broadcaster{watches: make(map[*wsConn]chan string)}
+}
+
+// publish offers a frame to every spectator.
+func (b *broadcaster) publish(frame string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if frame == b.last || b.closed {
+		return
+	}
+	b.last = frame
+	for _, ch := range b.watches {
+		// Replace any frame the spectator hasn't picked up yet
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- frame
+	}
+}
+
+// close ends the broadcast once the game is over.
+func (b *broadcaster) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for c, ch := range b.watches {
+		close(ch)
+		delete(b.watches, c)
+	}
+}
+
+func (b *broadcaster) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c, err := upgradeWebSocket(w, r)
+	if err != nil {
+		logger.Warn("spectator", "err", err)
+		return
+	}
+	defer c.Close()
+
+	ch := make(chan string, 1)
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		c.writeMessage(wsClose, nil)
+		return
+	}
+	b.watches[c] = ch
+	if b.last != "" {
+		ch <- b.last
+	}
+	b.mu.Unlock()
+	logger.Info("spectator joined", "remote", r.RemoteAddr)
+
+	gone := make(chan struct{})
+	go func() {
+		// Spectators have nothing to say; this only notices them leaving
+		for {
+			if _, err := c.readMessage(); err != nil {
+				close(gone)
+				return
+			}
+		}
+	}()
+	defer func() {
+		b.mu.Lock()
+		delete(b.watches, c)
+		b.mu.Unlock()
+		logger.Info("spectator left", "remote", r.RemoteAddr)
+	}()
+	for {
+		select {
+		case frame, ok := <-ch:
+			if !ok {
+				c.writeMessage(wsClose, nil)
+				return
+			}
+			if err := c.writeMessage(wsText, []byte(frame)); err != nil {
+				return
+			}
+		case <-gone:
+			return
+		}
+	}
+}
+
+// spectatorHub lists the games being played on a server, keyed by the
+// player's profile.
+type spectatorHub struct {
+	mu    sync.Mutex
+	games map[string]*broadcaster
+	names map[string]string
+}
+
+func newSpectatorHub() *spectatorHub {
+	return &spectatorHub{games: make(map[string]*broadcaster), names: make(map[string]string)}
+}
+
+func (h *spectatorHub) add(profile, player string) *broadcaster {
+	b := newBroadcaster()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if old := h.games[profile]; old != nil {
+		// The same key playing twice: the newer session wins
+		old.close()
+	}
+	h.games[profile] = b
+	h.names[profile] = player
+	return b
+}
+
+func (h *spectatorHub) remove(profile string, b *broadcaster) {
+	b.close()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.games[profile] == b {
+		delete(h.games, profile)
+		delete(h.names, profile)
+	}
+}
+
+// ServeHTTP lists live games at /watch/ and streams one at
+// /watch/<profile>.
+func (h *spectatorHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	profile := strings.TrimPrefix(r.URL.Path, "/watch/")
+	h.mu.Lock()
+	b := h.games[profile]
+	var lines []string
+	for p, name := range h.names {
+		lines = append(lines, fmt.Sprintf("%s\t/watch/%s", name, p))
+	}
+	h.mu.Unlock()
+	if profile == "" {
+		sort.Strings(lines)
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		for _, l := range lines {
+			fmt.Fprintln(w, l)
+		}
+		return
+	}
+	if b == nil {
+		http.NotFound(w, r)
+		return
+	}
+	b.ServeHTTP(w, r)
+}
+
+// serveSpectators serves h on addr in the background.
+func serveSpectators(addr, pattern string, h http.Handler) {
+	mux := http.NewServeMux()
+	mux.Handle(pattern, h)
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			fmt.Fprintf(os.Stderr, "spectator server: %v\n", err)
+		}
+	}()
+}
+
+// Watching
+
+type watchModel struct {
+	url   string
+	frame string
+	ended bool
+	err   error
+}
+
+type watchFrameMsg string
+
+type watchEndMsg struct{ err error }
+
+func (m watchModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m watchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "ctrl+c", "esc":
+			return m, tea.Quit
+		}
+	case watchFrameMsg:
+		m.frame = string(msg)
+	case watchEndMsg:
+		m.ended = true
+		m.err = msg.err
+	}
+	return m, nil
+}
+
+func (m watchModel) View() string {
+	status := "Watching " + m.url + " - press q to stop"
+	switch {
+	case m.err != nil:
+		status = fmt.Sprintf("Connection lost: %v - press q to quit", m.err)
+	case m.ended:
+		status = "The game has ended - press q to quit"
+	case m.frame == "":
+		status = "Waiting for the game to draw..."
+	}
+	return m.frame + "\n" + status
+}
+
+// watchURL accepts a bare host:port and fills in the scheme and path a
+// local game serves on.
+func watchURL(arg string) string {
+	if !strings.Contains(arg, "://") {
+		arg = "ws://" + arg
+	}
+	if u, err := url.Parse(arg); err == nil && (u.Path == "" || u.Path == "/") {
+		u.Path = "/watch"
+		return u.String()
+	}
+	return arg
+}
+
+// watch attaches to a broadcast game and shows it until it ends.
+func watch(rawURL string) error {
+	rawURL = watchURL(rawURL)
+	c, err := dialWebSocket(rawURL)
+	if err != nil {
+		return fmt.Errorf("connecting to %s: %w", rawURL, err)
+	}
+	defer c.Close()
+
+	p := tea.NewProgram(watchModel{url: rawURL}, tea.WithAltScreen())
+	go func() {
+		for {
+			frame, err := c.readMessage()
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					err = nil
+				}
+				p.Send(watchEndMsg{err: err})
+				return
+			}
+			p.Send(watchFrameMsg(frame))
+		}
+	}()
+	_, err = p.Run()
+	c.writeMessage(wsClose, nil)
+	return err
+}
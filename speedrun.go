@@ -0,0 +1,145 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// A speedrun races from level 1 to a target level. The clock is real time,
+// pauses included, and a split is taken at every level up. Runs are kept
+// in the stats history, and the fastest finished run to the same target is
+// the personal best the splits are compared against.
+
+// takeSplit records the time of a level up, ending the run at the target.
+func (m model) takeSplit() model {
+	m.splits = append(m.splits, m.elapsed())
+	logger.Info("split", "level", m.level, "time", m.elapsed())
+	if m.level >= m.speedrun {
+		m = m.endGame()
+	}
+	return m
+}
+
+// withSpeedrun sets up a race to target against the profile's best run.
+func (m model) withSpeedrun(target int) (model, error) {
+	switch {
+	case target < 2 || target > maxStartLevel:
+		return m, fmt.Errorf("-speedrun must be between 2 and %d", maxStartLevel)
+	case m.coop || m.weekly != "" || m.resumed:
+		return m, errors.New("speedruns are solo games from a fresh start")
+	case m.startLevel != 1:
+		return m, errors.New("speedruns start at level 1")
+	}
+	m.speedrun = target
+	sessions, err := loadHistory(m.profile)
+	if err != nil {
+		return m, fmt.Errorf("reading personal bests: %w", err)
+	}
+	m.pb = bestSplits(sessions, target)
+	return m, nil
+}
+
+// finished reports whether a speedrun reached its target.
+func (s session) finished() bool {
+	return s.Target > 0 && len(s.Splits) == s.Target-1
+}
+
+// bestSplits returns the fastest finished run to target in the history.
+func bestSplits(sessions []session, target int) []time.Duration {
+	var best []time.Duration
+	for _, s := range sessions {
+		if s.Target != target || !s.finished() {
+			continue
+		}
+		if best == nil || s.Splits[len(s.Splits)-1] < best[len(best)-1] {
+			best = s.Splits
+		}
+	}
+	return best
+}
+
+// formatSplit shows a run time as m:ss.cc.
+func formatSplit(d time.Duration) string {
+	cs := d.Milliseconds() / 10
+	return fmt.Sprintf("%d:%02d.%02d", cs/6000, cs/100%60, cs%100)
+}
+
+// formatDelta shows how far ahead (-) or behind (+) of a split a time is.
+func formatDelta(d time.Duration) string {
+	sign := "+"
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	cs := d.Milliseconds() / 10
+	return fmt.Sprintf("%s%d.%02d", sign, cs/100, cs%100)
+}
+
+// splitDelta renders the difference to the personal best at split i, or
+// "" when there is no best to compare with.
+func (m model) splitDelta(i int, at time.Duration) string {
+	if i >= len(m.pb) {
+		return ""
+	}
+	d := at - m.pb[i]
+	if d < 0 {
+		return m.styles.highlight.Render(formatDelta(d))
+	}
+	return m.styles.garbage.Render(formatDelta(d))
+}
+
+// renderSplits is the side panel during a speedrun.
+func (m model) renderSplits() []string {
+	lines := []string{
+		m.styles.title.Render("SPEEDRUN"),
+		m.styles.stats.Render(fmt.Sprintf("Race to level %d", m.speedrun)),
+		"",
+		m.styles.pause.Render(formatSplit(m.elapsed())),
+		"",
+	}
+	// The last few splits and the next one
+	first := max(0, len(m.splits)-(gameHeight-len(lines)-2))
+	for i := first; i < m.speedrun-1; i++ {
+		label := fmt.Sprintf("Level %2d", i+2)
+		switch {
+		case i < len(m.splits):
+			lines = append(lines, m.styles.stats.Render(label+"  "+formatSplit(m.splits[i]))+"  "+m.splitDelta(i, m.splits[i]))
+		case i < len(m.pb):
+			lines = append(lines, m.styles.help.Render(label+"  "+formatSplit(m.pb[i])))
+		default:
+			lines = append(lines, m.styles.help.Render(label+"  -"))
+		}
+		if len(lines) >= gameHeight {
+			break
+		}
+	}
+	return lines
+}
+
+// renderSplitTable is the game over screen's table of splits.
+func (m model) renderSplitTable() string {
+	var b strings.Builder
+	status := fmt.Sprintf("Speedrun to level %d: ", m.speedrun)
+	if len(m.splits) == m.speedrun-1 {
+		status += formatSplit(m.splits[len(m.splits)-1])
+		if len(m.pb) > 0 && m.splits[len(m.splits)-1] < m.pb[len(m.pb)-1] {
+			status += "  NEW PERSONAL BEST"
+		}
+	} else {
+		status += "not finished"
+	}
+	b.WriteString(m.styles.pause.Render(status) + "\n")
+	b.WriteString(m.styles.help.Render(fmt.Sprintf("%-8s %9s %9s %9s %7s", "LEVEL", "SPLIT", "SEGMENT", "BEST", "DELTA")) + "\n")
+	var prev time.Duration
+	for i, at := range m.splits {
+		best := ""
+		if i < len(m.pb) {
+			best = formatSplit(m.pb[i])
+		}
+		line := fmt.Sprintf("%-8d %9s %9s %9s ", i+2, formatSplit(at), formatSplit(at-prev), best)
+		b.WriteString(m.styles.stats.Render(line) + m.splitDelta(i, at) + "\n")
+		prev = at
+	}
+	return b.String()
+}
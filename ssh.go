@@ -0,0 +1,143 @@
+//go:build !js
+
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	"github.com/charmbracelet/wish/activeterm"
+	wishtea "github.com/charmbracelet/wish/bubbletea"
+	"github.com/charmbracelet/wish/logging"
+)
+
+// The SSH side of the server, left out of the browser build: wish doesn't
+// build for js.
+
+// keyProfile derives a stable profile id from a player's SSH public key, so
+// returning players get their own history back.
+func keyProfile(key ssh.PublicKey) string {
+	sum := sha256.Sum256(key.Marshal())
+	return hex.EncodeToString(sum[:8])
+}
+
+// serveSSH hosts the game over SSH until interrupted. Players authenticate
+// with any public key; the key identifies their profile.
+func serveSSH(opts serverOptions, dict *dictionary, hub *spectatorHub) error {
+	if opts.hostKeyPath == "" {
+		dir, err := dataDir()
+		if err != nil {
+			return err
+		}
+		opts.hostKeyPath = filepath.Join(dir, "ssh_host_ed25519")
+	}
+	s, err := wish.NewServer(
+		wish.WithAddress(opts.sshAddr),
+		wish.WithHostKeyPath(opts.hostKeyPath),
+		wish.WithPublicKeyAuth(func(ctx ssh.Context, key ssh.PublicKey) bool {
+			return true
+		}),
+		wish.WithMiddleware(
+			gameMiddleware(dict, opts, hub),
+			activeterm.Middleware(),
+			logging.Middleware(),
+		),
+	)
+	if err != nil {
+		return err
+	}
+
+	done := make(chan os.Signal, 1)
+	signal.Notify(done, os.Interrupt, syscall.SIGTERM)
+
+	fmt.Printf("Serving Letter Invaders over SSH on %s\n", opts.sshAddr)
+	go func() {
+		if err := s.ListenAndServe(); err != nil && !errors.Is(err, ssh.ErrServerClosed) {
+			fmt.Fprintf(os.Stderr, "ssh server: %v\n", err)
+			done <- os.Interrupt
+		}
+	}()
+
+	<-done
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return s.Shutdown(ctx)
+}
+
+// gameMiddleware runs one game per SSH session. It does the same job as
+// wish's bubbletea middleware but keeps the final model, so the session can
+// be recorded in the player's history when they leave.
+func gameMiddleware(dict *dictionary, opts serverOptions, hub *spectatorHub) wish.Middleware {
+	return func(next ssh.Handler) ssh.Handler {
+		return func(sess ssh.Session) {
+			_, windowChanges, _ := sess.Pty()
+
+			m := initialModel(dict)
+			m.styles = newStyles(wishtea.MakeRenderer(sess))
+			m.term = sess
+			m.idleTimeout = opts.idleTimeout
+			m.frameRate = opts.frameRate
+			m.player = sess.User()
+			m.profile = keyProfile(sess.PublicKey())
+			m.rating, _ = loadRating(m.profile)
+			m.highScores, _ = loadHighScores(m.profile)
+			m.practice, _ = practiceStatus(m.profile, time.Now())
+			if hub != nil {
+				m.spectators = hub.add(m.profile, m.player)
+				defer hub.remove(m.profile, m.spectators)
+			}
+
+			p := tea.NewProgram(m, append(wishtea.MakeOptions(sess), tea.WithAltScreen(), withFPS(m.frameRate))...)
+
+			metrics.connectedPlayers.Add(1)
+			defer metrics.connectedPlayers.Add(-1)
+			logger.Info("player connected", "user", m.player, "profile", m.profile, "remote", remoteHost(sess))
+
+			ctx, cancel := context.WithCancel(sess.Context())
+			defer cancel()
+			go func() {
+				for {
+					select {
+					case <-ctx.Done():
+						p.Quit()
+						return
+					case w := <-windowChanges:
+						p.Send(tea.WindowSizeMsg{Width: w.Width, Height: w.Height})
+					}
+				}
+			}()
+
+			final, err := p.Run()
+			p.Kill()
+			if err != nil {
+				logger.Error("game exited", "profile", m.profile, "err", err)
+			}
+			if fm, ok := final.(model); ok && !fm.title {
+				if _, err := fm.finish(); err != nil {
+					logger.Error("recording game", "profile", fm.profile, "err", err)
+				}
+			}
+			next(sess)
+		}
+	}
+}
+
+func remoteHost(sess ssh.Session) string {
+	host, _, err := net.SplitHostPort(sess.RemoteAddr().String())
+	if err != nil {
+		return sess.RemoteAddr().String()
+	}
+	return host
+}
@@ -0,0 +1,9 @@
+//go:build js
+
+package main
+
+import "errors"
+
+func serveSSH(opts serverOptions, dict *dictionary, hub *spectatorHub) error {
+	return errors.New("the browser build can't serve games over SSH")
+}
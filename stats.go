@@ -0,0 +1,342 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// session is one finished (or recovered) game in the stats history.
+type session struct {
+	Time       time.Time     `json:"time"`
+	Duration   time.Duration `json:"duration"`
+	Score      int           `json:"score"`
+	Level      int           `json:"level"`
+	WordsTyped int           `json:"words_typed"`
+	WPM        int           `json:"wpm"`
+	Accuracy   float64       `json:"accuracy"`
+	Keystrokes int           `json:"keystrokes"`
+	Typos      int           `json:"typos"`
+	// CPM is the raw characters typed a minute, typos included
+	CPM int `json:"cpm"`
+	// Letters maps each typed letter to its hit and miss counts
+	Letters map[string]letterStat `json:"letters,omitempty"`
+	// Timeline is the running WPM sampled every timelineEvery seconds
+	Timeline []int    `json:"wpm_timeline,omitempty"`
+	Missed   []string `json:"missed,omitempty"`
+	// KillTimes maps each word killed to its milliseconds on screen
+	KillTimes map[string]int64 `json:"kill_times_ms,omitempty"`
+	// Events are the game's notable moments, see timeline.go
+	Events []moment `json:"events,omitempty"`
+	// Mode is "versus", "coop", "weekly", "drill" or "shift", and empty
+	// for solo games
+	Mode     string `json:"mode,omitempty"`
+	Opponent string `json:"opponent,omitempty"`
+	Won      bool   `json:"won,omitempty"`
+	// Rating is the player's versus rating after the match
+	Rating    int  `json:"rating,omitempty"`
+	Recovered bool `json:"recovered,omitempty"`
+	Assisted  bool `json:"assisted,omitempty"`
+	// Week is the ISO week of a weekly challenge
+	Week string `json:"week,omitempty"`
+	// Target is the level a speedrun raced to and Splits its level up
+	// times from the start
+	Target int             `json:"target,omitempty"`
+	Splits []time.Duration `json:"splits,omitempty"`
+	// Goal is the win condition a game played to, and Victory whether it
+	// was reached; Duration is then the completion time
+	Goal    string `json:"goal,omitempty"`
+	Victory bool   `json:"victory,omitempty"`
+	// Initials are entered for a game that makes the top ten
+	Initials string `json:"initials,omitempty"`
+	// Metronome is the metronome's keystrokes a minute, if it was on, and
+	// OnBeat the percentage of keystrokes that landed on its beat
+	Metronome int     `json:"metronome,omitempty"`
+	OnBeat    float64 `json:"on_beat,omitempty"`
+	// Capitals and ShiftErrors are from shift training, see tally
+	Capitals    int `json:"capitals,omitempty"`
+	ShiftErrors int `json:"shift_errors,omitempty"`
+	// SlowMotion is the speed a slow-motion game ran at, which also marks
+	// it Assisted
+	SlowMotion float64 `json:"slow_motion,omitempty"`
+	// Hints counts the hints asked for
+	Hints int `json:"hints,omitempty"`
+	// Keys is the key drill the game was played as, and OneHand marks
+	// the one-handed preset
+	Keys    string `json:"keys,omitempty"`
+	OneHand bool   `json:"one_hand,omitempty"`
+	// Season is the seasonal event whose words the game dealt
+	Season string `json:"season,omitempty"`
+	// Modifiers are what set the game apart from the standard rules, see
+	// modifiers.go
+	Modifiers []string `json:"modifiers,omitempty"`
+	// Replay is the archive URL of the game's uploaded replay
+	Replay string `json:"replay,omitempty"`
+}
+
+// dataDir returns the directory holding saves and stats, creating it if
+// needed.
+func dataDir() (string, error) {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(base, "letter-invaders")
+	return dir, os.MkdirAll(dir, 0o755)
+}
+
+// profileDir is where a profile's files live. The empty profile is the
+// local player; hosted players each get a subdirectory.
+func profileDir(profile string) (string, error) {
+	if strings.ContainsAny(profile, `/\`) || strings.Contains(profile, "..") {
+		return "", fmt.Errorf("invalid profile %q", profile)
+	}
+	dir, err := dataDir()
+	if err != nil || profile == "" {
+		return dir, err
+	}
+	dir = filepath.Join(dir, "profiles", profile)
+	return dir, os.MkdirAll(dir, 0o755)
+}
+
+func historyPath(profile string) (string, error) {
+	dir, err := profileDir(profile)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "history.jsonl"), nil
+}
+
+// recordSession appends s to the profile's stats history.
+func recordSession(profile string, s session) error {
+	path, err := historyPath(profile)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return json.NewEncoder(file).Encode(s)
+}
+
+// loadHistory returns every session recorded for profile, oldest first.
+func loadHistory(profile string) ([]session, error) {
+	path, err := historyPath(profile)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var sessions []session
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var s session
+		if err := json.Unmarshal(scanner.Bytes(), &s); err != nil {
+			// Skip a line torn by a crash rather than losing the history
+			continue
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, scanner.Err()
+}
+
+// printStats writes a lifetime summary and the most recent games to stdout.
+func printStats() error {
+	sessions, err := loadHistory("")
+	if err != nil {
+		return err
+	}
+	if len(sessions) == 0 {
+		fmt.Println("No games recorded yet.")
+		return nil
+	}
+
+	var best session
+	var totalWords int
+	var totalTime time.Duration
+	for _, s := range sessions {
+		if s.Score > best.Score {
+			best = s
+		}
+		totalWords += s.WordsTyped
+		totalTime += s.Duration
+	}
+
+	fmt.Printf("Games played: %d\n", len(sessions))
+	fmt.Printf("Time played:  %v\n", totalTime.Round(time.Second))
+	fmt.Printf("Words typed:  %d\n", totalWords)
+	fmt.Printf("Average WPM:  %d\n", wpm(totalWords, totalTime))
+	fmt.Printf("Best score:   %d (level %d, %s)\n", best.Score, best.Level, best.Time.Format("2006-01-02"))
+	if r, err := loadRating(""); err == nil && r.Games > 0 {
+		fmt.Printf("Versus:       rating %d after %d matches\n", r.Rating, r.Games)
+	}
+	fastest := map[string]time.Duration{}
+	var goals []string
+	for _, s := range sessions {
+		if !s.Victory {
+			continue
+		}
+		if best, ok := fastest[s.Goal]; !ok {
+			goals = append(goals, s.Goal)
+			fastest[s.Goal] = s.Duration
+		} else if s.Duration < best {
+			fastest[s.Goal] = s.Duration
+		}
+	}
+	for _, g := range goals {
+		fmt.Printf("Goal:         %s in %s\n", g, formatSplit(fastest[g]))
+	}
+	for target := 2; target <= maxStartLevel; target++ {
+		if pb := bestSplits(sessions, target); pb != nil {
+			fmt.Printf("Speedrun:     level %d in %s\n", target, formatSplit(pb[len(pb)-1]))
+		}
+	}
+
+	fmt.Printf("\nHigh scores:\n")
+	for i, s := range highScores(sessions) {
+		fmt.Printf("  %2d. %-3s %7d  level %2d  %s\n", i+1, s.Initials, s.Score, s.Level, s.Time.Format("2006-01-02"))
+	}
+
+	printKillTimes(sessions)
+	printWarmUp(sessions)
+	printShift(sessions)
+
+	const recent = 10
+	fmt.Printf("\nRecent games:\n")
+	for _, s := range sessions[max(0, len(sessions)-recent):] {
+		fmt.Printf("  %s  score %6d  level %2d  words %4d  wpm %3d\n",
+			s.Time.Format("2006-01-02 15:04"), s.Score, s.Level, s.WordsTyped, s.WPM)
+	}
+	return nil
+}
+
+// wpm returns words per minute over elapsed.
+func wpm(words int, elapsed time.Duration) int {
+	if elapsed <= 0 {
+		return 0
+	}
+	return int(float64(words) * 60.0 / elapsed.Seconds())
+}
+
+// cpm returns raw characters per minute over elapsed: every letter typed,
+// typos included.
+func cpm(chars int, elapsed time.Duration) int {
+	return wpm(chars, elapsed)
+}
+
+// session summarizes the model's game for the stats history.
+func (m model) session() session {
+	elapsed := m.elapsed()
+	return session{
+		Time:       m.startTime,
+		Duration:   elapsed,
+		Score:      m.score,
+		Level:      m.level,
+		WordsTyped: m.wordsTyped,
+		WPM:        wpm(m.wordsTyped, elapsed),
+		Assisted:   m.assisted,
+		Metronome:  m.metronome,
+		OnBeat:     m.rhythm.onBeatPct(),
+	}.withTally(m.tally)
+}
+
+// finish records a started game in the player's history once its program
+// has exited. Games cut short by a quit or a dropped connection still count.
+func (m model) finish() (session, error) {
+	if !m.gameOver {
+		gameEnded(wpm(m.wordsTyped, m.elapsed()))
+	}
+	s := m.result()
+	if m.rated() {
+		r := m.rating.update(m.opponent.rating, m.won)
+		s.Rating = r.Rating
+		// A lobby match's rating is the lobby's to move
+		if !m.ladder {
+			if err := saveRating(m.profile, r); err != nil {
+				return s, err
+			}
+		}
+	}
+	if err := m.recordAccountGame(s); err != nil {
+		// The local history still has it
+		logger.Warn("account game", "err", err)
+	}
+	return s, recordSession(m.profile, s)
+}
+
+// result is the game's session with the co-op or versus outcome filled in.
+func (m model) result() session {
+	s := m.session()
+	if m.coop {
+		// Record the team result
+		s.Mode = "coop"
+		s.Score += m.partner.score
+		s.WordsTyped += m.partner.words
+		s.WPM = wpm(s.WordsTyped, s.Duration)
+	}
+	s.Goal, s.Victory = m.goal.String(), m.victory
+	s.Initials = m.initials
+	if m.speedrun > 0 {
+		s.Target = m.speedrun
+		s.Splits = m.splits
+	}
+	if m.shift {
+		s.Mode = "shift"
+	}
+	if m.drilling {
+		s.Mode = "drill"
+	}
+	s.Keys, s.OneHand = m.keyDrill, m.oneHand
+	s.Hints = m.hints
+	s.Modifiers = m.modifiers()
+	if m.evented {
+		s.Season = m.season.name
+	}
+	if m.slowed() {
+		s.Assisted, s.SlowMotion = true, m.slowMotion
+	}
+	if m.weekly != "" {
+		s.Mode = "weekly"
+		s.Week = m.weekly
+	}
+	if m.peer != nil {
+		s.Mode = "versus"
+		if m.teams != nil {
+			s.Mode = "team"
+		}
+		s.Opponent = m.opponent.name
+		s.Won = m.won
+	}
+	if m.board.replay != nil {
+		s.Replay = strings.TrimSuffix(m.board.url, "/") + "/replays/" + m.board.replay.ID
+	}
+	return s
+}
+
+// withTally fills in the keystroke detail from t.
+func (s session) withTally(t tally) session {
+	s.Accuracy = t.accuracy()
+	s.CPM = cpm(t.Keystrokes, s.Duration)
+	s.Keystrokes = t.Keystrokes
+	s.Typos = t.Typos
+	s.Letters = t.letterMap()
+	s.Timeline = t.Timeline
+	s.Events = t.Events
+	s.Missed = t.Missed
+	s.KillTimes = t.KillTimes
+	s.Capitals = t.Capitals
+	s.ShiftErrors = t.ShiftErrors
+	return s
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// styles are built once per program rather than on every frame. Each
+// program gets its own set so colors match the terminal it renders to,
+// which matters when one process serves several remote sessions.
+type styles struct {
+	highlight lipgloss.Style
+	// candidate faintly marks the input on words it also matches
+	candidate lipgloss.Style
+	// hint lights the next letter of a hinted word
+	hint lipgloss.Style
+	// decor is the faint backdrop of the symbol sets that have one
+	decor lipgloss.Style
+	// landing flashes a word about to cross the boundary
+	landing lipgloss.Style
+	// event colors a seasonal pack's words and snow its snowflakes
+	event   lipgloss.Style
+	snow    lipgloss.Style
+	word    lipgloss.Style
+	garbage lipgloss.Style
+	partner lipgloss.Style
+	// partnerHighlight marks player two's matched letters
+	partnerHighlight lipgloss.Style
+	// popup is the points floating up from a kill
+	popup     lipgloss.Style
+	status    lipgloss.Style
+	pause     lipgloss.Style
+	help      lipgloss.Style
+	title     lipgloss.Style
+	stats     lipgloss.Style
+	debug     lipgloss.Style
+	separator string
+	// border frames the playfield on terminals with room for it
+	border lipgloss.Style
+	// chat and categories color words by where they came from
+	chat       lipgloss.Style
+	categories []lipgloss.Style
+	inputBox   lipgloss.Style
+	urgent     [urgencySteps - 1]lipgloss.Style
+	// teams color the sides of a team match
+	teams [2]lipgloss.Style
+	// particles are the particle fade ramps, one after another
+	particles [particleColors * fadeSteps]lipgloss.Style
+}
+
+// categoryColors are handed out to dictionary categories in name order.
+var categoryColors = []string{"#50FA7B", "#FFB86C", "#BD93F9", "#F1FA8C", "#8BE9FD", "#FF9AA2"}
+
+func newStyles(r *lipgloss.Renderer) *styles {
+	categories := make([]lipgloss.Style, len(categoryColors))
+	for i, c := range categoryColors {
+		categories[i] = r.NewStyle().Foreground(lipgloss.Color(c))
+	}
+	var urgent [urgencySteps - 1]lipgloss.Style
+	for i, c := range urgencyColors {
+		urgent[i] = r.NewStyle().Foreground(lipgloss.Color(c))
+	}
+	var particles [particleColors * fadeSteps]lipgloss.Style
+	for i, ramp := range particleRamps {
+		for j, c := range ramp {
+			particles[i*fadeSteps+j] = r.NewStyle().Foreground(lipgloss.Color(c))
+		}
+	}
+	var teams [2]lipgloss.Style
+	for i, c := range teamColors {
+		teams[i] = r.NewStyle().Foreground(lipgloss.Color(c))
+	}
+	return &styles{
+		highlight:        r.NewStyle().Background(lipgloss.Color("#00FFFF")).Foreground(lipgloss.Color("#000000")).Bold(true),
+		candidate:        r.NewStyle().Background(lipgloss.Color("#1F4F4F")).Foreground(lipgloss.Color("#7FFFFF")),
+		hint:             r.NewStyle().Background(lipgloss.Color("#FFD700")).Foreground(lipgloss.Color("#000000")).Bold(true),
+		decor:            r.NewStyle().Foreground(lipgloss.Color("#44475A")),
+		event:            r.NewStyle().Foreground(lipgloss.Color("#B388FF")),
+		snow:             r.NewStyle().Foreground(lipgloss.Color("#E8F4FF")),
+		landing:          r.NewStyle().Background(lipgloss.Color("#FF5F5F")).Foreground(lipgloss.Color("#000000")).Bold(true),
+		word:             r.NewStyle().Foreground(lipgloss.Color("#00CED1")),
+		garbage:          r.NewStyle().Foreground(lipgloss.Color("#FF5F5F")),
+		partner:          r.NewStyle().Foreground(lipgloss.Color("#FF79C6")),
+		partnerHighlight: r.NewStyle().Background(lipgloss.Color("#FF79C6")).Foreground(lipgloss.Color("#000000")).Bold(true),
+		popup:            r.NewStyle().Foreground(lipgloss.Color("#FFD700")).Bold(true),
+		status:           r.NewStyle().Foreground(lipgloss.Color("#CCCCCC")),
+		pause:            r.NewStyle().Foreground(lipgloss.Color("#00FFFF")).Bold(true),
+		help:             r.NewStyle().Foreground(lipgloss.Color("#888888")),
+		title:            r.NewStyle().Foreground(lipgloss.Color("#00FFFF")).Bold(true),
+		stats:            r.NewStyle().Foreground(lipgloss.Color("#CCCCCC")),
+		debug:            r.NewStyle().Foreground(lipgloss.Color("#888888")),
+		separator:        r.NewStyle().Foreground(lipgloss.Color("#00CED1")).Render(strings.Repeat("─", screenWidth)),
+		border:           r.NewStyle().Foreground(lipgloss.Color("#00CED1")),
+		chat:             r.NewStyle().Foreground(lipgloss.Color("#9146FF")),
+		categories:       categories,
+		inputBox:         r.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("#00CED1")),
+		urgent:           urgent,
+		particles:        particles,
+		teams:            teams,
+	}
+}
+
+// cellKind says how a cell of the playfield should be styled.
+type cellKind uint8
+
+const (
+	cellWord cellKind = iota
+	cellGarbage
+	cellPartner
+	cellPartnerMatched
+	cellPopup
+	cellChat
+	// cellMatched is a letter of the target the player has typed
+	cellMatched
+	// cellCandidate is a typed letter of a word the input could still
+	// become, other than the target
+	cellCandidate
+	// cellHint is the next letter to type on a hinted word
+	cellHint
+	// cellDecor is the backdrop some symbol sets scatter, see drawDecor
+	cellDecor
+	// cellEvent is a word from a seasonal pack and cellSnow a snowflake,
+	// see seasons.go
+	cellEvent
+	cellSnow
+	// cellLanding is a word about to cross the boundary, see boundary.go
+	cellLanding
+	// cellUrgent is the first of the shades plain words turn on their way
+	// down, after cellWord's calm one, see word.urgency
+	cellUrgent
+	// cellParticle is the first of the particle kinds, a fade ramp for
+	// each particle color, see particle.kind
+	cellParticle = cellUrgent + urgencySteps - 1
+	// cellCategory is the first of a kind per category color
+	cellCategory = cellParticle + particleColors*fadeSteps
+)
+
+// urgencyColors are the shades a plain word passes through as it falls,
+// from cellWord's calm cyan to a warning orange.
+var urgencyColors = [urgencySteps - 1]string{"#5FD7AF", "#AFD75F", "#FFD75F", "#FFAF5F"}
+
+// particleRamps are the colors particles fade through, hottest first, for
+// each particle color.
+var particleRamps = [particleColors][fadeSteps]string{
+	sparkFire:  {"#FFFFFF", "#FFD700", "#FF8C00", "#8B3A3A"},
+	sparkHeart: {"#FFFFFF", "#FF79C6", "#FF5F5F", "#8B3A3A"},
+}
+
+// cell is the style for a kind of cell.
+func (s *styles) cell(k cellKind) lipgloss.Style {
+	switch {
+	case k >= cellCategory:
+		return s.categories[k-cellCategory]
+	case k >= cellParticle:
+		return s.particles[k-cellParticle]
+	case k >= cellUrgent:
+		return s.urgent[k-cellUrgent]
+	case k == cellGarbage:
+		return s.garbage
+	case k == cellPartner:
+		return s.partner
+	case k == cellPartnerMatched:
+		return s.partnerHighlight
+	case k == cellPopup:
+		return s.popup
+	case k == cellChat:
+		return s.chat
+	case k == cellMatched:
+		return s.highlight
+	case k == cellCandidate:
+		return s.candidate
+	case k == cellHint:
+		return s.hint
+	case k == cellDecor:
+		return s.decor
+	case k == cellEvent:
+		return s.event
+	case k == cellSnow:
+		return s.snow
+	case k == cellLanding:
+		return s.landing
+	}
+	return s.word
+}
+
+// frame is the cell buffer View draws into, a rune and a kind per cell;
+// the renderer styles the cells by kind in a last pass, so nothing slices
+// styled text. It's reused across frames to avoid allocating a fresh grid
+// at every tick. Copies of a model share one frame;
+// that's safe because a program only renders from its event loop.
+type frame struct {
+	cells [gameHeight][screenWidth]rune
+	// kinds records what was drawn in each cell, to pick its style
+	kinds [gameHeight][screenWidth]cellKind
+	// renderTime is how long the previous View call took
+	renderTime time.Duration
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Explosions can throw Unicode symbols or emoji instead of ASCII, and
+// those sets also scatter a faint backdrop over the empty playfield. Emoji
+// take two columns, so a wide symbol covers the cell after it, and one
+// that something else has half drawn over falls back to an ASCII spark.
+// A set the terminal can't show falls back too: Unicode needs a UTF-8
+// locale, emoji a terminal other than the Linux console, and the plain
+// renderer always gets ASCII.
+
+type symbolSet struct {
+	name string
+	// sparks are what explosion particles are drawn with
+	sparks []rune
+	// decor is scattered over the empty playfield, one cell in
+	// decorEvery; ascii has none
+	decor []rune
+	// fallback is the set used where this one can't be shown
+	fallback string
+}
+
+var symbolSets = map[string]symbolSet{
+	"ascii":   {"ascii", []rune("*+#o.~^x"), nil, ""},
+	"unicode": {"unicode", []rune("✦✧✶✷•◦∗⁕"), []rune("·˙∙"), "ascii"},
+	"emoji":   {"emoji", []rune("💥✨🔥⭐💫"), []rune("·˙✧"), "unicode"},
+}
+
+const (
+	defaultSymbols = "ascii"
+	// decorEvery is how sparse the backdrop is
+	decorEvery = 45
+	// wideCell fills the cell a wide symbol covers
+	wideCell rune = 0
+)
+
+func symbolNames() []string {
+	names := make([]string, 0, len(symbolSets))
+	for name := range symbolSets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func parseSymbols(name string) (symbolSet, error) {
+	s, ok := symbolSets[name]
+	if !ok {
+		return s, fmt.Errorf("symbols are %s, not %q", strings.Join(symbolNames(), ", "), name)
+	}
+	return s, nil
+}
+
+// shownOn is the set itself, or the first of its fallbacks the terminal
+// and renderer can show.
+func (s symbolSet) shownOn(r renderer) symbolSet {
+	if s.name == "" {
+		return symbolSets[defaultSymbols]
+	}
+	if _, plain := r.(plainRenderer); plain && s.name != "ascii" {
+		return symbolSets["ascii"]
+	}
+	for s.fallback != "" && !s.supported() {
+		logger.Info("symbols fall back", "from", s.name, "to", s.fallback)
+		s = symbolSets[s.fallback]
+	}
+	return s
+}
+
+// supported reports whether the terminal looks able to show the set.
+func (s symbolSet) supported() bool {
+	switch s.name {
+	case "emoji":
+		return utf8Locale() && os.Getenv("TERM") != "linux"
+	case "unicode":
+		return utf8Locale()
+	}
+	return true
+}
+
+// utf8Locale reports whether the locale, from the first of $LC_ALL,
+// $LC_CTYPE and $LANG that is set, is UTF-8.
+func utf8Locale() bool {
+	for _, env := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if locale := strings.ToLower(os.Getenv(env)); locale != "" {
+			return strings.Contains(locale, "utf-8") || strings.Contains(locale, "utf8")
+		}
+	}
+	return false
+}
+
+// sparks are what the game's explosions throw.
+func (m model) sparks() []rune {
+	if m.symbols.name == "" {
+		return symbolSets[defaultSymbols].sparks
+	}
+	return m.symbols.sparks
+}
+
+func wide(r rune) bool {
+	return r > '~' && ansi.StringWidth(string(r)) > 1
+}
+
+// put draws r at x, y, covering the next cell too when r is wide.
+func (m model) put(x, y int, r rune, kind cellKind) {
+	m.frame.cells[y][x] = r
+	m.frame.kinds[y][x] = kind
+	if wide(r) && x+1 < screenWidth {
+		m.frame.cells[y][x+1] = wideCell
+		m.frame.kinds[y][x+1] = kind
+	}
+}
+
+// drawDecor scatters the backdrop over the cleared playfield, in the same
+// places every frame.
+func (m model) drawDecor() {
+	decor := m.symbols.decor
+	if len(decor) == 0 || m.minimal {
+		return
+	}
+	for y := range gameHeight {
+		for x := range m.fieldWidth() {
+			h := uint32(x)*2654435761 ^ uint32(y)*40503
+			h ^= h >> 13
+			h *= 2246822519
+			h ^= h >> 16
+			if h%decorEvery == 0 {
+				m.put(x, y, decor[(h/decorEvery)%uint32(len(decor))], cellDecor)
+			}
+		}
+	}
+}
+
+// settleWide tidies up after wide symbols something else was drawn over:
+// a symbol whose second cell was taken becomes an ASCII spark, and a
+// covered cell whose symbol was drawn over becomes blank.
+func (m model) settleWide() {
+	for y := range gameHeight {
+		row, kinds := m.frame.cells[y][:m.fieldWidth()], m.frame.kinds[y]
+		for x, r := range row {
+			switch {
+			case r == wideCell && (x == 0 || !wide(row[x-1])):
+				row[x] = ' '
+			case wide(r) && symbolKind(kinds[x]) && (x+1 >= len(row) || row[x+1] != wideCell):
+				row[x] = '*'
+			}
+		}
+	}
+}
+
+// symbolKind reports whether cells of kind k come from the symbol set.
+func symbolKind(k cellKind) bool {
+	return k == cellDecor || k == cellSnow || (k >= cellParticle && k < cellCategory)
+}
+
+// cellText is a run of cells as text, leaving out the cells wide symbols
+// cover.
+func cellText(cells []rune) string {
+	if !slices.Contains(cells, wideCell) {
+		return string(cells)
+	}
+	return string(slices.DeleteFunc(slices.Clone(cells), func(r rune) bool { return r == wideCell }))
+}
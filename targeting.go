@@ -0,0 +1,98 @@
+package main
+
+import (
+	"slices"
+	"strings"
+)
+
+// Typing can match more than one word on screen when one is a prefix of
+// another, or when several share a start. The target is then, in order of
+// preference, a word the input spells out in full, the word already being
+// typed, and the word lowest on the screen.
+//
+// When words finish on their last letter, "cat" would always cut short
+// "catalog", so in that mode new words that are a prefix of a word on
+// screen, or have one as a prefix, are passed over. So are words already on
+// screen, which would leave two identical targets, and words dealt in the
+// last few spawns.
+
+// recentSpawns is how many spawns go by before a word can come again,
+// even across the end of a shuffle bag's round.
+const recentSpawns = 8
+
+// target returns the index of the seat's word the input should lock onto,
+// or -1 if it matches none.
+func (m model) target() int {
+	best := -1
+	for i := range m.words {
+		w := &m.words[i]
+		if w.owner != m.seat || !strings.HasPrefix(w.text, m.input) {
+			continue
+		}
+		switch {
+		case w.text == m.input:
+			return i
+		case best < 0:
+			best = i
+		case &m.words[best] == m.current:
+		case w == m.current || w.y > m.words[best].y:
+			best = i
+		}
+	}
+	return best
+}
+
+// typedOn is how many letters of w its owner has typed towards it: the
+// whole input if w starts with it, otherwise none.
+func (m model) typedOn(w word) int {
+	input := m.input
+	if w.owner == 1 {
+		input = m.partner.input
+	}
+	if !strings.HasPrefix(w.text, input) {
+		return 0
+	}
+	return len(input)
+}
+
+// clashes reports whether text would make typing ambiguous next to the
+// owner's words already on screen.
+func (m model) clashes(text string, owner int) bool {
+	if slices.Contains(m.recent, text) {
+		return true
+	}
+	for _, w := range m.words {
+		switch {
+		case w.text == text:
+			return true
+		case m.requireEnter || w.owner != owner:
+		case strings.HasPrefix(w.text, text) || strings.HasPrefix(text, w.text):
+			return true
+		}
+	}
+	return false
+}
+
+// deal draws the next word for owner that fits the playfield and the
+// level's band of lengths and doesn't clash with the words on screen. It
+// reports false when no word does, and the spawn should be skipped.
+func (m model) deal(owner int) (model, string, bool) {
+	d := m.dict
+	if m.coop {
+		d = m.pools[owner]
+	}
+	m, maxLen := m.maxFit()
+	lo, hi := m.lengthBand(d, maxLen)
+	hi = max(lo, m.warmUpBand(lo, hi)-m.handicaps[owner].Shorter)
+	text, ok := d.deal(m.rng, &m.bags[owner], lo, hi, func(w string) bool {
+		return !m.clashes(w, owner)
+	})
+	if !ok {
+		return m, "", false
+	}
+	m.recent = append(m.recent, text)
+	if len(m.recent) > recentSpawns {
+		m.recent = m.recent[1:]
+	}
+	return m, text, true
+}
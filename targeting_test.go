@@ -0,0 +1,74 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestClashes(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		owner  int
+		enter  bool
+		recent []string
+		want   bool
+	}{
+		{"unrelated", "dog", 0, false, nil, false},
+		{"on screen", "cat", 0, false, nil, true},
+		{"on screen for the other player", "cat", 1, false, nil, true},
+		{"prefix of a word on screen", "cata", 0, false, nil, true},
+		{"word on screen is its prefix", "catalogue", 0, false, nil, true},
+		{"prefix of the other player's word", "cata", 1, false, nil, false},
+		{"prefix with enter to finish", "cata", 0, true, nil, false},
+		{"same word with enter to finish", "cat", 0, true, nil, true},
+		{"dealt lately", "dog", 0, false, []string{"emu", "dog"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := initialModel(newDictionary([]string{"cat"}))
+			m.words = []word{{text: "cat"}, {text: "catalog"}}
+			m.requireEnter = tt.enter
+			m.recent = tt.recent
+			if got := m.clashes(tt.text, tt.owner); got != tt.want {
+				t.Errorf("clashes(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextThrown(t *testing.T) {
+	wide := strings.Repeat("w", minFieldWidth)
+	tests := []struct {
+		name   string
+		thrown []string
+		want   string
+		queued []string
+	}{
+		{"first word", []string{"dog", "emu"}, "dog", []string{"emu"}},
+		{"clashing word waits", []string{"cat", "dog"}, "dog", []string{"cat"}},
+		{"prefix clash waits", []string{"catalogs", "dog", "emu"}, "dog", []string{"catalogs", "emu"}},
+		{"too wide is dropped", []string{wide, "dog"}, "dog", nil},
+		{"all clash", []string{"cat", "catalog"}, "", []string{"cat", "catalog"}},
+		{"empty", nil, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := initialModel(newDictionary([]string{"cat"}))
+			m.width = minFieldWidth
+			m.words = []word{{text: "cat"}, {text: "catalog"}}
+			m.thrown = tt.thrown
+			m, got, ok := m.nextThrown()
+			if got != tt.want || ok != (tt.want != "") {
+				t.Errorf("nextThrown = %q, %v, want %q", got, ok, tt.want)
+			}
+			if !slices.Equal(m.thrown, tt.queued) {
+				t.Errorf("left %v queued, want %v", m.thrown, tt.queued)
+			}
+			if ok && !slices.Contains(m.recent, got) {
+				t.Errorf("%q not remembered as dealt", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,394 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Team matches put two players against two through the lobby. One player
+// hosts a 2v2 room with '2', three more join it, and the room starts once
+// both sides are full; anyone waiting can switch sides while there's room.
+// Each player plays their own game as in versus, and the lobby keeps the
+// teams: it adds up their scores and holds a garbage meter for each. Garbage
+// a player earns first cancels their own team's meter and the rest goes on
+// the other team's, which the lobby hands out a word at a time to whichever
+// of that team's players reports in next. A team is beaten when both of
+// its players are out of lives.
+//
+// On top of the lobby protocol: "host_team" hosts a room and "switch"
+// changes sides. "matched" for a team match carries Team, the player's
+// side, and Teams; from then on the lobby sends "teams" (Teams) whenever
+// the standings change and "lost" to the winners once the other team is
+// beaten. Players send "state", "garbage", "lost" and "chat" as in versus.
+
+// teamNames name the sides, and teamColors color them.
+var (
+	teamNames  = [2]string{"Blue", "Orange"}
+	teamColors = [2]string{"#5FAFFF", "#FFAF5F"}
+)
+
+// teamSize is how many players are on each side.
+const teamSize = 2
+
+// teamView is one side of a team match as the lobby reports it.
+type teamView struct {
+	Players []teamPlayer `json:"players"`
+	Score   int          `json:"score"`
+	Words   int          `json:"words"`
+	// Incoming is the team's garbage meter
+	Incoming int `json:"incoming"`
+}
+
+type teamPlayer struct {
+	Name  string `json:"name"`
+	Score int    `json:"score"`
+	Lives int    `json:"lives"`
+	Words int    `json:"words"`
+	Out   bool   `json:"out,omitempty"`
+	// Ping is the player's latency to the lobby in milliseconds
+	Ping int `json:"ping,omitempty"`
+}
+
+// Server
+
+// teamRoom is a 2v2 room filling up in the lobby.
+type teamRoom struct {
+	id    int
+	sides [2][]*lobbyPlayer
+}
+
+// side is the side pl is on, or -1.
+func (r *teamRoom) side(pl *lobbyPlayer) int {
+	for t, side := range r.sides {
+		for _, p := range side {
+			if p == pl {
+				return t
+			}
+		}
+	}
+	return -1
+}
+
+func (r *teamRoom) remove(pl *lobbyPlayer) {
+	for t, side := range r.sides {
+		for i, p := range side {
+			if p == pl {
+				r.sides[t] = append(side[:i:i], side[i+1:]...)
+			}
+		}
+	}
+}
+
+func (r *teamRoom) players() int {
+	return len(r.sides[0]) + len(r.sides[1])
+}
+
+// listing is the room as the room list shows it.
+func (r *teamRoom) listing() lobbyRoom {
+	room := lobbyRoom{ID: r.id, Teams: make([][]string, 2)}
+	rated := 0
+	for t, side := range r.sides {
+		for _, pl := range side {
+			room.Teams[t] = append(room.Teams[t], pl.name)
+			room.Rating += pl.rating.Rating
+			rated++
+		}
+	}
+	if rated > 0 {
+		room.Rating /= rated
+	}
+	return room
+}
+
+// teamMatch is a 2v2 match the lobby is running.
+type teamMatch struct {
+	sides [2][]*lobbyPlayer
+	// stats are the players' last reported states
+	stats map[*lobbyPlayer]*teamPlayer
+	meter [2]int
+	over  bool
+}
+
+func (tm *teamMatch) side(pl *lobbyPlayer) int {
+	for t, side := range tm.sides {
+		for _, p := range side {
+			if p == pl {
+				return t
+			}
+		}
+	}
+	return -1
+}
+
+// views are the standings for the "teams" message.
+func (tm *teamMatch) views() []teamView {
+	views := make([]teamView, 2)
+	for t, side := range tm.sides {
+		views[t].Incoming = tm.meter[t]
+		for _, pl := range side {
+			st := *tm.stats[pl]
+			views[t].Players = append(views[t].Players, st)
+			views[t].Score += st.Score
+			views[t].Words += st.Words
+		}
+	}
+	return views
+}
+
+// send writes msg to every player still on the match screen.
+func (tm *teamMatch) send(msg netMsg) {
+	for _, side := range tm.sides {
+		for _, pl := range side {
+			if pl.teamMatch == tm {
+				pl.send(msg)
+			}
+		}
+	}
+}
+
+// hostTeam opens a 2v2 room with pl on the first side. The caller holds
+// l.mu.
+func (l *lobby) hostTeam(pl *lobbyPlayer) {
+	l.leaveRoom(pl)
+	l.nextID++
+	r := &teamRoom{id: l.nextID}
+	r.sides[0] = []*lobbyPlayer{pl}
+	l.teamRooms = append(l.teamRooms, r)
+	pl.room, pl.state = r, "team"
+	l.announce()
+}
+
+// joinTeam puts pl on the shorter side of room r, starting the match once
+// it is full. The caller holds l.mu.
+func (l *lobby) joinTeam(pl *lobbyPlayer, r *teamRoom) {
+	l.leaveRoom(pl)
+	t := 0
+	if len(r.sides[1]) < len(r.sides[0]) {
+		t = 1
+	}
+	r.sides[t] = append(r.sides[t], pl)
+	pl.room, pl.state = r, "team"
+	if r.players() == 2*teamSize {
+		l.startTeams(r)
+	}
+	l.announce()
+}
+
+// switchSides moves pl to the other side of their room if it has space.
+// The caller holds l.mu.
+func (l *lobby) switchSides(pl *lobbyPlayer) {
+	r := pl.room
+	if r == nil {
+		return
+	}
+	t := r.side(pl)
+	if len(r.sides[1-t]) >= teamSize {
+		pl.send(netMsg{Type: "error", Text: "the other side is full"})
+		return
+	}
+	r.remove(pl)
+	r.sides[1-t] = append(r.sides[1-t], pl)
+	l.announce()
+}
+
+// teamRoom is the 2v2 room with id, or nil. The caller holds l.mu.
+func (l *lobby) teamRoom(id int) *teamRoom {
+	for _, r := range l.teamRooms {
+		if r.id == id && r.players() < 2*teamSize {
+			return r
+		}
+	}
+	return nil
+}
+
+// leaveRoom takes pl out of the room they are waiting in, closing it once
+// empty. The caller holds l.mu.
+func (l *lobby) leaveRoom(pl *lobbyPlayer) {
+	r := pl.room
+	if r == nil {
+		return
+	}
+	r.remove(pl)
+	pl.room, pl.state = nil, ""
+	if r.players() == 0 {
+		for i, other := range l.teamRooms {
+			if other == r {
+				l.teamRooms = append(l.teamRooms[:i], l.teamRooms[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// startTeams turns the full room r into a match. The caller holds l.mu.
+func (l *lobby) startTeams(r *teamRoom) {
+	for i, other := range l.teamRooms {
+		if other == r {
+			l.teamRooms = append(l.teamRooms[:i], l.teamRooms[i+1:]...)
+			break
+		}
+	}
+	tm := &teamMatch{sides: r.sides, stats: map[*lobbyPlayer]*teamPlayer{}}
+	var ratings [2]int
+	for t, side := range r.sides {
+		for _, pl := range side {
+			pl.room, pl.state, pl.teamMatch = nil, "", tm
+			tm.stats[pl] = &teamPlayer{Name: pl.name, Lives: startLives + pl.handicap.Lives}
+			ratings[t] += pl.rating.Rating
+		}
+	}
+	views := tm.views()
+	ranked := rated(slices.Concat(r.sides[:]...)...)
+	for t, side := range r.sides {
+		for _, pl := range side {
+			pl.send(netMsg{Type: "matched", Name: teamNames[1-t] + " team", Rating: ratings[1-t] / teamSize, Rated: ranked, Team: t, Teams: views})
+		}
+	}
+	logger.Info("lobby team match", "blue", r.listing().Teams[0], "orange", r.listing().Teams[1])
+}
+
+// relayTeam handles a message from pl during their team match. The caller
+// holds l.mu.
+func (l *lobby) relayTeam(pl *lobbyPlayer, msg netMsg) {
+	tm := pl.teamMatch
+	t := tm.side(pl)
+	st := tm.stats[pl]
+	switch msg.Type {
+	case "state":
+		st.Score, st.Lives, st.Words, st.Ping = msg.Score, msg.Lives, msg.Words, msg.Ping
+		if tm.meter[t] > 0 && !st.Out && !tm.over {
+			// Hand the meter out a word at a time to whoever reports in
+			tm.meter[t]--
+			pl.send(netMsg{Type: "garbage", Count: 1, At: stamp(time.Now())})
+		}
+	case "garbage":
+		cancel := min(msg.Count, tm.meter[t])
+		tm.meter[t] -= cancel
+		tm.meter[1-t] += msg.Count - cancel
+	case "lost":
+		st.Score, st.Words = max(st.Score, msg.Score), max(st.Words, msg.Words)
+		l.teamOut(pl)
+	case "chat":
+		msg.Name = pl.name
+		for _, side := range tm.sides {
+			for _, other := range side {
+				if other != pl && other.teamMatch == tm {
+					other.send(msg)
+				}
+			}
+		}
+		return
+	default:
+		return
+	}
+	tm.send(netMsg{Type: "teams", Teams: tm.views()})
+}
+
+// teamOut marks pl out of lives, ending the match if that beats their
+// team. The caller holds l.mu.
+func (l *lobby) teamOut(pl *lobbyPlayer) {
+	tm := pl.teamMatch
+	st := tm.stats[pl]
+	if st.Out || tm.over {
+		return
+	}
+	st.Out, st.Lives = true, 0
+	t := tm.side(pl)
+	for _, mate := range tm.sides[t] {
+		if !tm.stats[mate].Out {
+			return
+		}
+	}
+	tm.over = true
+	for _, winner := range tm.sides[1-t] {
+		if winner.teamMatch == tm {
+			winner.send(netMsg{Type: "lost"})
+		}
+	}
+	l.rate(tm.sides[1-t], tm.sides[t])
+	logger.Info("lobby team match over", "winner", teamNames[1-t])
+}
+
+// Client
+
+// withTeams sets m up for a team match from the lobby's "matched".
+func (m model) withTeams(matched netMsg) model {
+	m.team = matched.Team
+	m.teams = matched.Teams
+	m.opponent = opponent{name: matched.Name, rating: matched.Rating, lives: m.lives, level: m.level}
+	return m
+}
+
+// handleTeams takes in the lobby's standings, keeping the opponent fields
+// on the other team's totals for the game over screen.
+func (m model) handleTeams(teams []teamView) model {
+	if len(teams) != 2 {
+		return m
+	}
+	m.teams = teams
+	other := teams[1-m.team]
+	m.opponent.score, m.opponent.words, m.opponent.lives = other.Score, other.Words, 0
+	for _, pl := range other.Players {
+		m.opponent.lives += pl.Lives
+	}
+	return m
+}
+
+// teammateIn reports whether our side is still in a team match.
+func (m model) teammateIn() bool {
+	if m.teams == nil || m.opponent.lost {
+		return false
+	}
+	for _, pl := range m.teams[m.team].Players {
+		if !pl.Out && pl.Lives > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// renderTeams draws the side panel for a team match.
+func (m model) renderTeams() []string {
+	var lines []string
+	for i := range m.teams {
+		// Our team first
+		t := (m.team + i) % 2
+		view := m.teams[t]
+		style := m.styles.teams[t]
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, style.Bold(true).Render(fmt.Sprintf("%s TEAM  %d", strings.ToUpper(teamNames[t]), view.Score)))
+		for _, pl := range view.Players {
+			status := fmt.Sprintf("%d %s", pl.Lives, lifeIcon)
+			if pl.Out {
+				status = "out"
+			}
+			lines = append(lines, style.Render(fmt.Sprintf("  %-12s %6d  %-5s %s", pl.Name, pl.Score, status, pingText(pl.Ping))))
+		}
+		lines = append(lines, m.styles.garbage.Render(fmt.Sprintf("  Incoming: %d", view.Incoming)))
+	}
+	return lines
+}
+
+// renderTeamTag is the status line's team widget.
+func (m model) renderTeamTag() string {
+	if m.teams == nil {
+		return ""
+	}
+	return m.styles.teams[m.team].Bold(true).Render(strings.ToUpper(teamNames[m.team]))
+}
+
+// playTeamMatch plays the team match the lobby on p has matched us into,
+// as lm reports it.
+func playTeamMatch(p *peer, dict *dictionary, lm lobbyModel) error {
+	m := initialModel(dict)
+	m.title = false
+	m.peer = p
+	m = m.withHandicaps([2]handicap{lm.handicap})
+	m = m.withTeams(*lm.matched).withLadder(lm)
+	logger.Info("team match start", "team", teamNames[m.team])
+	return runMatch(p, m, true)
+}
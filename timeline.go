@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// A game keeps a timeline of its notable moments, each at the game time it
+// happened, not counting pauses: the level ups, the lives lost and gained,
+// the hints asked for and when the game's best combo peaked. The game over
+// screen lists it and the JSON report and history carry it as "events".
+
+// timelineRows is how many moments the game over screen lists before
+// summing up the rest.
+const timelineRows = 8
+
+// moment is one entry of the timeline.
+type moment struct {
+	// AtMs is the game time in milliseconds
+	AtMs int64 `json:"at_ms"`
+	// Type is level_up, life_lost, life_gained, hint or best_combo
+	Type  string `json:"type"`
+	Level int    `json:"level,omitempty"`
+	Lives int    `json:"lives,omitempty"`
+	Combo int    `json:"combo,omitempty"`
+	Word  string `json:"word,omitempty"`
+}
+
+// note adds a moment of type typ to the timeline, as things stand now;
+// fill adds the details.
+func (m model) note(typ string, fill func(e *moment)) model {
+	e := moment{AtMs: m.gameTime().Milliseconds(), Type: typ, Level: m.level, Lives: m.lives}
+	if fill != nil {
+		fill(&e)
+	}
+	m.tally.Events = append(m.tally.Events, e)
+	return m
+}
+
+// noteCombo moves the best combo's moment to now when the combo beats it.
+func (m model) noteCombo() model {
+	best := 1
+	for i, e := range m.tally.Events {
+		if e.Type == "best_combo" {
+			best = e.Combo
+			if m.combo > best {
+				m.tally.Events = append(m.tally.Events[:i:i], m.tally.Events[i+1:]...)
+			}
+			break
+		}
+	}
+	if m.combo <= best {
+		return m
+	}
+	return m.note("best_combo", func(e *moment) { e.Combo = m.combo })
+}
+
+// describe is the moment as the game over screen lists it.
+func (e moment) describe() string {
+	switch e.Type {
+	case "level_up":
+		return fmt.Sprintf("Level %d - %s", e.Level, levelTitle(e.Level))
+	case "life_lost":
+		return fmt.Sprintf("Lost a life to %q, %d left", e.Word, e.Lives)
+	case "life_gained":
+		return fmt.Sprintf("Extra life, %d now", e.Lives)
+	case "hint":
+		return fmt.Sprintf("Hint on %q", e.Word)
+	case "best_combo":
+		return fmt.Sprintf("Best combo, x%d", e.Combo)
+	}
+	return e.Type
+}
+
+// renderTimeline is the game over screen's timeline, or "" for a game
+// where nothing of note happened.
+func (m model) renderTimeline() string {
+	events := m.tally.Events
+	if len(events) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(m.styles.stats.Render("Timeline:") + "\n")
+	for _, e := range events[:min(len(events), timelineRows)] {
+		d := time.Duration(e.AtMs) * time.Millisecond
+		b.WriteString(m.styles.stats.Render(fmt.Sprintf("  %2d:%02d  %s", int(d.Minutes()), int(d.Seconds())%60, e.describe())) + "\n")
+	}
+	if more := len(events) - timelineRows; more > 0 {
+		b.WriteString(m.styles.help.Render(fmt.Sprintf("  ...and %d more in the report", more)) + "\n")
+	}
+	return b.String()
+}
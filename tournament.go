@@ -0,0 +1,244 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// A lobby started with -bracket runs a single-elimination tournament.
+// Players enter from the lobby browser; once the bracket is full it is
+// seeded by rating and each match starts as soon as both players are back
+// in the lobby. Leaving the lobby forfeits any match not yet played.
+
+var bracketSizes = []int{4, 8, 16}
+
+// bracketSlot is one match in the bracket.
+type bracketSlot struct {
+	a, b    *lobbyPlayer
+	winner  *lobbyPlayer
+	started bool
+}
+
+type tournament struct {
+	size     int
+	entrants []*lobbyPlayer
+	rounds   [][]*bracketSlot
+	champion *lobbyPlayer
+}
+
+// bracketView is the bracket as sent to the lobby browser.
+type bracketView struct {
+	Size     int             `json:"size"`
+	Entrants []string        `json:"entrants"`
+	Rounds   [][]bracketPair `json:"rounds,omitempty"`
+	Champion string          `json:"champion,omitempty"`
+}
+
+type bracketPair struct {
+	A      string `json:"a"`
+	B      string `json:"b"`
+	Winner string `json:"winner,omitempty"`
+	Live   bool   `json:"live,omitempty"`
+}
+
+// seedOrder lists seeds 1..n in bracket order, so the top seeds can only
+// meet in the late rounds: 1 8 4 5 2 7 3 6 for eight players.
+func seedOrder(n int) []int {
+	order := []int{1}
+	for len(order) < n {
+		next := make([]int, 0, len(order)*2)
+		for _, s := range order {
+			next = append(next, s, len(order)*2+1-s)
+		}
+		order = next
+	}
+	return order
+}
+
+func (t *tournament) view() *bracketView {
+	v := &bracketView{Size: t.size}
+	for _, pl := range t.entrants {
+		v.Entrants = append(v.Entrants, pl.name)
+	}
+	for _, round := range t.rounds {
+		var pairs []bracketPair
+		for _, s := range round {
+			p := bracketPair{A: s.a.name, B: s.b.name, Live: s.started && s.winner == nil}
+			if s.winner != nil {
+				p.Winner = s.winner.name
+			}
+			pairs = append(pairs, p)
+		}
+		v.Rounds = append(v.Rounds, pairs)
+	}
+	if t.champion != nil {
+		v.Champion = t.champion.name
+	}
+	return v
+}
+
+// broadcast sends msg to every player, in a match or not. The caller
+// holds l.mu.
+func (l *lobby) broadcast(msg netMsg) {
+	for _, pl := range l.players {
+		pl.send(msg)
+	}
+}
+
+// enter adds pl to the tournament, starting it once the bracket is full.
+// The caller holds l.mu.
+func (l *lobby) enter(pl *lobbyPlayer) {
+	t := l.tourney
+	switch {
+	case t == nil:
+		pl.send(netMsg{Type: "error", Text: "this lobby isn't running a tournament"})
+		return
+	case t.champion != nil:
+		// The last one is over: start a fresh bracket
+		t = &tournament{size: t.size}
+		l.tourney = t
+	case len(t.rounds) > 0:
+		pl.send(netMsg{Type: "error", Text: "the tournament has already started"})
+		return
+	case slices.Contains(t.entrants, pl):
+		return
+	}
+	t.entrants = append(t.entrants, pl)
+	l.broadcast(netMsg{Type: "announce", Text: fmt.Sprintf("%s entered the tournament (%d/%d)", pl.name, len(t.entrants), t.size)})
+	if len(t.entrants) == t.size {
+		seeded := slices.Clone(t.entrants)
+		sort.SliceStable(seeded, func(i, j int) bool { return seeded[i].rating.Rating > seeded[j].rating.Rating })
+		order := seedOrder(t.size)
+		var round []*bracketSlot
+		for i := 0; i < len(order); i += 2 {
+			round = append(round, &bracketSlot{a: seeded[order[i]-1], b: seeded[order[i+1]-1]})
+		}
+		t.rounds = append(t.rounds, round)
+		l.broadcast(netMsg{Type: "announce", Text: "The tournament begins: " + roundName(len(round))})
+		l.advance()
+	}
+	l.broadcast(netMsg{Type: "bracket", Bracket: t.view()})
+}
+
+// withdraw takes a departing player out of a bracket that hasn't started
+// yet. The caller holds l.mu.
+func (l *lobby) withdraw(pl *lobbyPlayer) {
+	t := l.tourney
+	if t == nil || len(t.rounds) > 0 {
+		return
+	}
+	if i := slices.Index(t.entrants, pl); i >= 0 {
+		t.entrants = slices.Delete(t.entrants, i, i+1)
+		l.broadcast(netMsg{Type: "bracket", Bracket: t.view()})
+	}
+}
+
+// roundName names a round by how many matches it has.
+func roundName(matches int) string {
+	switch matches {
+	case 1:
+		return "the final"
+	case 2:
+		return "the semi-finals"
+	case 4:
+		return "the quarter-finals"
+	}
+	return fmt.Sprintf("the round of %d", matches*2)
+}
+
+// advance settles walkovers, starts every match whose players are free and
+// moves on to the next round once the current one is decided. The caller
+// holds l.mu.
+func (l *lobby) advance() {
+	t := l.tourney
+	if t == nil || len(t.rounds) == 0 || t.champion != nil {
+		return
+	}
+	changed := false
+	for {
+		round := t.rounds[len(t.rounds)-1]
+		decided := true
+		for _, s := range round {
+			if s.winner != nil {
+				continue
+			}
+			aGone, bGone := l.players[s.a.id] != s.a, l.players[s.b.id] != s.b
+			switch {
+			case !s.started && bGone:
+				s.winner = s.a
+				changed = true
+			case !s.started && aGone:
+				s.winner = s.b
+				changed = true
+			case !s.started && !s.a.busy() && !s.b.busy():
+				s.started = true
+				l.pair(s.a, s.b).slot = s
+				changed = true
+				decided = false
+			default:
+				decided = false
+			}
+		}
+		if !decided {
+			break
+		}
+		changed = true
+		if len(round) == 1 {
+			t.champion = round[0].winner
+			l.broadcast(netMsg{Type: "announce", Text: t.champion.name + " wins the tournament!"})
+			logger.Info("tournament won", "champion", t.champion.name)
+			break
+		}
+		var next []*bracketSlot
+		for i := 0; i < len(round); i += 2 {
+			next = append(next, &bracketSlot{a: round[i].winner, b: round[i+1].winner})
+		}
+		t.rounds = append(t.rounds, next)
+		l.broadcast(netMsg{Type: "announce", Text: "Next up: " + roundName(len(next))})
+	}
+	if changed {
+		l.broadcast(netMsg{Type: "bracket", Bracket: t.view()})
+	}
+}
+
+// renderBracket draws the bracket viewer in the lobby browser.
+func (m lobbyModel) renderBracket() string {
+	var b strings.Builder
+	v := m.bracket
+	b.WriteString("\n" + m.styles.title.Render("TOURNAMENT") + "\n\n")
+	switch {
+	case v == nil:
+		b.WriteString(m.styles.stats.Render("This lobby isn't running a tournament") + "\n")
+	case len(v.Rounds) == 0:
+		b.WriteString(m.styles.stats.Render(fmt.Sprintf("Waiting for players: %d/%d entered", len(v.Entrants), v.Size)) + "\n")
+		for _, name := range v.Entrants {
+			b.WriteString(m.styles.word.Render("  "+name) + "\n")
+		}
+	default:
+		for _, round := range v.Rounds {
+			b.WriteString(m.styles.pause.Render(strings.ToUpper(roundName(len(round))[4:])) + "\n")
+			for _, p := range round {
+				a, c := m.styles.word.Render(p.A), m.styles.word.Render(p.B)
+				switch p.Winner {
+				case p.A:
+					a = m.styles.highlight.Render(p.A)
+				case p.B:
+					c = m.styles.highlight.Render(p.B)
+				}
+				line := "  " + a + m.styles.help.Render(" vs ") + c
+				if p.Live {
+					line += m.styles.help.Render("  (playing)")
+				}
+				b.WriteString(line + "\n")
+			}
+			b.WriteString("\n")
+		}
+		if v.Champion != "" {
+			b.WriteString(m.styles.title.Render("Champion: "+v.Champion) + "\n")
+		}
+	}
+	b.WriteString("\n" + m.styles.help.Render("[t: enter | b: back to the lobby | q: quit]"))
+	return b.String()
+}
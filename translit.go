@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// A dictionary file can show its words in one script and take them typed
+// in another: a header line naming the columns, such as
+//
+//	#! word typed category definition
+//
+// puts the displayed word first and its transliteration second. The game
+// keeps words by their typed form, so matching, scoring and the history
+// all work on what is typed, and only drawing looks up the display form.
+// Without a header the columns are word, category and definition.
+
+// dictHeader starts the header line naming a dictionary file's columns.
+const dictHeader = "#!"
+
+// dictColumns are the column names a header can use.
+var dictColumns = []string{"word", "typed", "category", "definition"}
+
+// defaultColumns is the layout of a file without a header.
+var defaultColumns = map[string]int{"word": 0, "category": 1, "definition": 2}
+
+// parseColumns reads a header line into each column's position.
+func parseColumns(line string) (map[string]int, error) {
+	columns := map[string]int{}
+	for i, name := range strings.Fields(strings.TrimPrefix(line, dictHeader)) {
+		name = strings.ToLower(name)
+		if !slices.Contains(dictColumns, name) {
+			return nil, fmt.Errorf("unknown dictionary column %q, want one of %s", name, strings.Join(dictColumns, ", "))
+		}
+		if _, dup := columns[name]; dup {
+			return nil, fmt.Errorf("dictionary column %q given twice", name)
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["word"]; !ok {
+		return nil, fmt.Errorf("dictionary header has no word column")
+	}
+	return columns, nil
+}
+
+// column is the trimmed field of fields named name, or "" when the file
+// doesn't have it.
+func column(fields []string, columns map[string]int, name string) string {
+	i, ok := columns[name]
+	if !ok || i >= len(fields) {
+		return ""
+	}
+	return strings.TrimSpace(fields[i])
+}
+
+// transliterate records the display forms of the dictionary's words.
+func (d *dictionary) transliterate(display map[string]string) {
+	d.display = map[string]string{}
+	for _, w := range d.words {
+		if s, ok := display[w]; ok {
+			d.display[w] = s
+		}
+	}
+}
+
+// shown is how the typed word text is drawn: its display form when the
+// dictionary has one.
+func (m model) shown(text string) []rune {
+	if s, ok := m.dict.display[text]; ok {
+		return []rune(s)
+	}
+	return []rune(text)
+}
+
+// shownWidth is how many columns the word text takes on screen.
+func (m model) shownWidth(text string) int {
+	return len(m.shown(text))
+}
+
+// typedAt is the letter of a typed word of length typed that the displayed
+// letter i of shown stands for, spreading the typed letters evenly over
+// the displayed ones.
+func typedAt(i, shown, typed int) int {
+	if shown == typed {
+		return i
+	}
+	return i * typed / shown
+}
+
+// shownAt is the first displayed letter standing for typed letter i, the
+// inverse of typedAt.
+func shownAt(i, shown, typed int) int {
+	if shown == typed {
+		return i
+	}
+	return (i*shown + typed - 1) / typed
+}
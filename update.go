@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// releasesURL is the GitHub API endpoint for the newest published release.
+const releasesURL = "https://api.github.com/repos/splinesreticulating/letter-invaders-go/releases/latest"
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// downloadClient fetches release binaries. A slow link can take minutes
+// over one, so only connecting and the response headers are timed, and the
+// body is given up on only if it stalls for downloadStall.
+var downloadClient = &http.Client{Transport: &http.Transport{
+	Proxy:                 http.ProxyFromEnvironment,
+	DialContext:           (&net.Dialer{Timeout: 10 * time.Second}).DialContext,
+	TLSHandshakeTimeout:   10 * time.Second,
+	ResponseHeaderTimeout: 10 * time.Second,
+}}
+
+const downloadStall = 30 * time.Second
+
+type release struct {
+	TagName string `json:"tag_name"`
+	Assets  []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+// updateAvailableMsg reports a newer release found by the update check.
+type updateAvailableMsg string
+
+func latestRelease() (*release, error) {
+	resp, err := httpClient.Get(releasesURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("checking releases: %s", resp.Status)
+	}
+	var r release
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// checkUpdateCmd looks for a newer release in the background. Failures are
+// only logged: being offline shouldn't get in the way of playing.
+func checkUpdateCmd() tea.Cmd {
+	return func() tea.Msg {
+		r, err := latestRelease()
+		if err != nil {
+			logger.Warn("update check", "err", err)
+			return nil
+		}
+		if newerVersion(r.TagName, version) {
+			return updateAvailableMsg(r.TagName)
+		}
+		return nil
+	}
+}
+
+// parseVersion splits "v1.2.3" into its numeric parts. Pre-release and
+// build suffixes are ignored.
+func parseVersion(v string) ([3]int, bool) {
+	var parts [3]int
+	v = strings.TrimPrefix(v, "v")
+	v, _, _ = strings.Cut(v, "-")
+	v, _, _ = strings.Cut(v, "+")
+	fields := strings.Split(v, ".")
+	if len(fields) != 3 {
+		return parts, false
+	}
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
+
+// newerVersion reports whether latest is a higher version than current.
+// Development builds have no comparable version and never report updates.
+func newerVersion(latest, current string) bool {
+	l, ok := parseVersion(latest)
+	if !ok {
+		return false
+	}
+	c, ok := parseVersion(current)
+	if !ok {
+		return false
+	}
+	for i := range l {
+		if l[i] != c[i] {
+			return l[i] > c[i]
+		}
+	}
+	return false
+}
+
+// assetName is the release asset for this platform, e.g.
+// letter-invaders-go_linux_amd64.
+func assetName() string {
+	name := fmt.Sprintf("letter-invaders-go_%s_%s", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// selfUpdate replaces the running binary with the latest release, once the
+// download matches the release's checksums.txt. A release without one is
+// refused rather than installed unchecked.
+func selfUpdate(force bool) error {
+	r, err := latestRelease()
+	if err != nil {
+		return err
+	}
+	if !force && !newerVersion(r.TagName, version) {
+		fmt.Printf("Already up to date (%s, latest %s).\n", version, r.TagName)
+		return nil
+	}
+
+	var binURL, sumsURL string
+	for _, a := range r.Assets {
+		switch a.Name {
+		case assetName():
+			binURL = a.URL
+		case "checksums.txt":
+			sumsURL = a.URL
+		}
+	}
+	if binURL == "" {
+		return fmt.Errorf("release %s has no asset %s", r.TagName, assetName())
+	}
+	if sumsURL == "" {
+		return fmt.Errorf("release %s has no checksums.txt to verify the download against", r.TagName)
+	}
+	want, err := releaseChecksum(sumsURL, assetName())
+	if err != nil {
+		return err
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	exe, err = filepath.EvalSymlinks(exe)
+	if err != nil {
+		return err
+	}
+
+	// Download next to the binary so the final rename stays on one filesystem
+	tmp, err := os.CreateTemp(filepath.Dir(exe), ".letter-invaders-update-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	sum, err := download(binURL, tmp)
+	tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	if !strings.EqualFold(want, sum) {
+		return fmt.Errorf("checksum mismatch for %s", assetName())
+	}
+
+	if err := os.Chmod(tmp.Name(), 0o755); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), exe); err != nil {
+		return err
+	}
+	fmt.Printf("Updated %s to %s.\n", exe, r.TagName)
+	return nil
+}
+
+// download writes url to w and returns the hex SHA-256 of the content.
+func download(url string, w io.Writer) (string, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := downloadClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("downloading %s: %s", url, resp.Status)
+	}
+	stall := time.AfterFunc(downloadStall, cancel)
+	defer stall.Stop()
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(w, h), stallReader{resp.Body, stall}); err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("downloading %s: stalled for %v", url, downloadStall)
+		}
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// stallReader restarts the stall timer whenever data arrives.
+type stallReader struct {
+	r     io.Reader
+	stall *time.Timer
+}
+
+func (s stallReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	if n > 0 {
+		s.stall.Reset(downloadStall)
+	}
+	return n, err
+}
+
+// releaseChecksum finds name's digest in a sha256sum-style checksums file.
+func releaseChecksum(url, name string) (string, error) {
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("downloading checksums: %s", resp.Status)
+	}
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return fields[0], nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", errors.New("checksums.txt has no entry for " + name)
+}
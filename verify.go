@@ -0,0 +1,146 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Leaderboard submissions carry the game's seed and keystroke log. Nothing
+// the client holds can vouch for a score, so the server replays every game
+// from them with its own dictionary, rejecting the submission if the
+// replay scores it differently. Versus games depend on the opponent's
+// garbage and can't be replayed, so they aren't taken at all: the versus
+// board is the lobby's ladder, see ladder.go.
+
+const (
+	// keyWindow keystrokes in a row averaging under minKeyGap is faster
+	// than anyone types (about 300 WPM)
+	keyWindow = 20
+	minKeyGap = 40 * time.Millisecond
+	// maxReplayTicks bounds how long a game the server will replay
+	maxReplayTicks = 24 * 60 * 60
+)
+
+// keystroke is one logged key: the tick it landed on, milliseconds since
+// the game started, and the letter, or '\b' for backspace and '\n' for
+// enter.
+type keystroke struct {
+	Tick int   `json:"t"`
+	At   int64 `json:"ms"`
+	Key  byte  `json:"k"`
+}
+
+func (m model) logKey(k byte, now time.Time) model {
+	m.keys = append(m.keys, keystroke{Tick: m.ticks, At: now.Sub(m.startTime).Milliseconds(), Key: k})
+	return m
+}
+
+// keyDigest summarises a keystroke log, which the board keeps in place of
+// the log itself.
+func keyDigest(keys []keystroke) string {
+	h := sha256.New()
+	for _, k := range keys {
+		fmt.Fprintf(h, "%d %d %d\n", k.Tick, k.At, k.Key)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// checkIntervals rejects keystroke logs that go backwards in time or
+// sustain an impossible typing speed.
+func checkIntervals(keys []keystroke) error {
+	for i := 1; i < len(keys); i++ {
+		if keys[i].At < keys[i-1].At || keys[i].Tick < keys[i-1].Tick {
+			return errors.New("keystrokes out of order")
+		}
+	}
+	for i := keyWindow; i < len(keys); i++ {
+		span := time.Duration(keys[i].At-keys[i-keyWindow].At) * time.Millisecond
+		if span < keyWindow*minKeyGap {
+			return fmt.Errorf("%d keystrokes in %v", keyWindow, span)
+		}
+	}
+	return nil
+}
+
+// replayModel sets up the game e was played as, ready to be stepped
+// through with replayKeys.
+func replayModel(dict *dictionary, e boardEntry) (model, error) {
+	m := initialModel(dict)
+	m.seed = e.Seed
+	m.rng.Seed(e.Seed)
+	m.title = false
+	if e.Start > 1 {
+		m.startLevel, m.level = e.Start, e.Start
+	}
+	m.levelTicks = e.LevelTicks
+	m.requireEnter = e.Enter
+	if e.Mode == "coop" {
+		return m.withCoop()
+	}
+	return m, nil
+}
+
+// replayKeys applies the logged keystrokes due by the current tick and
+// returns the rest.
+func (m model) replayKeys(keys []keystroke) (model, []keystroke) {
+	for len(keys) > 0 && keys[0].Tick <= m.ticks {
+		switch keys[0].Key {
+		case '\b':
+			m = m.backspace()
+		case '\n':
+			m = m.commit()
+		default:
+			m = m.typeLetter(rune(keys[0].Key))
+		}
+		keys = keys[1:]
+	}
+	return m, keys
+}
+
+// replay plays a submitted game again from its seed and keystroke log and
+// returns the resulting score and words typed.
+func replay(dict *dictionary, e boardEntry) (score, words int, err error) {
+	m, err := replayModel(dict, e)
+	if err != nil {
+		return 0, 0, err
+	}
+	keys := e.Keys
+	for {
+		m, keys = m.replayKeys(keys)
+		if m.ticks >= e.Ticks || m.gameOver {
+			break
+		}
+		m = m.step()
+	}
+	return m.score + m.partner.score, m.wordsTyped + m.partner.words, nil
+}
+
+// verify checks a submission before it is ranked.
+func (s *boardStore) verify(e boardEntry) error {
+	if e.Mode == "versus" {
+		return errors.New("versus matches are ranked by the lobby, not submitted")
+	}
+	if keyDigest(e.Keys) != e.Digest {
+		return errors.New("keystroke digest mismatch")
+	}
+	if err := checkIntervals(e.Keys); err != nil {
+		return err
+	}
+	if e.Dict != s.dict.digest() {
+		return errors.New("can't replay: played with a different dictionary")
+	}
+	if e.Ticks < 0 || e.Ticks > maxReplayTicks {
+		return errors.New("can't replay: game too long")
+	}
+	score, words, err := replay(s.dict, e)
+	if err != nil {
+		return fmt.Errorf("can't replay: %w", err)
+	}
+	if score != e.Score || words != e.Words {
+		return fmt.Errorf("replay scored %d with %d words", score, words)
+	}
+	return nil
+}
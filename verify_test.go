@@ -0,0 +1,142 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// playedEntry plays a standard game through Update on simulated time,
+// typing out the lowest word every few ticks, and returns its leaderboard
+// submission.
+func playedEntry(t *testing.T, dict *dictionary, seed int64) boardEntry {
+	t.Helper()
+	m := initialModel(dict)
+	m.seed = seed
+	m.rng.Seed(seed)
+	m.title = false
+	m.frameRate = tickRate
+	m.width, m.height = 120, 40
+	m.board = leaderboard{name: "test"}
+	now := time.Now()
+	for i := 0; i < 3000 && !m.gameOver; i++ {
+		now = now.Add(tickRate)
+		m = benchUpdate(m, tickMsg(now))
+		if i%6 != 0 || len(m.words) == 0 {
+			continue
+		}
+		low := m.words[0]
+		for _, w := range m.words {
+			if w.y > low.y {
+				low = w
+			}
+		}
+		for _, r := range low.text {
+			m = benchUpdate(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+	}
+	if m.score == 0 {
+		t.Fatal("the scripted game scored nothing")
+	}
+	// Keys are stamped with the wall clock; space them out like a fast
+	// typist's
+	for i := range m.keys {
+		m.keys[i].At = int64(i) * 100
+	}
+	return m.boardEntry()
+}
+
+func TestReplayDeterministic(t *testing.T) {
+	dict, err := loadDictionary("short_words.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := playedEntry(t, dict, 42)
+	for range 2 {
+		score, words, err := replay(dict, e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if score != e.Score || words != e.Words {
+			t.Fatalf("replay scored %d with %d words, played %d with %d", score, words, e.Score, e.Words)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	dict, err := loadDictionary("short_words.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	played := playedEntry(t, dict, 7)
+	s := &boardStore{dict: dict}
+	tests := []struct {
+		name   string
+		tamper func(e *boardEntry)
+		ok     bool
+	}{
+		{"as played", func(e *boardEntry) {}, true},
+		{"score raised", func(e *boardEntry) { e.Score += 10 }, false},
+		{"words raised", func(e *boardEntry) { e.Words++ }, false},
+		{"other seed", func(e *boardEntry) { e.Seed++ }, false},
+		{"key dropped", func(e *boardEntry) {
+			e.Keys = e.Keys[1:]
+			e.Digest = keyDigest(e.Keys)
+		}, false},
+		{"digest mismatch", func(e *boardEntry) { e.Digest = keyDigest(nil) }, false},
+		{"other dictionary", func(e *boardEntry) { e.Dict = "elsewhere" }, false},
+		{"too long", func(e *boardEntry) { e.Ticks = maxReplayTicks + 1 }, false},
+		{"typed too fast", func(e *boardEntry) {
+			for i := range e.Keys {
+				e.Keys[i].At = int64(i)
+			}
+			e.Digest = keyDigest(e.Keys)
+		}, false},
+		{"versus", func(e *boardEntry) { e.Mode = "versus" }, false},
+		{"forged versus", func(e *boardEntry) {
+			e.Mode = "versus"
+			e.Score += 10
+			e.Keys, e.Digest = nil, keyDigest(nil)
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := played
+			e.Keys = append([]keystroke(nil), played.Keys...)
+			tt.tamper(&e)
+			err := s.verify(e)
+			if tt.ok && err != nil {
+				t.Errorf("rejected: %v", err)
+			}
+			if !tt.ok && err == nil {
+				t.Error("accepted")
+			}
+		})
+	}
+}
+
+func TestLadderPage(t *testing.T) {
+	s, err := openAccountStore(t.TempDir() + "/accounts.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range []accountRecord{
+		{ID: "a", Name: "ann"},
+		{ID: "b", Name: "bob"},
+		{ID: "c", Name: "cat"},
+		{ID: "a", Match: &ladderMatch{OpponentRating: startRating, Won: true}},
+		{ID: "b", Match: &ladderMatch{OpponentRating: startRating}},
+	} {
+		if err := s.write(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p := s.ladderPage(0, boardPageSize)
+	if p.Total != 2 || len(p.Entries) != 2 {
+		t.Fatalf("ladder has %d of %d entries, want the 2 rated accounts", len(p.Entries), p.Total)
+	}
+	if a, b := p.Entries[0], p.Entries[1]; a.Name != "ann" || b.Name != "bob" || a.Rating <= b.Rating || a.Rank != 1 || a.Games != 1 {
+		t.Errorf("ladder = %+v", p.Entries)
+	}
+}
@@ -0,0 +1,471 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Versus mode connects two players over TCP. Each side plays its own game
+// and streams its state to the other as newline-delimited JSON; the first
+// to run out of lives loses.
+
+// netMsg is one message on the versus connection.
+type netMsg struct {
+	// Type is "hello" when connecting, "state" every tick, "garbage" to
+	// inject words into the receiver's game, "lost" when the sender runs
+	// out of lives, "chat" for a line of post-match chat and "leave" when
+	// the sender hangs up; see protocol.go for the rest
+	Type string `json:"type"`
+	// Version and Token are sent when connecting, see protocol.go
+	Version int    `json:"version,omitempty"`
+	Token   string `json:"token,omitempty"`
+
+	Name  string `json:"name,omitempty"`
+	Score int    `json:"score,omitempty"`
+	Lives int    `json:"lives,omitempty"`
+	Level int    `json:"level,omitempty"`
+	Words int    `json:"words,omitempty"`
+	// Count is the number of garbage words in a "garbage" message
+	Count int `json:"count,omitempty"`
+	// At stamps pings, pongs and garbage with the sender's clock, Echo is
+	// the ping a pong answers and Ping the sender's latency in "state", in
+	// milliseconds; see latency.go
+	At   int64 `json:"at,omitempty"`
+	Echo int64 `json:"echo,omitempty"`
+	Ping int   `json:"ping,omitempty"`
+	// Handicap is the sender's, in "hello" and the lobby's "matched"
+	Handicap *handicap `json:"handicap,omitempty"`
+	// Team is the player's side and Teams the standings in a team match,
+	// see team.go
+	Team  int        `json:"team,omitempty"`
+	Teams []teamView `json:"teams,omitempty"`
+	// The rest is only used when talking to a lobby
+	Rating  int          `json:"rating,omitempty"`
+	Room    int          `json:"room,omitempty"`
+	Rooms   []lobbyRoom  `json:"rooms,omitempty"`
+	Players int          `json:"players,omitempty"`
+	Text    string       `json:"text,omitempty"`
+	Bracket *bracketView `json:"bracket,omitempty"`
+	// Account signs in to the lobby in "hello", Ladder is the player's own
+	// rating in "welcome" and the "rooms" after a match, and Rated marks a
+	// "matched" the lobby rates; see ladder.go
+	Account string  `json:"account,omitempty"`
+	Ladder  *rating `json:"ladder,omitempty"`
+	Rated   bool    `json:"rated,omitempty"`
+	// and these when talking to a classroom
+	Seed     int64    `json:"seed,omitempty"`
+	WPM      int      `json:"wpm,omitempty"`
+	Accuracy float64  `json:"accuracy,omitempty"`
+	WordList []string `json:"word_list,omitempty"`
+}
+
+// peer is the connection to the opponent. Writes come from command
+// goroutines, so they are serialized, and the connection is swapped
+// under mu when a dropped match resumes.
+type peer struct {
+	conn net.Conn
+	mu   sync.Mutex
+	enc  *json.Encoder
+	dec  *json.Decoder
+	// token resumes the match and reconnect finds the new connection for
+	// it; resuming is set while that is under way. See protocol.go
+	token     string
+	reconnect func(token string, deadline time.Time) (*peer, error)
+	resuming  atomic.Bool
+	// rtt is the smoothed round trip and offset the other side's clock
+	// less ours, both in nanoseconds; see latency.go
+	rtt, offset atomic.Int64
+}
+
+func newPeer(conn net.Conn) *peer {
+	return &peer{
+		conn: conn,
+		enc:  json.NewEncoder(conn),
+		dec:  json.NewDecoder(&msgLimiter{r: bufio.NewReader(conn)}),
+	}
+}
+
+// maxNetMsg bounds one message on the wire, so a peer can't send a single
+// value that never ends.
+const maxNetMsg = 1 << 20
+
+// msgLimiter fails a read once a line, one message, runs past maxNetMsg.
+type msgLimiter struct {
+	r    io.Reader
+	line int
+}
+
+func (l *msgLimiter) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	for i, b := range p[:n] {
+		if b == '\n' {
+			l.line = 0
+		} else if l.line++; l.line > maxNetMsg {
+			return i, errors.New("message too large")
+		}
+	}
+	return n, err
+}
+
+// writeTimeout is how long a message may take to send before the
+// connection is given up on.
+const writeTimeout = 10 * time.Second
+
+func (p *peer) send(msg netMsg) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return p.enc.Encode(msg)
+}
+
+// opponent is what we know of the other player's game.
+type opponent struct {
+	name   string
+	rating int
+	score  int
+	lives  int
+	level  int
+	words  int
+	// ping is the opponent's latency in milliseconds, as they report it
+	ping int
+	lost bool
+	gone bool
+	// reconnecting is set while a dropped connection is being resumed
+	reconnecting bool
+	// handicap is the opponent's, see handicap
+	handicap handicap
+}
+
+// opponentMsg delivers a message from the peer to the program.
+type opponentMsg netMsg
+
+// opponentGoneMsg reports that the peer hung up, or that the connection
+// dropped and couldn't be resumed.
+type opponentGoneMsg struct{ err error }
+
+// listen relays messages from the peer into the program until the
+// connection closes, resuming it if it drops.
+func (p *peer) listen(prog *tea.Program) {
+	for {
+		msg, err := p.recv()
+		if err != nil {
+			prog.Send(opponentMsg{Type: "reconnecting"})
+			if err := p.resume(); err != nil {
+				prog.Send(opponentGoneMsg{err})
+				return
+			}
+			prog.Send(opponentMsg{Type: "resumed"})
+			continue
+		}
+		switch msg.Type {
+		case "left":
+			return
+		case "leave":
+			prog.Send(opponentGoneMsg{})
+			return
+		}
+		prog.Send(opponentMsg(msg))
+	}
+}
+
+// sendCmd writes msg to the peer off the update loop.
+func sendCmd(p *peer, msg netMsg) tea.Cmd {
+	return func() tea.Msg {
+		if err := p.send(msg); err != nil {
+			logger.Warn("versus send", "err", err)
+		}
+		return nil
+	}
+}
+
+func (m model) stateMsg() netMsg {
+	return netMsg{Type: "state", Score: m.score, Lives: m.lives, Level: m.level, Words: m.wordsTyped, Ping: int(m.peer.latency().Milliseconds())}
+}
+
+// handleOpponent applies a message from the peer.
+func (m model) handleOpponent(msg opponentMsg) (model, tea.Cmd) {
+	switch msg.Type {
+	case "state":
+		m.opponent.score = msg.Score
+		m.opponent.lives = msg.Lives
+		m.opponent.level = msg.Level
+		m.opponent.words = msg.Words
+		m.opponent.ping = msg.Ping
+	case "teams":
+		m = m.handleTeams(msg.Teams)
+	case "chat":
+		if m.teams != nil {
+			// Team chat comes from any of the other three
+			m.chat = m.chat.receive(msg.Name, msg.Text)
+			break
+		}
+		m.chat = m.chat.receive(m.opponent.name, msg.Text)
+	case "reconnecting", "resumed":
+		back := msg.Type == "resumed"
+		if m.teams != nil && msg.Name != "" {
+			// A teammate or opponent, relayed by the lobby
+			note := " is reconnecting"
+			if back {
+				note = " is back"
+			}
+			m.chat = m.chat.add("", msg.Name+note)
+			break
+		}
+		m.opponent.reconnecting = !back
+	case "garbage":
+		m = m.receiveGarbage(netMsg(msg))
+		logger.Info("garbage received", "count", msg.Count, "pending", len(m.pendingGarbage))
+	case "lost":
+		m.opponent.lost = true
+		m.opponent.lives = 0
+		if m.teams != nil {
+			// The other team is beaten, which wins it for a player already
+			// out as well
+			m.won = true
+		}
+		if !m.gameOver {
+			m.won = true
+			m = m.endGame()
+		}
+	}
+	return m, nil
+}
+
+// renderOpponent draws the side panel shown next to the playfield.
+func (m model) renderOpponent() []string {
+	o := m.opponent
+	status := "playing"
+	switch {
+	case o.gone:
+		status = "disconnected"
+	case o.reconnecting:
+		status = "reconnecting..."
+	case o.lost:
+		status = "out of lives"
+	}
+	lines := []string{
+		m.styles.title.Render("OPPONENT"),
+		m.styles.stats.Render(o.name),
+		m.styles.stats.Render(fmt.Sprintf("Rating: %d", o.rating)),
+		m.styles.stats.Render(fmt.Sprintf("Ping: %s (yours %s)", pingText(o.ping), pingText(int(m.peer.latency().Milliseconds())))),
+	}
+	if o.handicap != (handicap{}) {
+		lines = append(lines, m.styles.stats.Render("Handicap: "+o.handicap.describe()))
+	}
+	if m.handicaps[0] != (handicap{}) {
+		lines = append(lines, m.styles.stats.Render("Yours: "+m.handicaps[0].describe()))
+	}
+	return append(lines,
+		"",
+		m.styles.stats.Render(fmt.Sprintf("Score: %d", o.score)),
+		m.styles.stats.Render(fmt.Sprintf("Lives: %d", o.lives)),
+		m.styles.stats.Render(fmt.Sprintf("Level: %d", o.level)),
+		m.styles.stats.Render(fmt.Sprintf("Words: %d", o.words)),
+		"",
+		m.styles.garbage.Render(fmt.Sprintf("Incoming: %d", len(m.pendingGarbage))),
+		"",
+		m.styles.help.Render(status),
+	)
+}
+
+// versusOptions are the flags accepted by the versus command.
+type versusOptions struct {
+	listen   string
+	connect  string
+	lobby    string
+	name     string
+	dictPath string
+	handicap handicap
+}
+
+func addVersusFlags(fs *flag.FlagSet, opts *versusOptions) {
+	fs.StringVar(&opts.listen, "listen", "", "Host a match, waiting for an opponent on this address (e.g. :4000)")
+	fs.StringVar(&opts.connect, "connect", "", "Join a match hosted at this address (e.g. host:4000)")
+	fs.StringVar(&opts.lobby, "lobby", "", "Find an opponent in the lobby at this address (e.g. host:4001)")
+	fs.StringVar(&opts.name, "name", os.Getenv("USER"), "Name shown to your opponent")
+	fs.StringVar(&opts.dictPath, "d", "/usr/share/dict/words", "Path to dictionary file")
+	fs.Var(&opts.handicap, "handicap", "Your handicap: speed=F,lives=N,shorter=N in any combination")
+}
+
+// versus sets up the connection, trades names and plays the match.
+func versus(opts versusOptions) error {
+	set := 0
+	for _, addr := range []string{opts.listen, opts.connect, opts.lobby} {
+		if addr != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return errors.New("versus needs exactly one of -listen, -connect or -lobby")
+	}
+
+	dict, err := loadDictionary(opts.dictPath)
+	if err != nil {
+		return fmt.Errorf("loading dictionary: %w", err)
+	}
+	if dict.len() == 0 {
+		return errors.New("dictionary is empty")
+	}
+
+	var p *peer
+	switch {
+	case opts.lobby != "":
+		conn, err := net.DialTimeout("tcp", opts.lobby, 10*time.Second)
+		if err != nil {
+			return err
+		}
+		p := newPeer(conn)
+		defer p.close()
+		lm, err := joinLobby(p, opts.name, opts.handicap, ladderToken(opts.lobby))
+		if err != nil {
+			return err
+		}
+		p.reconnect = redial(opts.lobby)
+		go p.heartbeat()
+		for {
+			// Back to the lobby after every match, for the next round of
+			// a tournament or another game
+			if lm, err = browseLobby(lm); err != nil || lm.matched == nil {
+				return err
+			}
+			if lm.matched.Teams != nil {
+				err = playTeamMatch(p, dict, lm)
+			} else {
+				err = playMatch(p, dict, opts.name, lm.handicap, &lm)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	case opts.listen != "":
+		ln, err := net.Listen("tcp", opts.listen)
+		if err != nil {
+			return err
+		}
+		// Kept open for the opponent to reconnect on
+		defer ln.Close()
+		fmt.Printf("Waiting for an opponent on %s...\n", ln.Addr())
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		p = newPeer(conn)
+		p.token = newToken()
+		p.reconnect = reaccept(ln.(*net.TCPListener))
+	default:
+		conn, err := net.DialTimeout("tcp", opts.connect, 10*time.Second)
+		if err != nil {
+			return err
+		}
+		p = newPeer(conn)
+		p.reconnect = redial(opts.connect)
+	}
+	defer p.close()
+	return playMatch(p, dict, opts.name, opts.handicap, nil)
+}
+
+// playMatch trades names and handicaps with the opponent on p and plays the
+// match. In a lobby, lm is the lobby browser that matched us, and the
+// connection is handed back once the player leaves the match.
+func playMatch(p *peer, dict *dictionary, name string, h handicap, lm *lobbyModel) error {
+	conn := p.conn
+	inLobby := lm != nil
+	r, err := loadRating("")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Ignoring unreadable rating: %v\n", err)
+	}
+	hi := netMsg{Type: "hello", Version: protocolVersion, Name: name, Rating: r.Rating, Handicap: &h}
+	if !inLobby {
+		// The host hands out the token; in a lobby it came with "welcome"
+		hi.Token = p.token
+	}
+	if err := p.send(hi); err != nil {
+		return err
+	}
+	hello, err := p.recv()
+	if err == nil && hello.Type == "error" {
+		return errors.New(hello.Text)
+	}
+	if err != nil || hello.Type != "hello" {
+		return fmt.Errorf("handshake with %s failed", conn.RemoteAddr())
+	}
+	if !p.checkVersion(hello) {
+		return errors.New(versionError(hello.Version))
+	}
+	if p.token == "" {
+		p.token = hello.Token
+	}
+	if !inLobby {
+		go p.heartbeat()
+	}
+
+	m := initialModel(dict)
+	m.title = false
+	m.peer = p
+	m.rating = r
+	m.opponent = opponent{name: cleanName(hello.Name), rating: hello.Rating, lives: m.lives, level: m.level}
+	if hello.Handicap != nil {
+		m.opponent.handicap = *hello.Handicap
+		m.opponent.lives += hello.Handicap.Lives
+	}
+	m = m.withHandicaps([2]handicap{h})
+	if m.opponent.rating == 0 {
+		// An older client that doesn't send its rating
+		m.opponent.rating = startRating
+	}
+	if inLobby {
+		m = m.withLadder(*lm)
+	}
+	if m.opponent.name == "" {
+		m.opponent.name = conn.RemoteAddr().String()
+	}
+	logger.Info("versus start", "opponent", m.opponent.name)
+	return runMatch(p, m, inLobby)
+}
+
+// runMatch plays the networked game m against the peer on p and records
+// it once the player leaves.
+func runMatch(p *peer, m model, inLobby bool) error {
+	m.presence = startPresence()
+	defer m.presence.close()
+	gameStarted()
+
+	prog := tea.NewProgram(m, tea.WithAltScreen())
+	listening := make(chan struct{})
+	go func() {
+		p.listen(prog)
+		close(listening)
+	}()
+	final, err := prog.Run()
+	if err != nil {
+		return err
+	}
+
+	fm := final.(model)
+	if !fm.gameOver {
+		// Quitting mid-match concedes it
+		p.send(netMsg{Type: "lost"})
+	}
+	// Saying goodbye spares a direct opponent the wait for a reconnection.
+	// The lobby answers "left" once it stops relaying, which also stops
+	// the listener
+	p.send(netMsg{Type: "leave"})
+	if inLobby {
+		<-listening
+	}
+	if _, err := fm.finish(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error recording game: %v\n", err)
+	}
+	return nil
+}
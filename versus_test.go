@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCleanName(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"  ann  ", "ann"},
+		{"\x1b]0;pwned\x07bob", "]0;pwnedbob"},
+		{"\x1b[2J\x1b[31mcat", "[2J[31mcat"},
+		{"a very long name that goes on", "a very long name"},
+		{"ünïcödé", "ünïcödé"},
+		{"\x00\x1b", ""},
+	}
+	for _, tt := range tests {
+		if got := cleanName(tt.in); got != tt.want {
+			t.Errorf("cleanName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMsgLimiter(t *testing.T) {
+	short := `{"type":"hello","name":"ann"}` + "\n"
+	dec := json.NewDecoder(&msgLimiter{r: bufio.NewReader(strings.NewReader(strings.Repeat(short, 1000)))})
+	for i := range 1000 {
+		var msg netMsg
+		if err := dec.Decode(&msg); err != nil || msg.Name != "ann" {
+			t.Fatalf("message %d: %+v, %v", i, msg, err)
+		}
+	}
+	endless := `{"type":"hello","name":"` + strings.Repeat("a", maxNetMsg)
+	dec = json.NewDecoder(&msgLimiter{r: strings.NewReader(endless)})
+	var msg netMsg
+	if err := dec.Decode(&msg); err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Errorf("endless message: err = %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Most players type slower for their first minute. The stats view shows
+// that warm-up curve, minute by minute from the WPM timelines in the
+// history, against the rest of their games. The warm-up setting eases a
+// game in to match: for that long, words are more likely to come from the
+// short half of the level's band of lengths, less so as it runs out.
+
+const (
+	// warmUpMinutes is how many minutes the curve follows
+	warmUpMinutes    = 5
+	samplesPerMinute = 60 / timelineEvery
+)
+
+// timelineWords is roughly how many words had been typed by timeline
+// sample i, from the running WPM it records.
+func timelineWords(timeline []int, i int) float64 {
+	return float64(timeline[i]) * float64((i+1)*timelineEvery) / 60
+}
+
+// warmUpCurve averages the WPM of each of the first warmUpMinutes minutes
+// over the games that lasted that long, and the WPM after the first
+// minute over the games that went on past it.
+func warmUpCurve(sessions []session) (byMinute []int, rest, games int) {
+	var sums [warmUpMinutes]float64
+	var counts [warmUpMinutes]int
+	var restWords float64
+	var restTime time.Duration
+	for _, s := range sessions {
+		if len(s.Timeline) < 2*samplesPerMinute || s.Duration <= time.Minute {
+			continue
+		}
+		games++
+		prev := 0.0
+		for m := range warmUpMinutes {
+			i := (m+1)*samplesPerMinute - 1
+			if i >= len(s.Timeline) {
+				break
+			}
+			words := timelineWords(s.Timeline, i)
+			sums[m] += words - prev
+			counts[m]++
+			prev = words
+		}
+		restWords += float64(s.WordsTyped) - timelineWords(s.Timeline, samplesPerMinute-1)
+		restTime += s.Duration - time.Minute
+	}
+	for m := range warmUpMinutes {
+		if counts[m] == 0 {
+			break
+		}
+		byMinute = append(byMinute, int(sums[m]/float64(counts[m])+0.5))
+	}
+	if restTime > 0 {
+		rest = int(restWords * 60 / restTime.Seconds())
+	}
+	return byMinute, rest, games
+}
+
+// printWarmUp writes the warm-up part of the stats view.
+func printWarmUp(sessions []session) {
+	byMinute, rest, games := warmUpCurve(sessions)
+	if games == 0 {
+		return
+	}
+	steps := make([]string, len(byMinute))
+	for i, w := range byMinute {
+		steps[i] = fmt.Sprintf("%d", w)
+	}
+	fmt.Printf("\nWarm-up (%d %s of two minutes or more):\n", games, plural(games, "game"))
+	fmt.Printf("  By minute:  %s WPM\n", strings.Join(steps, ", "))
+	fmt.Printf("  After the first minute: %d WPM", rest)
+	if rest > 0 {
+		fmt.Printf(", the first is %+.0f%%", float64(byMinute[0]-rest)*100/float64(rest))
+	}
+	fmt.Println()
+}
+
+// warmUpBand is the top of the band of lengths to deal from while the game
+// warms up: the short half of lo to hi, with a chance that falls to none
+// as the warm-up runs out.
+func (m model) warmUpBand(lo, hi int) int {
+	left := m.warmUp - m.gameTime()
+	if left <= 0 || hi <= lo {
+		return hi
+	}
+	if m.rng.Float64() < float64(left)/float64(m.warmUp) {
+		return lo + (hi-lo)/2
+	}
+	return hi
+}
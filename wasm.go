@@ -0,0 +1,99 @@
+//go:build js
+
+package main
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"syscall/js"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// The browser build runs the game in the page itself, compiled to
+// WebAssembly (see wasm/build.sh). The page's terminal (web/term.js) shows
+// what the game draws and passes on keystrokes and its size through the
+// letterInvaders global. There is no disk to keep history on, so each
+// visit starts fresh with the short word list built in.
+
+//go:embed short_words.txt
+var builtinWords string
+
+// pageTerminal writes to the page's terminal.
+type pageTerminal struct{ write js.Value }
+
+func (t pageTerminal) Write(p []byte) (int, error) {
+	b := js.Global().Get("Uint8Array").New(len(p))
+	js.CopyBytesToJS(b, p)
+	t.write.Invoke(b)
+	return len(p), nil
+}
+
+func main() {
+	if err := playPage(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// playPage runs one game in the page until the player quits.
+func playPage() error {
+	page := js.Global().Get("letterInvaders")
+	if page.IsUndefined() {
+		return errors.New("the page has no letterInvaders terminal")
+	}
+	dict, err := scanDictionary(strings.NewReader(builtinWords), "short_words.txt", false)
+	if err != nil {
+		return err
+	}
+	out := pageTerminal{page.Get("write")}
+	r := lipgloss.NewRenderer(out)
+	r.SetColorProfile(termenv.TrueColor)
+
+	m := initialModel(dict)
+	m.styles = newStyles(r)
+	m.term = out
+
+	in, keys := io.Pipe()
+	p := tea.NewProgram(m, tea.WithInput(in), tea.WithOutput(out), tea.WithAltScreen(), withFPS(m.frameRate))
+
+	// Callbacks mustn't block, so input goes through a channel to keep
+	// its order
+	typed := make(chan string, 64)
+	go func() {
+		for s := range typed {
+			io.WriteString(keys, s)
+		}
+	}()
+	input := js.FuncOf(func(this js.Value, args []js.Value) any {
+		if len(args) > 0 {
+			typed <- args[0].String()
+		}
+		return nil
+	})
+	resize := js.FuncOf(func(this js.Value, args []js.Value) any {
+		if len(args) > 1 {
+			go p.Send(tea.WindowSizeMsg{Width: args[0].Int(), Height: args[1].Int()})
+		}
+		return nil
+	})
+	defer input.Release()
+	defer resize.Release()
+	page.Set("input", input)
+	page.Set("resize", resize)
+	if ready := page.Get("ready"); ready.Type() == js.TypeFunction {
+		ready.Invoke()
+	}
+
+	_, err = p.Run()
+	close(typed)
+	keys.Close()
+	out.Write([]byte("\r\n\x1b[2mGame over. Reload to play again.\x1b[0m\r\n"))
+	return err
+}
@@ -0,0 +1,55 @@
+#!/bin/sh
+# Builds the browser version of the game into a directory of static files:
+#
+#   wasm/build.sh [OUT]    (default OUT is ./site)
+#
+# Serve OUT with any static file server and open index.html. Bubble Tea
+# has no js port, so the build uses a copy of it with the few functions it
+# leaves out for js filled in: there is no TTY, suspending or SIGWINCH in a
+# page, and the page reports its size itself.
+set -eu
+
+root=$(cd "$(dirname "$0")/.." && pwd)
+out=$(mkdir -p "${1:-site}" && cd "${1:-site}" && pwd)
+work=$(mktemp -d)
+trap 'rm -rf "$work"' EXIT
+
+cd "$root"
+tea=$(go list -m -f '{{.Dir}}' github.com/charmbracelet/bubbletea)
+cp -R "$root/." "$work/game"
+cp -R "$tea" "$work/bubbletea"
+chmod -R u+w "$work/bubbletea"
+cat > "$work/bubbletea/tea_js.go" <<'GO'
+//go:build js
+
+package tea
+
+import (
+	"errors"
+	"os"
+)
+
+func (p *Program) initInput() error { return nil }
+
+func openInputTTY() (*os.File, error) { return nil, errors.New("no TTY in the browser") }
+
+const suspendSupported = false
+
+func suspendProcess() {}
+
+func (p *Program) listenForResize(done chan struct{}) { close(done) }
+GO
+
+cd "$work/game"
+go mod edit -replace "github.com/charmbracelet/bubbletea=$work/bubbletea"
+GOOS=js GOARCH=wasm go build -o "$out/game.wasm" .
+
+goroot=$(go env GOROOT)
+if [ -f "$goroot/lib/wasm/wasm_exec.js" ]; then
+	cp "$goroot/lib/wasm/wasm_exec.js" "$out/"
+else
+	cp "$goroot/misc/wasm/wasm_exec.js" "$out/"
+fi
+cp "$root/web/term.js" "$out/"
+cp "$root/web/wasm.html" "$out/index.html"
+echo "Built the browser game in $out"
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"crypto/rand"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// Browser play runs each game on the server, exactly as over SSH, and
+// streams the terminal output over a WebSocket to a small terminal in the
+// page, web/term.js, built in so nothing is fetched from elsewhere.
+// The page sends keystrokes and its size back as small JSON messages, so
+// nothing needs installing to try the game.
+
+//go:embed web/index.html web/term.js
+var webAssets embed.FS
+
+// webCookie remembers a browser's profile between visits.
+const webCookie = "letter-invaders-profile"
+
+// webMessage is what the page sends: typed input or a new terminal size.
+type webMessage struct {
+	Input string `json:"input,omitempty"`
+	Cols  int    `json:"cols,omitempty"`
+	Rows  int    `json:"rows,omitempty"`
+}
+
+// wsWriter sends everything written to it as binary WebSocket frames. The
+// browser decodes UTF-8 itself, so runes split across writes are fine.
+type wsWriter struct{ c *wsConn }
+
+func (w wsWriter) Write(p []byte) (int, error) {
+	if err := w.c.writeMessage(wsBinary, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// serveWeb serves the browser page at /, its terminal at /term.js and
+// games at /play.
+func serveWeb(addr string, dict *dictionary, opts serverOptions, hub *spectatorHub) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		if c, err := r.Cookie(webCookie); err != nil || !validWebProfile(c.Value) {
+			http.SetCookie(w, &http.Cookie{Name: webCookie, Value: newWebProfile(), Path: "/", MaxAge: 365 * 24 * 60 * 60, HttpOnly: true})
+		}
+		http.ServeFileFS(w, r, webAssets, "web/index.html")
+	})
+	mux.HandleFunc("/term.js", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFileFS(w, r, webAssets, "web/term.js")
+	})
+	mux.HandleFunc("/play", func(w http.ResponseWriter, r *http.Request) {
+		profile := newWebProfile()
+		if c, err := r.Cookie(webCookie); err == nil && validWebProfile(c.Value) {
+			profile = c.Value
+		}
+		c, err := upgradeWebSocket(w, r)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		playWeb(c, profile, dict, opts, hub)
+	})
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			fmt.Fprintf(os.Stderr, "web server: %v\n", err)
+		}
+	}()
+}
+
+// validWebProfile reports whether a cookie holds a profile as
+// newWebProfile makes them: 16 lowercase hex digits. The profile names a
+// directory, so nothing else is let through.
+func validWebProfile(s string) bool {
+	if len(s) != 16 {
+		return false
+	}
+	for _, c := range s {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func newWebProfile() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// playWeb runs one game for a browser until the page closes or the player
+// quits.
+func playWeb(c *wsConn, profile string, dict *dictionary, opts serverOptions, hub *spectatorHub) {
+	out := wsWriter{c}
+	r := lipgloss.NewRenderer(out)
+	r.SetColorProfile(termenv.TrueColor)
+
+	m := initialModel(dict)
+	m.styles = newStyles(r)
+	m.term = out
+	m.idleTimeout = opts.idleTimeout
+	m.frameRate = opts.frameRate
+	m.profile = "web-" + profile
+	m.rating, _ = loadRating(m.profile)
+	m.highScores, _ = loadHighScores(m.profile)
+	m.practice, _ = practiceStatus(m.profile, time.Now())
+	if hub != nil {
+		m.spectators = hub.add(m.profile, "")
+		defer hub.remove(m.profile, m.spectators)
+	}
+
+	in, keys := io.Pipe()
+	p := tea.NewProgram(m, tea.WithInput(in), tea.WithOutput(out), tea.WithAltScreen(), withFPS(m.frameRate))
+
+	metrics.connectedPlayers.Add(1)
+	defer metrics.connectedPlayers.Add(-1)
+	logger.Info("browser connected", "profile", m.profile, "remote", c.conn.RemoteAddr())
+
+	go func() {
+		defer p.Quit()
+		defer keys.Close()
+		for {
+			data, err := c.readMessage()
+			if err != nil {
+				return
+			}
+			var msg webMessage
+			if json.Unmarshal(data, &msg) != nil {
+				continue
+			}
+			if msg.Cols > 0 && msg.Rows > 0 {
+				p.Send(tea.WindowSizeMsg{Width: msg.Cols, Height: msg.Rows})
+			}
+			if msg.Input != "" {
+				if _, err := io.WriteString(keys, msg.Input); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	final, err := p.Run()
+	p.Kill()
+	in.Close()
+	if err != nil {
+		logger.Error("game exited", "profile", m.profile, "err", err)
+	}
+	if fm, ok := final.(model); ok && !fm.title {
+		if _, err := fm.finish(); err != nil {
+			logger.Error("recording game", "profile", fm.profile, "err", err)
+		}
+	}
+	c.writeMessage(wsClose, nil)
+}
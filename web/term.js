@@ -0,0 +1,288 @@
+// A small terminal for Letter Invaders in the browser. It understands what
+// the game draws with: cursor movement, erasing, the alternate screen and
+// SGR colours up to 24-bit. Anything else is skipped. Keystrokes come back
+// out of onData as the bytes a terminal would send.
+"use strict";
+
+const palette = [
+  "#000000", "#cd3131", "#0dbc79", "#e5e510", "#2472c8", "#bc3fbc", "#11a8cd", "#e5e5e5",
+  "#666666", "#f14c4c", "#23d18b", "#f5f543", "#3b8eea", "#d670d6", "#29b8db", "#ffffff",
+];
+
+// color256 is the CSS colour of xterm colour n.
+function color256(n) {
+  if (n < 16) return palette[n];
+  if (n >= 232) {
+    const v = 8 + (n - 232) * 10;
+    return `rgb(${v},${v},${v})`;
+  }
+  n -= 16;
+  const level = (c) => (c === 0 ? 0 : 55 + c * 40);
+  return `rgb(${level(Math.floor(n / 36))},${level(Math.floor(n / 6) % 6)},${level(n % 6)})`;
+}
+
+const plain = { fg: null, bg: null, bold: false, faint: false, italic: false, underline: false, reverse: false };
+
+const keys = {
+  Enter: "\r", Backspace: "\x7f", Tab: "\t", Escape: "\x1b",
+  ArrowUp: "\x1b[A", ArrowDown: "\x1b[B", ArrowRight: "\x1b[C", ArrowLeft: "\x1b[D",
+  Home: "\x1b[H", End: "\x1b[F", PageUp: "\x1b[5~", PageDown: "\x1b[6~", Delete: "\x1b[3~",
+};
+
+class Term {
+  constructor(el) {
+    this.el = el;
+    this.decoder = new TextDecoder();
+    this.pending = "";
+    this.dataHandlers = [];
+    this.resizeHandlers = [];
+    this.cols = 80;
+    this.rows = 24;
+    this.reset();
+    this.fit();
+    window.addEventListener("resize", () => this.fit());
+    document.addEventListener("keydown", (e) => this.key(e));
+    document.addEventListener("paste", (e) => {
+      this.emit(e.clipboardData.getData("text"));
+      e.preventDefault();
+    });
+  }
+
+  reset() {
+    this.x = 0;
+    this.y = 0;
+    this.style = plain;
+    this.saved = { x: 0, y: 0 };
+    this.grid = this.blank(this.rows);
+  }
+
+  blank(n) {
+    return Array.from({ length: n }, () => this.blankRow());
+  }
+
+  blankRow() {
+    return Array.from({ length: this.cols }, () => ({ ch: " ", style: this.style || plain }));
+  }
+
+  onData(fn) { this.dataHandlers.push(fn); }
+  onResize(fn) { this.resizeHandlers.push(fn); }
+  emit(data) { this.dataHandlers.forEach((fn) => fn(data)); }
+
+  // fit sizes the grid to fill its element.
+  fit() {
+    const probe = document.createElement("span");
+    probe.textContent = "M".repeat(10);
+    this.el.appendChild(probe);
+    const cw = probe.getBoundingClientRect().width / 10;
+    const ch = probe.getBoundingClientRect().height;
+    probe.remove();
+    const cols = Math.max(20, Math.floor(this.el.clientWidth / cw));
+    const rows = Math.max(10, Math.floor(this.el.clientHeight / ch));
+    if (cols === this.cols && rows === this.rows) return;
+    this.grid = this.grid.slice(0, rows).map((row) => {
+      row = row.slice(0, cols);
+      while (row.length < cols) row.push({ ch: " ", style: plain });
+      return row;
+    });
+    this.cols = cols;
+    this.rows = rows;
+    while (this.grid.length < rows) this.grid.push(this.blankRow());
+    this.x = Math.min(this.x, cols - 1);
+    this.y = Math.min(this.y, rows - 1);
+    this.draw();
+    this.resizeHandlers.forEach((fn) => fn({ cols, rows }));
+  }
+
+  key(e) {
+    let data = keys[e.key];
+    if (e.ctrlKey && e.key.length === 1) {
+      const c = e.key.toLowerCase().charCodeAt(0);
+      if (c >= 97 && c <= 122) data = String.fromCharCode(c - 96);
+    } else if (!data && !e.metaKey && e.key.length === 1) {
+      data = e.altKey ? "\x1b" + e.key : e.key;
+    }
+    if (data === undefined) return;
+    e.preventDefault();
+    this.emit(data);
+  }
+
+  write(data) {
+    this.pending += typeof data === "string" ? data : this.decoder.decode(data, { stream: true });
+    let i = 0;
+    const s = this.pending;
+    while (i < s.length) {
+      const c = s[i];
+      if (c === "\x1b") {
+        const n = this.escape(s, i);
+        if (n === 0) break; // wait for the rest of the sequence
+        i += n;
+        continue;
+      }
+      i++;
+      if (c === "\r") this.x = 0;
+      else if (c === "\n") this.lineFeed();
+      else if (c === "\b") this.x = Math.max(0, this.x - 1);
+      else if (c >= " ") this.put(c);
+    }
+    this.pending = s.slice(i);
+    if (!this.frame) {
+      this.frame = requestAnimationFrame(() => {
+        this.frame = null;
+        this.draw();
+      });
+    }
+  }
+
+  put(c) {
+    if (this.x >= this.cols) {
+      this.x = 0;
+      this.lineFeed();
+    }
+    this.grid[this.y][this.x++] = { ch: c, style: this.style };
+  }
+
+  lineFeed() {
+    if (this.y < this.rows - 1) {
+      this.y++;
+      return;
+    }
+    this.grid.shift();
+    this.grid.push(this.blankRow());
+  }
+
+  // escape handles the escape sequence at s[i], returning its length, or 0
+  // if it isn't all there yet.
+  escape(s, i) {
+    if (i + 1 >= s.length) return 0;
+    const kind = s[i + 1];
+    if (kind === "]") {
+      // OSC, ended by BEL or ST; ignored
+      for (let j = i + 2; j < s.length; j++) {
+        if (s[j] === "\x07") return j - i + 1;
+        if (s[j] === "\x1b" && s[j + 1] === "\\") return j - i + 2;
+      }
+      return 0;
+    }
+    if (kind === "7") { this.saved = { x: this.x, y: this.y }; return 2; }
+    if (kind === "8") { ({ x: this.x, y: this.y } = this.saved); return 2; }
+    if (kind !== "[") return 2;
+    let j = i + 2;
+    while (j < s.length && (s[j] < "@" || s[j] > "~")) j++;
+    if (j >= s.length) return 0;
+    this.csi(s.slice(i + 2, j), s[j]);
+    return j - i + 1;
+  }
+
+  csi(params, final) {
+    const priv = params.startsWith("?");
+    const args = (priv ? params.slice(1) : params).split(";").map((p) => parseInt(p, 10));
+    const arg = (k, d) => (Number.isNaN(args[k]) || args[k] === undefined ? d : args[k]);
+    if (priv) {
+      if ((final === "h" || final === "l") && args.includes(1049)) this.reset();
+      return;
+    }
+    const clampX = (x) => Math.max(0, Math.min(this.cols - 1, x));
+    const clampY = (y) => Math.max(0, Math.min(this.rows - 1, y));
+    switch (final) {
+      case "A": this.y = clampY(this.y - arg(0, 1)); break;
+      case "B": this.y = clampY(this.y + arg(0, 1)); break;
+      case "C": this.x = clampX(this.x + arg(0, 1)); break;
+      case "D": this.x = clampX(this.x - arg(0, 1)); break;
+      case "E": this.x = 0; this.y = clampY(this.y + arg(0, 1)); break;
+      case "F": this.x = 0; this.y = clampY(this.y - arg(0, 1)); break;
+      case "G": this.x = clampX(arg(0, 1) - 1); break;
+      case "d": this.y = clampY(arg(0, 1) - 1); break;
+      case "H": case "f": this.y = clampY(arg(0, 1) - 1); this.x = clampX(arg(1, 1) - 1); break;
+      case "J": this.eraseDisplay(arg(0, 0)); break;
+      case "K": this.eraseLine(arg(0, 0)); break;
+      case "m": this.sgr(args); break;
+      case "s": this.saved = { x: this.x, y: this.y }; break;
+      case "u": ({ x: this.x, y: this.y } = this.saved); break;
+    }
+  }
+
+  eraseLine(mode, y = this.y) {
+    const [from, to] = mode === 0 ? [this.x, this.cols] : mode === 1 ? [0, this.x + 1] : [0, this.cols];
+    for (let x = from; x < to; x++) this.grid[y][x] = { ch: " ", style: this.style };
+  }
+
+  eraseDisplay(mode) {
+    if (mode === 0) {
+      this.eraseLine(0);
+      for (let y = this.y + 1; y < this.rows; y++) this.grid[y] = this.blankRow();
+    } else if (mode === 1) {
+      for (let y = 0; y < this.y; y++) this.grid[y] = this.blankRow();
+      this.eraseLine(1);
+    } else {
+      this.grid = this.blank(this.rows);
+    }
+  }
+
+  sgr(args) {
+    const st = { ...this.style };
+    for (let k = 0; k < args.length; k++) {
+      const a = Number.isNaN(args[k]) ? 0 : args[k];
+      if (a === 0) Object.assign(st, plain);
+      else if (a === 1) st.bold = true;
+      else if (a === 2) st.faint = true;
+      else if (a === 3) st.italic = true;
+      else if (a === 4) st.underline = true;
+      else if (a === 7) st.reverse = true;
+      else if (a === 22) st.bold = st.faint = false;
+      else if (a === 23) st.italic = false;
+      else if (a === 24) st.underline = false;
+      else if (a === 27) st.reverse = false;
+      else if (a >= 30 && a <= 37) st.fg = palette[a - 30];
+      else if (a >= 90 && a <= 97) st.fg = palette[a - 90 + 8];
+      else if (a >= 40 && a <= 47) st.bg = palette[a - 40];
+      else if (a >= 100 && a <= 107) st.bg = palette[a - 100 + 8];
+      else if (a === 39) st.fg = null;
+      else if (a === 49) st.bg = null;
+      else if (a === 38 || a === 48) {
+        let color = null;
+        if (args[k + 1] === 5) {
+          color = color256(args[k + 2]);
+          k += 2;
+        } else if (args[k + 1] === 2) {
+          color = `rgb(${args[k + 2]},${args[k + 3]},${args[k + 4]})`;
+          k += 4;
+        }
+        if (a === 38) st.fg = color;
+        else st.bg = color;
+      }
+    }
+    this.style = st;
+  }
+
+  css(st) {
+    let fg = st.fg || "#e5e5e5";
+    let bg = st.bg || "transparent";
+    if (st.reverse) [fg, bg] = [st.bg || "#000000", st.fg || "#e5e5e5"];
+    let css = `color:${fg};background:${bg};`;
+    if (st.bold) css += "font-weight:bold;";
+    if (st.faint) css += "opacity:0.6;";
+    if (st.italic) css += "font-style:italic;";
+    if (st.underline) css += "text-decoration:underline;";
+    return css;
+  }
+
+  draw() {
+    const esc = (t) => t.replace(/&/g, "&amp;").replace(/</g, "&lt;").replace(/>/g, "&gt;");
+    const lines = this.grid.map((row) => {
+      let html = "";
+      let run = "";
+      let style = null;
+      for (const cell of row) {
+        if (cell.style !== style) {
+          if (run) html += `<span style="${this.css(style)}">${esc(run)}</span>`;
+          run = "";
+          style = cell.style;
+        }
+        run += cell.ch;
+      }
+      if (run) html += `<span style="${this.css(style)}">${esc(run)}</span>`;
+      return html;
+    });
+    this.el.innerHTML = lines.join("\n");
+  }
+}
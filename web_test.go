@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestWebProfile(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	tests := []struct {
+		cookie string
+		want   bool
+	}{
+		{newWebProfile(), true},
+		{"0123456789abcdef", true},
+		{"0123456789ABCDEF", false},
+		{"0123456789abcde", false},
+		{"../../../../tmp/", false},
+		{"..%2f..%2f..%2fx", false},
+		{"0123456789abcdeg", false},
+	}
+	for _, tt := range tests {
+		if got := validWebProfile(tt.cookie); got != tt.want {
+			t.Errorf("validWebProfile(%q) = %v, want %v", tt.cookie, got, tt.want)
+		}
+	}
+	for _, profile := range []string{"web-../../../../tmp/", "..", `a\b`, "a/b"} {
+		if dir, err := profileDir(profile); err == nil {
+			t.Errorf("profileDir(%q) = %q, want an error", profile, dir)
+		}
+	}
+	if _, err := profileDir("web-0123456789abcdef"); err != nil {
+		t.Errorf("profileDir: %v", err)
+	}
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Just enough of RFC 6455 for spectators and browser play: unfragmented
+// frames each way, no extensions and no TLS. Anything else that arrives,
+// fragmented messages included, closes the connection as a protocol error.
+// Browsers are only let in from pages served by the same host, so another
+// site can't open a game in a visitor's name.
+
+const wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+const (
+	wsCont   = 0x0
+	wsText   = 0x1
+	wsBinary = 0x2
+	wsClose  = 0x8
+	wsPing   = 0x9
+	wsPong   = 0xa
+	// wsMaxMessage bounds a single incoming message
+	wsMaxMessage = 1 << 20
+	// wsMaxControl bounds the payload of close, ping and pong frames
+	wsMaxControl = 125
+)
+
+// Close status codes
+const (
+	wsProtocolError = 1002
+	wsTooBig        = 1009
+)
+
+// wsError is a frame that breaks the protocol, and the status to close the
+// connection with.
+type wsError struct {
+	status uint16
+	reason string
+}
+
+func (e *wsError) Error() string { return "websocket: " + e.reason }
+
+type wsConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	mu   sync.Mutex
+	// client connections mask what they send
+	client bool
+}
+
+func wsAccept(key string) string {
+	h := sha1.Sum([]byte(key + wsGUID))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+// upgradeWebSocket answers a WebSocket handshake and takes over the
+// connection.
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if r.Method != http.MethodGet || !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || key == "" {
+		http.Error(w, "expected a WebSocket handshake", http.StatusBadRequest)
+		return nil, errors.New("not a websocket handshake")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "unsupported WebSocket version", http.StatusUpgradeRequired)
+		return nil, errors.New("unsupported websocket version")
+	}
+	if !sameOrigin(r) {
+		http.Error(w, "cross-origin WebSocket refused", http.StatusForbidden)
+		return nil, fmt.Errorf("websocket from origin %q refused", r.Header.Get("Origin"))
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "can't upgrade", http.StatusInternalServerError)
+		return nil, errors.New("connection can't be hijacked")
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n", wsAccept(key))
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &wsConn{conn: conn, r: rw.Reader}, nil
+}
+
+// sameOrigin reports whether a handshake comes from a page on the host it
+// was sent to. Browsers always send Origin; other clients, like 'watch',
+// send none and are let in.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// dialWebSocket connects to a ws:// or http:// URL.
+func dialWebSocket(rawURL string) (*wsConn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "ws", "http":
+	default:
+		return nil, fmt.Errorf("unsupported scheme %q (use ws:// or http://)", u.Scheme)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "80")
+	}
+	conn, err := net.DialTimeout("tcp", host, httpClient.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	var nonce [16]byte
+	rand.Read(nonce[:])
+	key := base64.StdEncoding.EncodeToString(nonce[:])
+	req := &http.Request{
+		Method: http.MethodGet,
+		URL:    u,
+		Host:   u.Host,
+		Header: http.Header{
+			"Upgrade":               {"websocket"},
+			"Connection":            {"Upgrade"},
+			"Sec-WebSocket-Key":     {key},
+			"Sec-WebSocket-Version": {"13"},
+		},
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != wsAccept(key) {
+		conn.Close()
+		return nil, fmt.Errorf("websocket handshake: %s", resp.Status)
+	}
+	return &wsConn{conn: conn, r: r, client: true}, nil
+}
+
+func (c *wsConn) writeMessage(op byte, payload []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	header := []byte{0x80 | op, 0}
+	switch n := len(payload); {
+	case n < 126:
+		header[1] = byte(n)
+	case n <= 0xffff:
+		header[1] = 126
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header[1] = 127
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+	if c.client {
+		var mask [4]byte
+		rand.Read(mask[:])
+		header[1] |= 0x80
+		header = append(header, mask[:]...)
+		masked := make([]byte, len(payload))
+		for i, b := range payload {
+			masked[i] = b ^ mask[i%4]
+		}
+		payload = masked
+	}
+	if _, err := c.conn.Write(header); err != nil {
+		return err
+	}
+	_, err := c.conn.Write(payload)
+	return err
+}
+
+// readMessage returns the next data message, answering pings on the way.
+// A close frame ends the stream with io.EOF. A frame that breaks the
+// protocol closes the connection with the reason and is returned as an
+// error.
+func (c *wsConn) readMessage() ([]byte, error) {
+	for {
+		op, payload, err := readFrame(c.r, !c.client)
+		var bad *wsError
+		if errors.As(err, &bad) {
+			c.writeMessage(wsClose, binary.BigEndian.AppendUint16(nil, bad.status))
+			return nil, err
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case wsClose:
+			c.writeMessage(wsClose, nil)
+			return nil, io.EOF
+		case wsPing:
+			if err := c.writeMessage(wsPong, payload); err != nil {
+				return nil, err
+			}
+		case wsPong:
+		default:
+			return payload, nil
+		}
+	}
+}
+
+// readFrame reads one frame, unmasking its payload. Frames from clients are
+// masked and frames from servers aren't; masked says which to expect.
+// Fragments, reserved bits, unknown opcodes and oversized frames are all
+// refused with a *wsError.
+func readFrame(r io.Reader, masked bool) (op byte, payload []byte, err error) {
+	var head [2]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return 0, nil, err
+	}
+	fin, op := head[0]&0x80 != 0, head[0]&0x0f
+	if head[0]&0x70 != 0 {
+		return 0, nil, &wsError{wsProtocolError, "reserved bits set"}
+	}
+	switch op {
+	case wsText, wsBinary, wsClose, wsPing, wsPong:
+	case wsCont:
+		return 0, nil, &wsError{wsProtocolError, "fragmented messages aren't supported"}
+	default:
+		return 0, nil, &wsError{wsProtocolError, fmt.Sprintf("unknown opcode %#x", op)}
+	}
+	if !fin {
+		return 0, nil, &wsError{wsProtocolError, "fragmented messages aren't supported"}
+	}
+	if head[1]&0x80 != 0 != masked {
+		return 0, nil, &wsError{wsProtocolError, "wrong masking"}
+	}
+	n := uint64(head[1] & 0x7f)
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	if op >= wsClose && n > wsMaxControl {
+		return 0, nil, &wsError{wsProtocolError, "control frame too large"}
+	}
+	if n > wsMaxMessage {
+		return 0, nil, &wsError{wsTooBig, "message too large"}
+	}
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(r, mask[:]); err != nil {
+			return 0, nil, err
+		}
+	}
+	payload = make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return op, payload, nil
+}
+
+func (c *wsConn) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+)
+
+// wsFrame builds a raw frame: head is the first byte, and mask, if set, is
+// applied to payload.
+func wsFrame(head byte, payload []byte, mask []byte) []byte {
+	b := []byte{head}
+	switch n := len(payload); {
+	case n < 126:
+		b = append(b, byte(n))
+	case n <= 0xffff:
+		b = append(b, 126, byte(n>>8), byte(n))
+	default:
+		b = append(b, 127, 0, 0, 0, 0, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	}
+	if mask == nil {
+		return append(b, payload...)
+	}
+	b[1] |= 0x80
+	b = append(b, mask...)
+	for i, c := range payload {
+		b = append(b, c^mask[i%4])
+	}
+	return b
+}
+
+func TestReadFrame(t *testing.T) {
+	mask := []byte{1, 2, 3, 4}
+	long := bytes.Repeat([]byte("x"), 300)
+	tests := []struct {
+		name    string
+		in      []byte
+		masked  bool
+		op      byte
+		payload []byte
+		status  uint16 // of the *wsError expected, if any
+	}{
+		{"masked text", wsFrame(0x80|wsText, []byte("hello"), mask), true, wsText, []byte("hello"), 0},
+		{"unmasked binary", wsFrame(0x80|wsBinary, []byte{0, 1, 2}, nil), false, wsBinary, []byte{0, 1, 2}, 0},
+		{"16-bit length", wsFrame(0x80|wsText, long, mask), true, wsText, long, 0},
+		{"empty ping", wsFrame(0x80|wsPing, nil, mask), true, wsPing, []byte{}, 0},
+		{"close", wsFrame(0x80|wsClose, []byte{3, 232}, mask), true, wsClose, []byte{3, 232}, 0},
+		{"first fragment", wsFrame(wsText, []byte("hel"), mask), true, 0, nil, wsProtocolError},
+		{"continuation", wsFrame(0x80|wsCont, []byte("lo"), mask), true, 0, nil, wsProtocolError},
+		{"fragmented ping", wsFrame(wsPing, nil, mask), true, 0, nil, wsProtocolError},
+		{"reserved bit", wsFrame(0xc0|wsText, []byte("hi"), mask), true, 0, nil, wsProtocolError},
+		{"unknown opcode", wsFrame(0x80|0x3, []byte("hi"), mask), true, 0, nil, wsProtocolError},
+		{"unmasked from a client", wsFrame(0x80|wsText, []byte("hi"), nil), true, 0, nil, wsProtocolError},
+		{"masked from a server", wsFrame(0x80|wsText, []byte("hi"), mask), false, 0, nil, wsProtocolError},
+		{"long ping", wsFrame(0x80|wsPing, long, mask), true, 0, nil, wsProtocolError},
+		{"too large", []byte{0x80 | wsBinary, 0x80 | 127, 0, 0, 0, 0, 0, 0x20, 0, 0}, true, 0, nil, wsTooBig},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op, payload, err := readFrame(bytes.NewReader(tt.in), tt.masked)
+			var bad *wsError
+			switch {
+			case tt.status != 0:
+				if !errors.As(err, &bad) || bad.status != tt.status {
+					t.Fatalf("err = %v, want status %d", err, tt.status)
+				}
+			case err != nil:
+				t.Fatalf("err = %v", err)
+			case op != tt.op || !bytes.Equal(payload, tt.payload):
+				t.Errorf("got op %#x %q, want %#x %q", op, payload, tt.op, tt.payload)
+			}
+		})
+	}
+}
+
+func TestReadFrameShort(t *testing.T) {
+	in := wsFrame(0x80|wsText, []byte("hello"), []byte{1, 2, 3, 4})
+	for n := range len(in) {
+		if _, _, err := readFrame(bytes.NewReader(in[:n]), true); !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("%d bytes: err = %v, want EOF", n, err)
+		}
+	}
+}
+
+func TestSameOrigin(t *testing.T) {
+	tests := []struct {
+		host, origin string
+		want         bool
+	}{
+		{"play.example.com:8080", "", true},
+		{"play.example.com:8080", "http://play.example.com:8080", true},
+		{"play.example.com:8080", "https://PLAY.example.com:8080", true},
+		{"play.example.com:8080", "http://play.example.com", false},
+		{"play.example.com:8080", "http://evil.example.com:8080", false},
+		{"play.example.com:8080", "null", false},
+		{"play.example.com:8080", "file://play.example.com:8080", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/play", nil)
+		r.Host = tt.host
+		if tt.origin != "" {
+			r.Header.Set("Origin", tt.origin)
+		}
+		if got := sameOrigin(r); got != tt.want {
+			t.Errorf("sameOrigin(%q from %q) = %v, want %v", tt.host, tt.origin, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"time"
+)
+
+// The weekly challenge is the same solo game for everyone for an ISO week
+// (Monday to Sunday, UTC): its seed is derived from the week, and it has
+// its own board on the leaderboard server, one per week.
+
+// weeklyGrace lets games finished just after the rotation still count
+// toward the week they were played in.
+const weeklyGrace = time.Hour
+
+// weekID names the ISO week t falls in, e.g. 2026-W42.
+func weekID(t time.Time) string {
+	year, week := t.UTC().ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
+
+// weeklySeed is the seed every player gets for a week.
+func weeklySeed(week string) int64 {
+	h := fnv.New32a()
+	h.Write([]byte("letter-invaders weekly " + week))
+	return int64(h.Sum32())
+}
+
+// nextWeek is when the week t falls in ends.
+func nextWeek(t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	// Weekday counts from Sunday; ISO weeks start on Monday
+	return day.AddDate(0, 0, 7-(int(t.Weekday())+6)%7)
+}
+
+// weeklyOpen reports whether scores for week are still accepted at now.
+func weeklyOpen(week string, now time.Time) bool {
+	return week == weekID(now) || week == weekID(now.Add(-weeklyGrace))
+}
+
+// withWeekly sets up this week's challenge.
+func (m model) withWeekly(now time.Time) model {
+	m.weekly = weekID(now)
+	m.seed = weeklySeed(m.weekly)
+	m.rng.Seed(m.seed)
+	return m
+}
+
+// weeklyCountdown is the title screen's line about the weekly challenge.
+func weeklyCountdown(now time.Time) string {
+	left := nextWeek(now).Sub(now)
+	days := int(left.Hours()) / 24
+	hours := int(left.Hours()) % 24
+	mins := int(left.Minutes()) % 60
+	return fmt.Sprintf("Weekly challenge %s: next one in %dd %02dh %02dm (play it with -weekly)", weekID(now), days, hours, mins)
+}